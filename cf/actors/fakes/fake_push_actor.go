@@ -2,20 +2,22 @@
 package fakes
 
 import (
-	"os"
 	"sync"
 
 	"github.com/cloudfoundry/cli/cf/actors"
+	"github.com/cloudfoundry/cli/cf/api/application_bits"
 	"github.com/cloudfoundry/cli/cf/api/resources"
 	"github.com/cloudfoundry/cli/cf/models"
+	"github.com/cloudfoundry/cli/cf/terminal"
 )
 
 type FakePushActor struct {
-	UploadAppStub        func(appGuid string, zipFile *os.File, presentFiles []resources.AppFileResource) error
+	UploadAppStub        func(appGuid string, zipSource application_bits.ZipSource, zipSize int64, presentFiles []resources.AppFileResource) error
 	uploadAppMutex       sync.RWMutex
 	uploadAppArgsForCall []struct {
 		appGuid      string
-		zipFile      *os.File
+		zipSource    application_bits.ZipSource
+		zipSize      int64
 		presentFiles []resources.AppFileResource
 	}
 	uploadAppReturns struct {
@@ -42,18 +44,27 @@ type FakePushActor struct {
 		result2 bool
 		result3 error
 	}
+	WithUIStub        func(ui terminal.UI) actors.PushActor
+	withUIMutex       sync.RWMutex
+	withUIArgsForCall []struct {
+		ui terminal.UI
+	}
+	withUIReturns struct {
+		result1 actors.PushActor
+	}
 }
 
-func (fake *FakePushActor) UploadApp(appGuid string, zipFile *os.File, presentFiles []resources.AppFileResource) error {
+func (fake *FakePushActor) UploadApp(appGuid string, zipSource application_bits.ZipSource, zipSize int64, presentFiles []resources.AppFileResource) error {
 	fake.uploadAppMutex.Lock()
 	fake.uploadAppArgsForCall = append(fake.uploadAppArgsForCall, struct {
 		appGuid      string
-		zipFile      *os.File
+		zipSource    application_bits.ZipSource
+		zipSize      int64
 		presentFiles []resources.AppFileResource
-	}{appGuid, zipFile, presentFiles})
+	}{appGuid, zipSource, zipSize, presentFiles})
 	fake.uploadAppMutex.Unlock()
 	if fake.UploadAppStub != nil {
-		return fake.UploadAppStub(appGuid, zipFile, presentFiles)
+		return fake.UploadAppStub(appGuid, zipSource, zipSize, presentFiles)
 	} else {
 		return fake.uploadAppReturns.result1
 	}
@@ -65,10 +76,10 @@ func (fake *FakePushActor) UploadAppCallCount() int {
 	return len(fake.uploadAppArgsForCall)
 }
 
-func (fake *FakePushActor) UploadAppArgsForCall(i int) (string, *os.File, []resources.AppFileResource) {
+func (fake *FakePushActor) UploadAppArgsForCall(i int) (string, application_bits.ZipSource, int64, []resources.AppFileResource) {
 	fake.uploadAppMutex.RLock()
 	defer fake.uploadAppMutex.RUnlock()
-	return fake.uploadAppArgsForCall[i].appGuid, fake.uploadAppArgsForCall[i].zipFile, fake.uploadAppArgsForCall[i].presentFiles
+	return fake.uploadAppArgsForCall[i].appGuid, fake.uploadAppArgsForCall[i].zipSource, fake.uploadAppArgsForCall[i].zipSize, fake.uploadAppArgsForCall[i].presentFiles
 }
 
 func (fake *FakePushActor) UploadAppReturns(result1 error) {
@@ -147,4 +158,36 @@ func (fake *FakePushActor) GatherFilesReturns(result1 []resources.AppFileResourc
 	}{result1, result2, result3}
 }
 
+func (fake *FakePushActor) WithUI(ui terminal.UI) actors.PushActor {
+	fake.withUIMutex.Lock()
+	fake.withUIArgsForCall = append(fake.withUIArgsForCall, struct {
+		ui terminal.UI
+	}{ui})
+	fake.withUIMutex.Unlock()
+	if fake.WithUIStub != nil {
+		return fake.WithUIStub(ui)
+	} else {
+		return fake.withUIReturns.result1
+	}
+}
+
+func (fake *FakePushActor) WithUICallCount() int {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return len(fake.withUIArgsForCall)
+}
+
+func (fake *FakePushActor) WithUIArgsForCall(i int) terminal.UI {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return fake.withUIArgsForCall[i].ui
+}
+
+func (fake *FakePushActor) WithUIReturns(result1 actors.PushActor) {
+	fake.WithUIStub = nil
+	fake.withUIReturns = struct {
+		result1 actors.PushActor
+	}{result1}
+}
+
 var _ actors.PushActor = new(FakePushActor)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/cloudfoundry/cli/cf/api/resources"
 	"github.com/cloudfoundry/cli/cf/app_files"
 	"github.com/cloudfoundry/cli/cf/models"
+	"github.com/cloudfoundry/cli/cf/terminal"
 	"github.com/cloudfoundry/gofileutils/fileutils"
 )
 
@@ -18,9 +20,10 @@ const windowsPathPrefix = `\\?\`
 
 //go:generate counterfeiter -o fakes/fake_push_actor.go . PushActor
 type PushActor interface {
-	UploadApp(appGuid string, zipFile *os.File, presentFiles []resources.AppFileResource) error
-	ProcessPath(dirOrZipFile string, f func(string)) error
+	UploadApp(appGuid string, zipSource application_bits.ZipSource, zipSize int64, presentFiles []resources.AppFileResource) error
+	ProcessPath(dirOrArchive string, f func(string)) error
 	GatherFiles(localFiles []models.AppFileFields, appDir string, uploadDir string) ([]resources.AppFileResource, bool, error)
+	WithUI(ui terminal.UI) PushActor
 }
 
 type PushActorImpl struct {
@@ -37,9 +40,24 @@ func NewPushActor(appBitsRepo application_bits.ApplicationBitsRepository, zipper
 	}
 }
 
-func (actor PushActorImpl) ProcessPath(dirOrZipFile string, f func(string)) error {
-	if !actor.zipper.IsZipFile(dirOrZipFile) {
-		appDir, err := filepath.EvalSymlinks(dirOrZipFile)
+// WithUI returns a copy of the actor whose uploads report their progress to
+// ui, for the apps of a parallel push.
+func (actor PushActorImpl) WithUI(ui terminal.UI) PushActor {
+	actor.appBitsRepo = actor.appBitsRepo.WithUI(ui)
+	return actor
+}
+
+// ProcessPath calls f with the app directory, extracting the app to a
+// temporary directory first when given a zip, jar, war or tar archive.
+func (actor PushActorImpl) ProcessPath(dirOrArchive string, f func(string)) error {
+	var extract func(string, string) error
+	switch {
+	case actor.zipper.IsZipFile(dirOrArchive):
+		extract = actor.zipper.Unzip
+	case actor.zipper.IsTarFile(dirOrArchive):
+		extract = actor.zipper.Untar
+	default:
+		appDir, err := filepath.EvalSymlinks(dirOrArchive)
 		if err != nil {
 			return err
 		}
@@ -65,7 +83,7 @@ func (actor PushActorImpl) ProcessPath(dirOrZipFile string, f func(string)) erro
 	}
 	defer os.RemoveAll(tempDir)
 
-	err = actor.zipper.Unzip(dirOrZipFile, tempDir)
+	err = extract(dirOrArchive, tempDir)
 	if err != nil {
 		return err
 	}
@@ -106,12 +124,9 @@ func (actor PushActorImpl) GatherFiles(localFiles []models.AppFileFields, appDir
 		return []resources.AppFileResource{}, false, err
 	}
 
-	_, err = os.Stat(filepath.Join(appDir, ".cfignore"))
-	if err == nil {
-		err = fileutils.CopyPathToPath(filepath.Join(appDir, ".cfignore"), filepath.Join(uploadDir, ".cfignore"))
-		if err != nil {
-			return []resources.AppFileResource{}, false, err
-		}
+	err = actor.copyIgnoreFiles(appDir, uploadDir)
+	if err != nil {
+		return []resources.AppFileResource{}, false, err
 	}
 
 	for i := range remoteFiles {
@@ -139,6 +154,34 @@ func (actor PushActorImpl) GatherFiles(localFiles []models.AppFileFields, appDir
 	return remoteFiles, len(filesToUpload) > 0, nil
 }
 
-func (actor PushActorImpl) UploadApp(appGuid string, zipFile *os.File, presentFiles []resources.AppFileResource) error {
-	return actor.appBitsRepo.UploadBits(appGuid, zipFile, presentFiles)
+// copyIgnoreFiles copies the .cfignore files of appDir to uploadDir, as the
+// zipper applies them again. Nested files are copied too, so that the files
+// they include back are not left out of the zip by the top level one.
+func (actor PushActorImpl) copyIgnoreFiles(appDir, uploadDir string) error {
+	ignoredFiles, err := actor.appfiles.IgnoredFilesInDir(appDir)
+	if err != nil {
+		return err
+	}
+
+	for _, ignoredFile := range ignoredFiles {
+		if path.Base(ignoredFile) != ".cfignore" {
+			continue
+		}
+
+		dir := filepath.Join(uploadDir, filepath.FromSlash(path.Dir(ignoredFile)))
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+
+		err = fileutils.CopyPathToPath(filepath.Join(appDir, filepath.FromSlash(ignoredFile)), filepath.Join(dir, ".cfignore"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (actor PushActorImpl) UploadApp(appGuid string, zipSource application_bits.ZipSource, zipSize int64, presentFiles []resources.AppFileResource) error {
+	return actor.appBitsRepo.UploadBits(appGuid, zipSource, zipSize, presentFiles)
 }
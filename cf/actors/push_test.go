@@ -1,6 +1,7 @@
 package actors_test
 
 import (
+	"archive/zip"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -101,6 +102,7 @@ var _ = Describe("Push Actor", func() {
 				appDir = filepath.Join(fixturesDir, "exclude-a-default-cfignore")
 				// Ignore app files for this test as .cfignore is not one of them
 				appBitsRepo.GetApplicationFilesReturns(nil, nil)
+				appFiles.IgnoredFilesInDirReturns([]string{".cfignore"}, nil)
 			})
 
 			It("copies the .cfignore file to the upload directory", func() {
@@ -112,6 +114,43 @@ var _ = Describe("Push Actor", func() {
 			})
 		})
 
+		Context("when a nested .cfignore includes back files excluded by the top level one", func() {
+			BeforeEach(func() {
+				appDir = filepath.Join(fixturesDir, "app-with-nested-cfignore")
+				appBitsRepo.GetApplicationFilesReturns(nil, nil)
+				actor = actors.NewPushActor(appBitsRepo, fakezipper, app_files.ApplicationFiles{})
+			})
+
+			It("zips every file that was gathered", func() {
+				localFiles, err := app_files.ApplicationFiles{}.AppFilesInDir(appDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, _, err = actor.GatherFiles(localFiles, appDir, tmpDir)
+				Expect(err).NotTo(HaveOccurred())
+
+				zipFile, err := ioutil.TempFile("", "gather-files-zip")
+				Expect(err).NotTo(HaveOccurred())
+				defer os.Remove(zipFile.Name())
+
+				err = app_files.ApplicationZipper{}.Zip(tmpDir, zipFile)
+				zipFile.Close()
+				Expect(err).NotTo(HaveOccurred())
+
+				reader, err := zip.OpenReader(zipFile.Name())
+				Expect(err).NotTo(HaveOccurred())
+				defer reader.Close()
+
+				zippedFiles := []string{}
+				for _, file := range reader.File {
+					zippedFiles = append(zippedFiles, file.Name)
+				}
+
+				Expect(zippedFiles).To(ContainElement("src/important.log"))
+				Expect(zippedFiles).NotTo(ContainElement("src/.cfignore"))
+				Expect(len(zippedFiles)).To(Equal(len(localFiles)))
+			})
+		})
+
 		It("returns files to upload with file mode unchanged on non-Windows platforms", func() {
 			if runtime.GOOS == "windows" {
 				Skip("This does not run on windows")
@@ -329,6 +368,35 @@ var _ = Describe("Push Actor", func() {
 			})
 		})
 
+		Context("when given a tar file", func() {
+			for _, archive := range []string{"example-app.tar", "example-app.tgz"} {
+				archive := archive
+
+				It("extracts "+archive, func() {
+					var extracted bool
+					f := func(tempDir string) {
+						for _, file := range allFiles {
+							_, err := os.Stat(filepath.Join(tempDir, file.Path))
+							Expect(err).NotTo(HaveOccurred())
+						}
+						extracted = true
+					}
+					err := actor.ProcessPath(filepath.Join(fixturesDir, archive), f)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(extracted).To(BeTrue())
+				})
+			}
+
+			It("returns an error if the extraction fails", func() {
+				fakezipper.IsTarFileReturns(true)
+				fakezipper.UntarReturns(errors.New("some-error"))
+				actor = actors.NewPushActor(appBitsRepo, fakezipper, appFiles)
+
+				err := actor.ProcessPath(filepath.Join(fixturesDir, "example-app.tar"), func(string) {})
+				Expect(err).To(MatchError("some-error"))
+			})
+		})
+
 		It("calls the provided function with the provided directory", func() {
 			appDir = filepath.Join(fixturesDir, "example-app")
 			f := func(tempDir string) {
@@ -88,6 +88,7 @@ func (resource ApplicationFromSummary) ToModel() (app models.Application) {
 type RouteSummary struct {
 	Guid   string
 	Host   string
+	Path   string
 	Domain DomainSummary
 }
 
@@ -99,6 +100,7 @@ func (resource RouteSummary) ToModel() (route models.RouteSummary) {
 
 	route.Guid = resource.Guid
 	route.Host = resource.Host
+	route.Path = resource.Path
 	route.Domain = domain
 	return
 }
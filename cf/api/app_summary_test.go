@@ -117,8 +117,9 @@ var _ = Describe("AppSummaryRepository", func() {
 			Expect(app.Name).To(Equal("app1"))
 			Expect(app.Guid).To(Equal("app-1-guid"))
 			Expect(app.BuildpackUrl).To(Equal("go_buildpack"))
-			Expect(len(app.Routes)).To(Equal(1))
+			Expect(len(app.Routes)).To(Equal(2))
 			Expect(app.Routes[0].URL()).To(Equal("app1.cfapps.io"))
+			Expect(app.Routes[1].URL()).To(Equal("app1.cfapps.io/api"))
 
 			Expect(app.State).To(Equal("started"))
 			Expect(app.Command).To(Equal("start_command"))
@@ -222,6 +223,15 @@ const getAppSummaryResponseBody string = `
 					"guid":"domain-1-guid",
 					"name":"cfapps.io"
 				}
+			},
+			{
+				"guid":"route-2-guid",
+				"host":"app1",
+				"path":"/api",
+				"domain":{
+					"guid":"domain-1-guid",
+					"name":"cfapps.io"
+				}
 			}
 		],
 		"running_instances":1,
@@ -5,25 +5,35 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"mime/multipart"
 	"net/textproto"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry/cli/cf/api/resources"
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
+	"github.com/cloudfoundry/cli/cf/errors"
 	. "github.com/cloudfoundry/cli/cf/i18n"
 	"github.com/cloudfoundry/cli/cf/net"
-	"github.com/cloudfoundry/gofileutils/fileutils"
+	"github.com/cloudfoundry/cli/cf/terminal"
 )
 
 const (
 	DefaultAppUploadBitsTimeout = 15 * time.Minute
 )
 
+// ZipSource writes the zip of the application files to upload. It is called
+// again whenever the upload has to be retried.
+type ZipSource func(io.Writer) error
+
 type ApplicationBitsRepository interface {
 	GetApplicationFiles(appFilesRequest []resources.AppFileResource) ([]resources.AppFileResource, error)
-	UploadBits(appGuid string, zipFile *os.File, presentFiles []resources.AppFileResource) (apiErr error)
+	UploadBits(appGuid string, zipSource ZipSource, zipSize int64, presentFiles []resources.AppFileResource) (apiErr error)
+	DownloadDroplet(appGuid string, droplet io.Writer) error
+	UploadDroplet(appGuid string, dropletPath string) error
+	WithUI(ui terminal.UI) ApplicationBitsRepository
 }
 
 type CloudControllerApplicationBitsRepository struct {
@@ -37,50 +47,141 @@ func NewCloudControllerApplicationBitsRepository(config core_config.Reader, gate
 	return
 }
 
-func (repo CloudControllerApplicationBitsRepository) UploadBits(appGuid string, zipFile *os.File, presentFiles []resources.AppFileResource) (apiErr error) {
+// WithUI returns a copy of the repository that reports the progress of its
+// uploads to ui, which buffers the output of one app of a parallel push.
+func (repo CloudControllerApplicationBitsRepository) WithUI(ui terminal.UI) ApplicationBitsRepository {
+	repo.gateway = repo.gateway.WithBufferedUI(ui)
+	return repo
+}
+
+// UploadBits uploads the zip written by zipSource along with the resources
+// already present on the server. zipSize is an estimate of the size of the
+// zip, which is streamed as it is written, and is only used to report the
+// progress of the upload.
+func (repo CloudControllerApplicationBitsRepository) UploadBits(appGuid string, zipSource ZipSource, zipSize int64, presentFiles []resources.AppFileResource) (apiErr error) {
 	apiUrl := fmt.Sprintf("/v2/apps/%s/bits", appGuid)
-	fileutils.TempFile("requests", func(requestFile *os.File, err error) {
-		if err != nil {
-			apiErr = fmt.Errorf("%s: %s", T("Error creating tmp file: {{.Err}}", map[string]interface{}{"Err": err}), err.Error())
-			return
-		}
 
-		// json.Marshal represents a nil value as "null" instead of an empty slice "[]"
-		if presentFiles == nil {
-			presentFiles = []resources.AppFileResource{}
-		}
+	// json.Marshal represents a nil value as "null" instead of an empty slice "[]"
+	if presentFiles == nil {
+		presentFiles = []resources.AppFileResource{}
+	}
 
-		presentFilesJSON, err := json.Marshal(presentFiles)
-		if err != nil {
-			apiErr = fmt.Errorf("%s: %s", T("Error marshaling JSON"), err.Error())
-			return
-		}
+	presentFilesJSON, err := json.Marshal(presentFiles)
+	if err != nil {
+		return fmt.Errorf("%s: %s", T("Error marshaling JSON"), err.Error())
+	}
 
-		boundary, err := repo.writeUploadBody(zipFile, requestFile, presentFilesJSON)
-		if err != nil {
-			apiErr = fmt.Errorf("%s: %s", T("Error writing to tmp file: {{.Err}}", map[string]interface{}{"Err": err}), err.Error())
-			return
-		}
+	// the boundary has to stay the same for every attempt, as the content
+	// type header is only set once
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
 
-		var request *net.Request
-		request, apiErr = repo.gateway.NewRequestForFile("PUT", repo.config.ApiEndpoint()+apiUrl, repo.config.AccessToken(), requestFile)
-		if apiErr != nil {
-			return
-		}
+	var zipErr error
+	zipErrMutex := &sync.Mutex{}
 
-		contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
-		request.HttpReq.Header.Set("Content-Type", contentType)
+	request, apiErr := repo.gateway.NewRequestForStream("PUT", repo.config.ApiEndpoint()+apiUrl, repo.config.AccessToken(), func() io.ReadCloser {
+		bodyReader, bodyWriter := io.Pipe()
 
-		response := &resources.Resource{}
-		_, apiErr = repo.gateway.PerformPollingRequestForJSONResponse(repo.config.ApiEndpoint(), request, response, DefaultAppUploadBitsTimeout)
-		if apiErr != nil {
-			return
+		zipErrMutex.Lock()
+		previousZipErr := zipErr
+		zipErrMutex.Unlock()
+
+		if previousZipErr != nil {
+			bodyWriter.CloseWithError(previousZipErr)
+			return bodyReader
 		}
-	})
+
+		go func() {
+			err := writeUploadBody(zipSource, bodyWriter, boundary, presentFilesJSON)
+			if err != nil && err != io.ErrClosedPipe {
+				zipErrMutex.Lock()
+				zipErr = err
+				zipErrMutex.Unlock()
+			}
+			bodyWriter.CloseWithError(err)
+		}()
+
+		return bodyReader
+	}, 0)
+	if apiErr != nil {
+		return
+	}
+	request.SetProgressTotal(uploadBodySizeEstimate(boundary, presentFilesJSON, zipSource != nil, zipSize))
+
+	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
+	request.HttpReq.Header.Set("Content-Type", contentType)
+
+	response := &resources.Resource{}
+	_, apiErr = repo.gateway.PerformPollingRequestForJSONResponse(repo.config.ApiEndpoint(), request, response, DefaultAppUploadBitsTimeout)
+
+	zipErrMutex.Lock()
+	defer zipErrMutex.Unlock()
+	if apiErr != nil && zipErr != nil {
+		apiErr = fmt.Errorf("%s: %s", T("Error zipping application"), zipErr.Error())
+	}
 
 	return
 }
 
+// IsRetryableError tells whether an upload or a resource match failed in a
+// way that a new attempt may fix: the connection was lost, or the server, or
+// a proxy in front of it, answered with a 5xx status.
+func IsRetryableError(err error) bool {
+	switch typedErr := err.(type) {
+	case *errors.NetworkError:
+		return true
+	case errors.HttpError:
+		return typedErr.StatusCode() >= 500
+	}
+	return false
+}
+
+// DownloadDroplet writes the droplet the app currently runs with to droplet.
+func (repo CloudControllerApplicationBitsRepository) DownloadDroplet(appGuid string, droplet io.Writer) error {
+	apiUrl := fmt.Sprintf("%s/v2/apps/%s/droplet/download", repo.config.ApiEndpoint(), appGuid)
+	request, err := repo.gateway.NewRequest("GET", apiUrl, repo.config.AccessToken(), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := repo.gateway.PerformRequestForDownload(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	_, err = io.Copy(droplet, response.Body)
+	if err != nil {
+		return fmt.Errorf("%s: %s", T("Error downloading droplet"), err.Error())
+	}
+	return nil
+}
+
+// UploadDroplet replaces the droplet of the app with the file at dropletPath,
+// as saved by DownloadDroplet.
+func (repo CloudControllerApplicationBitsRepository) UploadDroplet(appGuid string, dropletPath string) error {
+	apiUrl := fmt.Sprintf("%s/v2/apps/%s/droplet/upload", repo.config.ApiEndpoint(), appGuid)
+	boundary := multipart.NewWriter(ioutil.Discard).Boundary()
+
+	bodySize := dropletUploadBodySize(dropletPath, boundary)
+
+	request, err := repo.gateway.NewRequestForStream("PUT", apiUrl, repo.config.AccessToken(), func() io.ReadCloser {
+		bodyReader, bodyWriter := io.Pipe()
+		go func() {
+			bodyWriter.CloseWithError(writeDropletUploadBody(dropletPath, bodyWriter, boundary))
+		}()
+		return bodyReader
+	}, bodySize)
+	if err != nil {
+		return err
+	}
+
+	request.HttpReq.Header.Set("Content-Type", fmt.Sprintf("multipart/form-data; boundary=%s", boundary))
+
+	response := &resources.Resource{}
+	_, err = repo.gateway.PerformPollingRequestForJSONResponse(repo.config.ApiEndpoint(), request, response, DefaultAppUploadBitsTimeout)
+	return err
+}
+
 func (repo CloudControllerApplicationBitsRepository) GetApplicationFiles(appFilesToCheck []resources.AppFileResource) ([]resources.AppFileResource, error) {
 	integrityFieldsJson, err := json.Marshal(mapAppFilesToIntegrityFields(appFilesToCheck))
 	if err != nil {
@@ -131,51 +232,117 @@ func appFilesBySha(in []resources.AppFileResource) (out map[string]resources.App
 	return out
 }
 
-func (repo CloudControllerApplicationBitsRepository) writeUploadBody(zipFile *os.File, body *os.File, presentResourcesJson []byte) (boundary string, err error) {
-	writer := multipart.NewWriter(body)
-	defer writer.Close()
+// dropletUploadBodySize is the size of the body that uploads the droplet at
+// dropletPath: the multipart framing plus the size of the droplet. It gives 0
+// when the droplet cannot be read, as the upload itself then reports the
+// error.
+func dropletUploadBodySize(dropletPath string, boundary string) int64 {
+	dropletInfo, err := os.Stat(dropletPath)
+	if err != nil {
+		return 0
+	}
+
+	counter := &byteCounter{}
+	if writeDropletParts(counter, boundary, &bytes.Buffer{}) != nil {
+		return 0
+	}
+	return counter.count + dropletInfo.Size()
+}
+
+// uploadBodySizeEstimate is the size of the multipart framing and the present
+// resources of an upload, plus the estimated size of its zip.
+func uploadBodySizeEstimate(boundary string, presentFilesJSON []byte, hasZip bool, zipSize int64) int64 {
+	var emptyZip ZipSource
+	if hasZip {
+		emptyZip = func(io.Writer) error { return nil }
+	}
+
+	counter := &byteCounter{}
+	if writeUploadBody(emptyZip, counter, boundary, presentFilesJSON) != nil {
+		return 0
+	}
+	return counter.count + zipSize
+}
+
+type byteCounter struct {
+	count int64
+}
 
-	boundary = writer.Boundary()
+func (counter *byteCounter) Write(p []byte) (int, error) {
+	counter.count += int64(len(p))
+	return len(p), nil
+}
+
+func writeUploadBody(zipSource ZipSource, body io.Writer, boundary string, presentResourcesJson []byte) error {
+	writer := multipart.NewWriter(body)
+	err := writer.SetBoundary(boundary)
+	if err != nil {
+		return err
+	}
 
 	part, err := writer.CreateFormField("resources")
 	if err != nil {
-		return
+		return err
 	}
 
 	_, err = io.Copy(part, bytes.NewBuffer(presentResourcesJson))
 	if err != nil {
-		return
+		return err
 	}
 
-	if zipFile != nil {
-		zipStats, zipErr := zipFile.Stat()
-		if zipErr != nil {
-			return
+	if zipSource != nil {
+		part, err = createZipPartWriter(writer)
+		if err != nil {
+			return err
 		}
 
-		if zipStats.Size() == 0 {
-			return
+		err = zipSource(part)
+		if err != nil {
+			return err
 		}
+	}
 
-		part, zipErr = createZipPartWriter(zipStats, writer)
-		if zipErr != nil {
-			return
-		}
+	return writer.Close()
+}
 
-		_, zipErr = io.Copy(part, zipFile)
-		if zipErr != nil {
-			return
-		}
+func writeDropletUploadBody(dropletPath string, body io.Writer, boundary string) error {
+	droplet, err := os.Open(dropletPath)
+	if err != nil {
+		return err
 	}
+	defer droplet.Close()
 
-	return
+	return writeDropletParts(body, boundary, droplet)
+}
+
+func writeDropletParts(body io.Writer, boundary string, droplet io.Reader) error {
+	writer := multipart.NewWriter(body)
+	err := writer.SetBoundary(boundary)
+	if err != nil {
+		return err
+	}
+
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="droplet"; filename="droplet.tgz"`)
+	h.Set("Content-Type", "application/octet-stream")
+	h.Set("Content-Transfer-Encoding", "binary")
+	part, err := writer.CreatePart(h)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(part, droplet)
+	if err != nil {
+		return err
+	}
+
+	return writer.Close()
 }
 
-func createZipPartWriter(zipStats os.FileInfo, writer *multipart.Writer) (io.Writer, error) {
+func createZipPartWriter(writer *multipart.Writer) (io.Writer, error) {
 	h := make(textproto.MIMEHeader)
 	h.Set("Content-Disposition", `form-data; name="application"; filename="application.zip"`)
 	h.Set("Content-Type", "application/zip")
-	h.Set("Content-Length", fmt.Sprintf("%d", zipStats.Size()))
 	h.Set("Content-Transfer-Encoding", "binary")
 	return writer.CreatePart(h)
 }
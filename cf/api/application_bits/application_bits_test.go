@@ -2,21 +2,25 @@ package application_bits_test
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
-	"strconv"
 	"strings"
 	"time"
 
 	testapi "github.com/cloudfoundry/cli/cf/api/fakes"
 	"github.com/cloudfoundry/cli/cf/api/resources"
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
+	cferrors "github.com/cloudfoundry/cli/cf/errors"
 	"github.com/cloudfoundry/cli/cf/net"
+	"github.com/cloudfoundry/cli/cf/trace"
 	testconfig "github.com/cloudfoundry/cli/testhelpers/configuration"
 	testnet "github.com/cloudfoundry/cli/testhelpers/net"
 	testterm "github.com/cloudfoundry/cli/testhelpers/terminal"
@@ -62,13 +66,18 @@ var _ = Describe("CloudControllerApplicationBitsRepository", func() {
 	}
 
 	Describe(".UploadBits", func() {
-		var uploadFile *os.File
-		var err error
+		var zipSource ZipSource
 
 		BeforeEach(func() {
-			uploadFile, err = os.Open(filepath.Join(fixturesDir, "ignored_and_resource_matched_example_app.zip"))
-			if err != nil {
-				log.Fatal(err)
+			zipSource = func(zipWriter io.Writer) error {
+				uploadFile, err := os.Open(filepath.Join(fixturesDir, "ignored_and_resource_matched_example_app.zip"))
+				if err != nil {
+					return err
+				}
+				defer uploadFile.Close()
+
+				_, err = io.Copy(zipWriter, uploadFile)
+				return err
 			}
 		})
 
@@ -96,7 +105,7 @@ var _ = Describe("CloudControllerApplicationBitsRepository", func() {
 				createProgressEndpoint("finished"),
 			)
 
-			apiErr := repo.UploadBits("my-cool-app-guid", uploadFile, []resources.AppFileResource{file1, file2})
+			apiErr := repo.UploadBits("my-cool-app-guid", zipSource, 0, []resources.AppFileResource{file1, file2})
 			Expect(apiErr).NotTo(HaveOccurred())
 		})
 
@@ -119,11 +128,45 @@ var _ = Describe("CloudControllerApplicationBitsRepository", func() {
 				createProgressEndpoint("running"),
 				createProgressEndpoint("failed"),
 			)
-			apiErr := repo.UploadBits("my-cool-app-guid", uploadFile, []resources.AppFileResource{file1, file2})
+			apiErr := repo.UploadBits("my-cool-app-guid", zipSource, 0, []resources.AppFileResource{file1, file2})
 
 			Expect(apiErr).To(HaveOccurred())
 		})
 
+		It("writes the zip only once for an upload", func() {
+			testServer = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				ioutil.ReadAll(request.Body)
+				writer.WriteHeader(http.StatusCreated)
+				writer.Write([]byte(`{"metadata":{"guid":"my-job-guid","url":""}}`))
+			}))
+			configRepo.SetApiEndpoint(testServer.URL)
+
+			zipsWritten := 0
+			countingZipSource := func(zipWriter io.Writer) error {
+				zipsWritten++
+				return zipSource(zipWriter)
+			}
+
+			apiErr := repo.UploadBits("my-cool-app-guid", countingZipSource, 0, []resources.AppFileResource{file1, file2})
+			Expect(apiErr).NotTo(HaveOccurred())
+			Expect(zipsWritten).To(Equal(1))
+		})
+
+		It("reports errors writing the zip", func() {
+			testServer = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				ioutil.ReadAll(request.Body)
+				writer.WriteHeader(http.StatusCreated)
+			}))
+			configRepo.SetApiEndpoint(testServer.URL)
+
+			zipSource = func(zipWriter io.Writer) error {
+				return errors.New("disk on fire")
+			}
+
+			apiErr := repo.UploadBits("my-cool-app-guid", zipSource, 0, []resources.AppFileResource{file1, file2})
+			Expect(apiErr).To(MatchError("Error zipping application: disk on fire"))
+		})
+
 		Context("when there are no files to upload", func() {
 			It("makes a request without a zipfile", func() {
 				setupTestServer(
@@ -157,7 +200,7 @@ var _ = Describe("CloudControllerApplicationBitsRepository", func() {
 					createProgressEndpoint("finished"),
 				)
 
-				apiErr := repo.UploadBits("my-cool-app-guid", nil, []resources.AppFileResource{})
+				apiErr := repo.UploadBits("my-cool-app-guid", nil, 0, []resources.AppFileResource{})
 				Expect(apiErr).NotTo(HaveOccurred())
 			})
 		})
@@ -194,11 +237,128 @@ var _ = Describe("CloudControllerApplicationBitsRepository", func() {
 				createProgressEndpoint("finished"),
 			)
 
-			apiErr := repo.UploadBits("my-cool-app-guid", nil, nil)
+			apiErr := repo.UploadBits("my-cool-app-guid", nil, 0, nil)
 			Expect(apiErr).NotTo(HaveOccurred())
 		})
 	})
 
+	Describe(".DownloadDroplet", func() {
+		AfterEach(func() {
+			testServer.Close()
+		})
+
+		It("writes the droplet the download redirects to", func() {
+			testServer = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				switch request.URL.Path {
+				case "/v2/apps/my-cool-app-guid/droplet/download":
+					Expect(request.Method).To(Equal("GET"))
+					Expect(request.Header.Get("Authorization")).To(Equal(configRepo.AccessToken()))
+					http.Redirect(writer, request, "/blobstore/droplet", http.StatusFound)
+				case "/blobstore/droplet":
+					writer.Write([]byte("droplet-content"))
+				default:
+					writer.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			configRepo.SetApiEndpoint(testServer.URL)
+
+			droplet := &bytes.Buffer{}
+			err := repo.DownloadDroplet("my-cool-app-guid", droplet)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(droplet.String()).To(Equal("droplet-content"))
+		})
+
+		It("keeps the droplet out of the trace", func() {
+			testServer = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				writer.Write([]byte("droplet-content"))
+			}))
+			configRepo.SetApiEndpoint(testServer.URL)
+
+			output := &bytes.Buffer{}
+			trace.SetStdout(output)
+			trace.EnableTrace()
+			defer func() {
+				trace.DisableTrace()
+				trace.SetStdout(os.Stdout)
+			}()
+
+			droplet := &bytes.Buffer{}
+			err := repo.DownloadDroplet("my-cool-app-guid", droplet)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(droplet.String()).To(Equal("droplet-content"))
+			Expect(output.String()).To(ContainSubstring("RESPONSE:"))
+			Expect(output.String()).NotTo(ContainSubstring("droplet-content"))
+		})
+
+		It("returns an error when the app has no droplet", func() {
+			setupTestServer(testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method: "GET",
+				Path:   "/v2/apps/my-cool-app-guid/droplet/download",
+				Response: testnet.TestResponse{
+					Status: http.StatusNotFound,
+					Body:   `{"code": 10010, "description": "Droplet not found"}`,
+				},
+			}))
+
+			err := repo.DownloadDroplet("my-cool-app-guid", &bytes.Buffer{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Droplet not found"))
+		})
+	})
+
+	Describe(".UploadDroplet", func() {
+		var dropletPath string
+
+		BeforeEach(func() {
+			dropletFile, err := ioutil.TempFile("", "droplet")
+			Expect(err).NotTo(HaveOccurred())
+			dropletFile.Write([]byte("droplet-content"))
+			dropletFile.Close()
+			dropletPath = dropletFile.Name()
+		})
+
+		AfterEach(func() {
+			os.Remove(dropletPath)
+			testServer.Close()
+		})
+
+		It("uploads the droplet as a multipart form", func() {
+			var uploaded string
+			testServer = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				defer GinkgoRecover()
+				Expect(request.Method).To(Equal("PUT"))
+				Expect(request.URL.Path).To(Equal("/v2/apps/my-cool-app-guid/droplet/upload"))
+				Expect(request.URL.Query().Get("async")).To(Equal("true"))
+				Expect(request.TransferEncoding).To(BeEmpty())
+				Expect(request.ContentLength).To(BeNumerically(">", len("droplet-content")))
+
+				droplet, _, err := request.FormFile("droplet")
+				Expect(err).NotTo(HaveOccurred())
+				content, _ := ioutil.ReadAll(droplet)
+				uploaded = string(content)
+
+				writer.WriteHeader(http.StatusCreated)
+				writer.Write([]byte(`{"metadata":{"guid":"my-job-guid","url":""}}`))
+			}))
+			configRepo.SetApiEndpoint(testServer.URL)
+
+			err := repo.UploadDroplet("my-cool-app-guid", dropletPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uploaded).To(Equal("droplet-content"))
+		})
+
+		It("returns an error when the droplet cannot be read", func() {
+			testServer = httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				ioutil.ReadAll(request.Body)
+				writer.WriteHeader(http.StatusCreated)
+			}))
+			configRepo.SetApiEndpoint(testServer.URL)
+
+			err := repo.UploadDroplet("my-cool-app-guid", filepath.Join(fixturesDir, "no-such-droplet"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe(".GetApplicationFiles", func() {
 		It("accepts a slice of files and returns a slice of the files that it already has", func() {
 			setupTestServer(matchResourceRequest)
@@ -374,14 +534,8 @@ func uploadBodyMatcher(zipChecks func(zipReader *zip.Reader)) func(*http.Request
 			return
 		}
 
-		length, err := strconv.ParseInt(applicationFile.Header.Get("content-length"), 10, 64)
-		if err != nil {
-			Fail(fmt.Sprintf("Cannot convert content-length to int %v", err.Error()))
-			return
-		}
-
 		if zipChecks != nil {
-			zipReader, err := zip.NewReader(file, length)
+			zipReader, err := zip.NewReader(file, applicationFile.Size)
 			if err != nil {
 				Fail(fmt.Sprintf("Error reading zip content %v", err.Error()))
 				return
@@ -439,3 +593,23 @@ var matchExcludedResourceRequest = testnet.TestRequest{
 func executableBits(mode os.FileMode) os.FileMode {
 	return mode & 0111
 }
+
+var _ = Describe("IsRetryableError", func() {
+	It("retries network errors", func() {
+		Expect(IsRetryableError(cferrors.NewNetworkError("connection reset by peer"))).To(BeTrue())
+	})
+
+	It("retries server errors", func() {
+		Expect(IsRetryableError(cferrors.NewHttpError(502, "", "Bad Gateway"))).To(BeTrue())
+	})
+
+	It("does not retry client errors", func() {
+		Expect(IsRetryableError(cferrors.NewHttpError(400, "170001", "Staging error"))).To(BeFalse())
+		Expect(IsRetryableError(cferrors.NewHttpError(404, "100004", "App not found"))).To(BeFalse())
+	})
+
+	It("does not retry other errors", func() {
+		Expect(IsRetryableError(cferrors.NewInvalidSSLCert("example.com", ""))).To(BeFalse())
+		Expect(IsRetryableError(errors.New("Error zipping application: disk on fire"))).To(BeFalse())
+	})
+})
@@ -2,10 +2,12 @@
 package fakes
 
 import (
+	"io"
+	"sync"
+
 	. "github.com/cloudfoundry/cli/cf/api/application_bits"
 	"github.com/cloudfoundry/cli/cf/api/resources"
-	"os"
-	"sync"
+	"github.com/cloudfoundry/cli/cf/terminal"
 )
 
 type FakeApplicationBitsRepository struct {
@@ -18,16 +20,43 @@ type FakeApplicationBitsRepository struct {
 		result1 []resources.AppFileResource
 		result2 error
 	}
-	UploadBitsStub        func(appGuid string, zipFile *os.File, presentFiles []resources.AppFileResource) (apiErr error)
+	UploadBitsStub        func(appGuid string, zipSource ZipSource, zipSize int64, presentFiles []resources.AppFileResource) (apiErr error)
 	uploadBitsMutex       sync.RWMutex
 	uploadBitsArgsForCall []struct {
 		arg1 string
-		arg2 *os.File
-		arg3 []resources.AppFileResource
+		arg2 ZipSource
+		arg3 int64
+		arg4 []resources.AppFileResource
 	}
 	uploadBitsReturns struct {
 		result1 error
 	}
+	DownloadDropletStub        func(appGuid string, droplet io.Writer) error
+	downloadDropletMutex       sync.RWMutex
+	downloadDropletArgsForCall []struct {
+		appGuid string
+		droplet io.Writer
+	}
+	downloadDropletReturns struct {
+		result1 error
+	}
+	UploadDropletStub        func(appGuid string, dropletPath string) error
+	uploadDropletMutex       sync.RWMutex
+	uploadDropletArgsForCall []struct {
+		appGuid     string
+		dropletPath string
+	}
+	uploadDropletReturns struct {
+		result1 error
+	}
+	WithUIStub        func(ui terminal.UI) ApplicationBitsRepository
+	withUIMutex       sync.RWMutex
+	withUIArgsForCall []struct {
+		ui terminal.UI
+	}
+	withUIReturns struct {
+		result1 ApplicationBitsRepository
+	}
 }
 
 func (fake *FakeApplicationBitsRepository) GetApplicationFiles(arg1 []resources.AppFileResource) ([]resources.AppFileResource, error) {
@@ -62,16 +91,17 @@ func (fake *FakeApplicationBitsRepository) GetApplicationFilesReturns(result1 []
 	}{result1, result2}
 }
 
-func (fake *FakeApplicationBitsRepository) UploadBits(arg1 string, arg2 *os.File, arg3 []resources.AppFileResource) (apiErr error) {
+func (fake *FakeApplicationBitsRepository) UploadBits(arg1 string, arg2 ZipSource, arg3 int64, arg4 []resources.AppFileResource) (apiErr error) {
 	fake.uploadBitsMutex.Lock()
 	defer fake.uploadBitsMutex.Unlock()
 	fake.uploadBitsArgsForCall = append(fake.uploadBitsArgsForCall, struct {
 		arg1 string
-		arg2 *os.File
-		arg3 []resources.AppFileResource
-	}{arg1, arg2, arg3})
+		arg2 ZipSource
+		arg3 int64
+		arg4 []resources.AppFileResource
+	}{arg1, arg2, arg3, arg4})
 	if fake.UploadBitsStub != nil {
-		return fake.UploadBitsStub(arg1, arg2, arg3)
+		return fake.UploadBitsStub(arg1, arg2, arg3, arg4)
 	} else {
 		return fake.uploadBitsReturns.result1
 	}
@@ -83,10 +113,10 @@ func (fake *FakeApplicationBitsRepository) UploadBitsCallCount() int {
 	return len(fake.uploadBitsArgsForCall)
 }
 
-func (fake *FakeApplicationBitsRepository) UploadBitsArgsForCall(i int) (string, *os.File, []resources.AppFileResource) {
+func (fake *FakeApplicationBitsRepository) UploadBitsArgsForCall(i int) (string, ZipSource, int64, []resources.AppFileResource) {
 	fake.uploadBitsMutex.RLock()
 	defer fake.uploadBitsMutex.RUnlock()
-	return fake.uploadBitsArgsForCall[i].arg1, fake.uploadBitsArgsForCall[i].arg2, fake.uploadBitsArgsForCall[i].arg3
+	return fake.uploadBitsArgsForCall[i].arg1, fake.uploadBitsArgsForCall[i].arg2, fake.uploadBitsArgsForCall[i].arg3, fake.uploadBitsArgsForCall[i].arg4
 }
 
 func (fake *FakeApplicationBitsRepository) UploadBitsReturns(result1 error) {
@@ -95,4 +125,102 @@ func (fake *FakeApplicationBitsRepository) UploadBitsReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeApplicationBitsRepository) DownloadDroplet(appGuid string, droplet io.Writer) error {
+	fake.downloadDropletMutex.Lock()
+	fake.downloadDropletArgsForCall = append(fake.downloadDropletArgsForCall, struct {
+		appGuid string
+		droplet io.Writer
+	}{appGuid, droplet})
+	fake.downloadDropletMutex.Unlock()
+	if fake.DownloadDropletStub != nil {
+		return fake.DownloadDropletStub(appGuid, droplet)
+	} else {
+		return fake.downloadDropletReturns.result1
+	}
+}
+
+func (fake *FakeApplicationBitsRepository) DownloadDropletCallCount() int {
+	fake.downloadDropletMutex.RLock()
+	defer fake.downloadDropletMutex.RUnlock()
+	return len(fake.downloadDropletArgsForCall)
+}
+
+func (fake *FakeApplicationBitsRepository) DownloadDropletArgsForCall(i int) (string, io.Writer) {
+	fake.downloadDropletMutex.RLock()
+	defer fake.downloadDropletMutex.RUnlock()
+	return fake.downloadDropletArgsForCall[i].appGuid, fake.downloadDropletArgsForCall[i].droplet
+}
+
+func (fake *FakeApplicationBitsRepository) DownloadDropletReturns(result1 error) {
+	fake.DownloadDropletStub = nil
+	fake.downloadDropletReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeApplicationBitsRepository) UploadDroplet(appGuid string, dropletPath string) error {
+	fake.uploadDropletMutex.Lock()
+	fake.uploadDropletArgsForCall = append(fake.uploadDropletArgsForCall, struct {
+		appGuid     string
+		dropletPath string
+	}{appGuid, dropletPath})
+	fake.uploadDropletMutex.Unlock()
+	if fake.UploadDropletStub != nil {
+		return fake.UploadDropletStub(appGuid, dropletPath)
+	} else {
+		return fake.uploadDropletReturns.result1
+	}
+}
+
+func (fake *FakeApplicationBitsRepository) UploadDropletCallCount() int {
+	fake.uploadDropletMutex.RLock()
+	defer fake.uploadDropletMutex.RUnlock()
+	return len(fake.uploadDropletArgsForCall)
+}
+
+func (fake *FakeApplicationBitsRepository) UploadDropletArgsForCall(i int) (string, string) {
+	fake.uploadDropletMutex.RLock()
+	defer fake.uploadDropletMutex.RUnlock()
+	return fake.uploadDropletArgsForCall[i].appGuid, fake.uploadDropletArgsForCall[i].dropletPath
+}
+
+func (fake *FakeApplicationBitsRepository) UploadDropletReturns(result1 error) {
+	fake.UploadDropletStub = nil
+	fake.uploadDropletReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeApplicationBitsRepository) WithUI(ui terminal.UI) ApplicationBitsRepository {
+	fake.withUIMutex.Lock()
+	fake.withUIArgsForCall = append(fake.withUIArgsForCall, struct {
+		ui terminal.UI
+	}{ui})
+	fake.withUIMutex.Unlock()
+	if fake.WithUIStub != nil {
+		return fake.WithUIStub(ui)
+	} else {
+		return fake.withUIReturns.result1
+	}
+}
+
+func (fake *FakeApplicationBitsRepository) WithUICallCount() int {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return len(fake.withUIArgsForCall)
+}
+
+func (fake *FakeApplicationBitsRepository) WithUIArgsForCall(i int) terminal.UI {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return fake.withUIArgsForCall[i].ui
+}
+
+func (fake *FakeApplicationBitsRepository) WithUIReturns(result1 ApplicationBitsRepository) {
+	fake.WithUIStub = nil
+	fake.withUIReturns = struct {
+		result1 ApplicationBitsRepository
+	}{result1}
+}
+
 var _ ApplicationBitsRepository = new(FakeApplicationBitsRepository)
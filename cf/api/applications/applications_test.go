@@ -124,6 +124,32 @@ var _ = Describe("ApplicationsRepository", func() {
 			Expect(handler).To(HaveAllRequestsCalled())
 			Expect(apiErr).NotTo(HaveOccurred())
 		})
+
+		It("sends the credentials of the docker registry", func() {
+			request := testapi.NewCloudControllerTestRequest(testnet.TestRequest{
+				Method:   "POST",
+				Path:     "/v2/apps",
+				Matcher:  testnet.RequestBodyMatcher(`{"name":"my-docker-app","space_guid":"some-space-guid","docker_image":"registry.example.com/my-image","docker_credentials":{"username":"some-user","password":"some-password"}}`),
+				Response: testnet.TestResponse{Status: http.StatusCreated, Body: createApplicationResponse},
+			})
+
+			ts, handler, repo := createAppRepo([]testnet.TestRequest{request})
+			defer ts.Close()
+
+			name := "my-docker-app"
+			spaceGuid := "some-space-guid"
+			image := "registry.example.com/my-image"
+			params := models.AppParams{
+				Name:              &name,
+				SpaceGuid:         &spaceGuid,
+				DockerImage:       &image,
+				DockerCredentials: &models.DockerCredentials{Username: "some-user", Password: "some-password"},
+			}
+
+			_, apiErr := repo.Create(params)
+			Expect(handler).To(HaveAllRequestsCalled())
+			Expect(apiErr).NotTo(HaveOccurred())
+		})
 	})
 
 	Describe("reading environment for an app", func() {
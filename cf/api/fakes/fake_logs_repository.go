@@ -3,44 +3,60 @@ package fakes
 
 import (
 	"sync"
+	"time"
 
 	"github.com/cloudfoundry/cli/cf/api"
 	"github.com/cloudfoundry/loggregatorlib/logmessage"
 )
 
 type FakeLogsRepository struct {
-	RecentLogsForStub        func(appGuid string) ([]*logmessage.LogMessage, error)
+	RecentLogsForStub        func(appGuid string, filter api.LogFilter) ([]*logmessage.LogMessage, error)
 	recentLogsForMutex       sync.RWMutex
 	recentLogsForArgsForCall []struct {
 		appGuid string
+		filter  api.LogFilter
 	}
 	recentLogsForReturns struct {
 		result1 []*logmessage.LogMessage
 		result2 error
 	}
-	TailLogsForStub        func(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error
+	TailLogsForStub        func(appGuid string, filter api.LogFilter, onConnect func(), onMessage func(*logmessage.LogMessage)) error
 	tailLogsForMutex       sync.RWMutex
 	tailLogsForArgsForCall []struct {
 		appGuid   string
+		filter    api.LogFilter
 		onConnect func()
 		onMessage func(*logmessage.LogMessage)
 	}
 	tailLogsForReturns struct {
 		result1 error
 	}
+	TailLogsForAppsStub        func(appGuids []string, filter api.LogFilter, onConnect func(), onReconnect func(appGuid string, missing time.Duration), onMessage func(*logmessage.LogMessage)) error
+	tailLogsForAppsMutex       sync.RWMutex
+	tailLogsForAppsArgsForCall []struct {
+		appGuids    []string
+		filter      api.LogFilter
+		onConnect   func()
+		onReconnect func(appGuid string, missing time.Duration)
+		onMessage   func(*logmessage.LogMessage)
+	}
+	tailLogsForAppsReturns struct {
+		result1 error
+	}
 	CloseStub        func()
 	closeMutex       sync.RWMutex
 	closeArgsForCall []struct{}
 }
 
-func (fake *FakeLogsRepository) RecentLogsFor(appGuid string) ([]*logmessage.LogMessage, error) {
+func (fake *FakeLogsRepository) RecentLogsFor(appGuid string, filter api.LogFilter) ([]*logmessage.LogMessage, error) {
 	fake.recentLogsForMutex.Lock()
 	fake.recentLogsForArgsForCall = append(fake.recentLogsForArgsForCall, struct {
 		appGuid string
-	}{appGuid})
+		filter  api.LogFilter
+	}{appGuid, filter})
 	fake.recentLogsForMutex.Unlock()
 	if fake.RecentLogsForStub != nil {
-		return fake.RecentLogsForStub(appGuid)
+		return fake.RecentLogsForStub(appGuid, filter)
 	} else {
 		return fake.recentLogsForReturns.result1, fake.recentLogsForReturns.result2
 	}
@@ -52,10 +68,10 @@ func (fake *FakeLogsRepository) RecentLogsForCallCount() int {
 	return len(fake.recentLogsForArgsForCall)
 }
 
-func (fake *FakeLogsRepository) RecentLogsForArgsForCall(i int) string {
+func (fake *FakeLogsRepository) RecentLogsForArgsForCall(i int) (string, api.LogFilter) {
 	fake.recentLogsForMutex.RLock()
 	defer fake.recentLogsForMutex.RUnlock()
-	return fake.recentLogsForArgsForCall[i].appGuid
+	return fake.recentLogsForArgsForCall[i].appGuid, fake.recentLogsForArgsForCall[i].filter
 }
 
 func (fake *FakeLogsRepository) RecentLogsForReturns(result1 []*logmessage.LogMessage, result2 error) {
@@ -66,16 +82,17 @@ func (fake *FakeLogsRepository) RecentLogsForReturns(result1 []*logmessage.LogMe
 	}{result1, result2}
 }
 
-func (fake *FakeLogsRepository) TailLogsFor(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+func (fake *FakeLogsRepository) TailLogsFor(appGuid string, filter api.LogFilter, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
 	fake.tailLogsForMutex.Lock()
 	fake.tailLogsForArgsForCall = append(fake.tailLogsForArgsForCall, struct {
 		appGuid   string
+		filter    api.LogFilter
 		onConnect func()
 		onMessage func(*logmessage.LogMessage)
-	}{appGuid, onConnect, onMessage})
+	}{appGuid, filter, onConnect, onMessage})
 	fake.tailLogsForMutex.Unlock()
 	if fake.TailLogsForStub != nil {
-		return fake.TailLogsForStub(appGuid, onConnect, onMessage)
+		return fake.TailLogsForStub(appGuid, filter, onConnect, onMessage)
 	} else {
 		return fake.tailLogsForReturns.result1
 	}
@@ -87,10 +104,10 @@ func (fake *FakeLogsRepository) TailLogsForCallCount() int {
 	return len(fake.tailLogsForArgsForCall)
 }
 
-func (fake *FakeLogsRepository) TailLogsForArgsForCall(i int) (string, func(), func(*logmessage.LogMessage)) {
+func (fake *FakeLogsRepository) TailLogsForArgsForCall(i int) (string, api.LogFilter, func(), func(*logmessage.LogMessage)) {
 	fake.tailLogsForMutex.RLock()
 	defer fake.tailLogsForMutex.RUnlock()
-	return fake.tailLogsForArgsForCall[i].appGuid, fake.tailLogsForArgsForCall[i].onConnect, fake.tailLogsForArgsForCall[i].onMessage
+	return fake.tailLogsForArgsForCall[i].appGuid, fake.tailLogsForArgsForCall[i].filter, fake.tailLogsForArgsForCall[i].onConnect, fake.tailLogsForArgsForCall[i].onMessage
 }
 
 func (fake *FakeLogsRepository) TailLogsForReturns(result1 error) {
@@ -100,6 +117,42 @@ func (fake *FakeLogsRepository) TailLogsForReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeLogsRepository) TailLogsForApps(appGuids []string, filter api.LogFilter, onConnect func(), onReconnect func(appGuid string, missing time.Duration), onMessage func(*logmessage.LogMessage)) error {
+	fake.tailLogsForAppsMutex.Lock()
+	fake.tailLogsForAppsArgsForCall = append(fake.tailLogsForAppsArgsForCall, struct {
+		appGuids    []string
+		filter      api.LogFilter
+		onConnect   func()
+		onReconnect func(appGuid string, missing time.Duration)
+		onMessage   func(*logmessage.LogMessage)
+	}{appGuids, filter, onConnect, onReconnect, onMessage})
+	fake.tailLogsForAppsMutex.Unlock()
+	if fake.TailLogsForAppsStub != nil {
+		return fake.TailLogsForAppsStub(appGuids, filter, onConnect, onReconnect, onMessage)
+	} else {
+		return fake.tailLogsForAppsReturns.result1
+	}
+}
+
+func (fake *FakeLogsRepository) TailLogsForAppsCallCount() int {
+	fake.tailLogsForAppsMutex.RLock()
+	defer fake.tailLogsForAppsMutex.RUnlock()
+	return len(fake.tailLogsForAppsArgsForCall)
+}
+
+func (fake *FakeLogsRepository) TailLogsForAppsArgsForCall(i int) ([]string, api.LogFilter, func(), func(appGuid string, missing time.Duration), func(*logmessage.LogMessage)) {
+	fake.tailLogsForAppsMutex.RLock()
+	defer fake.tailLogsForAppsMutex.RUnlock()
+	return fake.tailLogsForAppsArgsForCall[i].appGuids, fake.tailLogsForAppsArgsForCall[i].filter, fake.tailLogsForAppsArgsForCall[i].onConnect, fake.tailLogsForAppsArgsForCall[i].onReconnect, fake.tailLogsForAppsArgsForCall[i].onMessage
+}
+
+func (fake *FakeLogsRepository) TailLogsForAppsReturns(result1 error) {
+	fake.TailLogsForAppsStub = nil
+	fake.tailLogsForAppsReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeLogsRepository) Close() {
 	fake.closeMutex.Lock()
 	fake.closeArgsForCall = append(fake.closeArgsForCall, struct{}{})
@@ -4,16 +4,22 @@ import (
 	"errors"
 	"time"
 
+	"github.com/cloudfoundry/cli/cf/api"
 	"github.com/cloudfoundry/loggregatorlib/logmessage"
 )
 
 type FakeLogsRepositoryWithTimeout struct{}
 
-func (fake *FakeLogsRepositoryWithTimeout) RecentLogsFor(appGuid string) ([]*logmessage.LogMessage, error) {
+func (fake *FakeLogsRepositoryWithTimeout) RecentLogsFor(appGuid string, filter api.LogFilter) ([]*logmessage.LogMessage, error) {
 	return nil, nil
 }
 
-func (fake *FakeLogsRepositoryWithTimeout) TailLogsFor(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+func (fake *FakeLogsRepositoryWithTimeout) TailLogsFor(appGuid string, filter api.LogFilter, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+	time.Sleep(150 * time.Millisecond)
+	return errors.New("Fake http timeout error")
+}
+
+func (fake *FakeLogsRepositoryWithTimeout) TailLogsForApps(appGuids []string, filter api.LogFilter, onConnect func(), onReconnect func(string, time.Duration), onMessage func(*logmessage.LogMessage)) error {
 	time.Sleep(150 * time.Millisecond)
 	return errors.New("Fake http timeout error")
 }
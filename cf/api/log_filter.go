@@ -0,0 +1,84 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/cloudfoundry/loggregatorlib/logmessage"
+)
+
+// LogFilter selects the log messages of an app. Every field that is set must
+// match, and the zero value selects all messages.
+type LogFilter struct {
+	SourceTypes  []string
+	Instances    []string
+	MessageTypes []logmessage.LogMessage_MessageType
+	Pattern      *regexp.Regexp
+	Since        time.Time
+}
+
+func (filter LogFilter) Matches(msg *logmessage.LogMessage) bool {
+	if len(filter.SourceTypes) > 0 && !filter.matchesSourceType(msg.GetSourceName()) {
+		return false
+	}
+
+	if len(filter.Instances) > 0 && !containsString(filter.Instances, msg.GetSourceId()) {
+		return false
+	}
+
+	if len(filter.MessageTypes) > 0 && !filter.matchesMessageType(msg.GetMessageType()) {
+		return false
+	}
+
+	if filter.Pattern != nil && !filter.Pattern.Match(msg.GetMessage()) {
+		return false
+	}
+
+	if !filter.Since.IsZero() && msg.GetTimestamp() < filter.Since.UnixNano() {
+		return false
+	}
+
+	return true
+}
+
+// matchesSourceType ignores the case and any "/" suffix of the source name,
+// as the app logs are sent by "App" and some sources name the process type
+// too.
+func (filter LogFilter) matchesSourceType(sourceName string) bool {
+	sourceType := strings.SplitN(sourceName, "/", 2)[0]
+	for _, filterType := range filter.SourceTypes {
+		if strings.EqualFold(filterType, sourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (filter LogFilter) matchesMessageType(messageType logmessage.LogMessage_MessageType) bool {
+	for _, filterType := range filter.MessageTypes {
+		if filterType == messageType {
+			return true
+		}
+	}
+	return false
+}
+
+func (filter LogFilter) filterMessages(messages []*logmessage.LogMessage) []*logmessage.LogMessage {
+	filtered := []*logmessage.LogMessage{}
+	for _, msg := range messages {
+		if filter.Matches(msg) {
+			filtered = append(filtered, msg)
+		}
+	}
+	return filtered
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package api_test
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/cloudfoundry/loggregatorlib/logmessage"
+	"github.com/gogo/protobuf/proto"
+
+	. "github.com/cloudfoundry/cli/cf/api"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LogFilter", func() {
+	var msg *logmessage.LogMessage
+
+	BeforeEach(func() {
+		messageType := logmessage.LogMessage_ERR
+		msg = &logmessage.LogMessage{
+			Message:     []byte("GET /index.html 404"),
+			AppId:       proto.String("my-app-guid"),
+			MessageType: &messageType,
+			SourceName:  proto.String("App"),
+			SourceId:    proto.String("1"),
+			Timestamp:   proto.Int64(1000),
+		}
+	})
+
+	It("matches every message when it is empty", func() {
+		Expect(LogFilter{}.Matches(msg)).To(BeTrue())
+	})
+
+	It("matches the source type without regard to case", func() {
+		Expect(LogFilter{SourceTypes: []string{"RTR", "APP"}}.Matches(msg)).To(BeTrue())
+		Expect(LogFilter{SourceTypes: []string{"RTR"}}.Matches(msg)).To(BeFalse())
+	})
+
+	It("matches the source type of sources naming their process type", func() {
+		msg.SourceName = proto.String("APP/PROC/WEB")
+		Expect(LogFilter{SourceTypes: []string{"APP"}}.Matches(msg)).To(BeTrue())
+	})
+
+	It("matches the instance index", func() {
+		Expect(LogFilter{Instances: []string{"0", "1"}}.Matches(msg)).To(BeTrue())
+		Expect(LogFilter{Instances: []string{"0"}}.Matches(msg)).To(BeFalse())
+	})
+
+	It("matches the stream", func() {
+		Expect(LogFilter{MessageTypes: []logmessage.LogMessage_MessageType{logmessage.LogMessage_ERR}}.Matches(msg)).To(BeTrue())
+		Expect(LogFilter{MessageTypes: []logmessage.LogMessage_MessageType{logmessage.LogMessage_OUT}}.Matches(msg)).To(BeFalse())
+	})
+
+	It("matches the message against the pattern", func() {
+		Expect(LogFilter{Pattern: regexp.MustCompile(`\s404$`)}.Matches(msg)).To(BeTrue())
+		Expect(LogFilter{Pattern: regexp.MustCompile(`\s500$`)}.Matches(msg)).To(BeFalse())
+	})
+
+	It("matches the messages sent since the given time", func() {
+		Expect(LogFilter{Since: time.Unix(0, 1000)}.Matches(msg)).To(BeTrue())
+		Expect(LogFilter{Since: time.Unix(0, 1001)}.Matches(msg)).To(BeFalse())
+	})
+
+	It("requires every given field to match", func() {
+		filter := LogFilter{
+			SourceTypes: []string{"APP"},
+			Instances:   []string{"2"},
+			Pattern:     regexp.MustCompile("404"),
+		}
+		Expect(filter.Matches(msg)).To(BeFalse())
+	})
+})
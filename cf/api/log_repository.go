@@ -2,6 +2,10 @@ package api
 
 import (
 	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
 	"time"
 
 	. "github.com/cloudfoundry/cli/cf/i18n"
@@ -11,18 +15,25 @@ import (
 	consumer "github.com/cloudfoundry/loggregator_consumer"
 	"github.com/cloudfoundry/loggregatorlib/logmessage"
 	noaa_errors "github.com/cloudfoundry/noaa/errors"
+	"github.com/gorilla/websocket"
 )
 
 //go:generate counterfeiter -o fakes/fake_logs_repository.go . LogsRepository
 type LogsRepository interface {
-	RecentLogsFor(appGuid string) ([]*logmessage.LogMessage, error)
-	TailLogsFor(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error
+	RecentLogsFor(appGuid string, filter LogFilter) ([]*logmessage.LogMessage, error)
+	TailLogsFor(appGuid string, filter LogFilter, onConnect func(), onMessage func(*logmessage.LogMessage)) error
+	TailLogsForApps(appGuids []string, filter LogFilter, onConnect func(), onReconnect func(appGuid string, missing time.Duration), onMessage func(*logmessage.LogMessage)) error
 	Close()
 }
 
 type LoggregatorLogsRepository struct {
-	consumer consumer.LoggregatorConsumer
-	config   core_config.Reader
+	consumer        consumer.LoggregatorConsumer
+	newConsumer     func() consumer.LoggregatorConsumer
+	streamConsumers []consumer.LoggregatorConsumer
+	closed          bool
+	closing         chan struct{}
+	consumersMutex  sync.Mutex
+	config          core_config.Reader
 	// TrustedCerts   []tls.Certificate
 	tokenRefresher authentication.TokenRefresher
 	messageQueue   *Loggregator_SortedMessageQueue
@@ -32,76 +43,379 @@ type LoggregatorLogsRepository struct {
 
 var BufferTime time.Duration = 5 * time.Second
 
-func NewLoggregatorLogsRepository(config core_config.Reader, consumer consumer.LoggregatorConsumer, refresher authentication.TokenRefresher) LogsRepository {
+// ReconnectBackoff is the time to wait before connecting again when a tail
+// drops. It doubles after every failed attempt, up to maxReconnectBackoff.
+var ReconnectBackoff time.Duration = 1 * time.Second
+
+const maxReconnectBackoff = 30 * time.Second
+
+// maxTokenRefreshes is how many times the token is refreshed to reconnect a
+// tail before giving up, as a token that is refused again and again will not
+// be accepted by waiting longer.
+const maxTokenRefreshes = 3
+
+// NewLoggregatorLogsRepository creates the consumers it needs with
+// newConsumer, as a consumer holds a single connection and tailing several
+// apps takes one connection per app.
+func NewLoggregatorLogsRepository(config core_config.Reader, newConsumer func() consumer.LoggregatorConsumer, refresher authentication.TokenRefresher) LogsRepository {
 	return &LoggregatorLogsRepository{
 		config:         config,
-		consumer:       consumer,
+		consumer:       newConsumer(),
+		newConsumer:    newConsumer,
 		tokenRefresher: refresher,
 		messageQueue:   NewLoggregator_SortedMessageQueue(BufferTime, time.Now),
 	}
 }
 
 func (repo *LoggregatorLogsRepository) Close() {
-	repo.consumer.Close()
+	repo.markClosed()
+	repo.closeConsumers()
 	repo.flushMessageQueue()
 }
 
-func (repo *LoggregatorLogsRepository) RecentLogsFor(appGuid string) ([]*logmessage.LogMessage, error) {
-	messages, err := repo.consumer.Recent(appGuid, repo.config.AccessToken())
+// RecentLogsFor asks a consumer of its own for the recent logs of the app, as
+// it is called while the consumers of the repository are tailing, and from
+// several goroutines at once. A consumer keeps the connection of its last
+// call, so it cannot be shared between concurrent calls.
+func (repo *LoggregatorLogsRepository) RecentLogsFor(appGuid string, filter LogFilter) ([]*logmessage.LogMessage, error) {
+	cnsmr := repo.newConsumer()
+	messages, err := cnsmr.Recent(appGuid, repo.config.AccessToken())
 
 	switch err.(type) {
 	case nil: // do nothing
 	case *noaa_errors.UnauthorizedError:
 		repo.tokenRefresher.RefreshAuthToken()
-		messages, err = repo.consumer.Recent(appGuid, repo.config.AccessToken())
+		messages, err = cnsmr.Recent(appGuid, repo.config.AccessToken())
 	default:
 		return messages, err
 	}
 
 	consumer.SortRecent(messages)
-	return messages, err
+	return filter.filterMessages(messages), err
 }
 
-func (repo *LoggregatorLogsRepository) TailLogsFor(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+func (repo *LoggregatorLogsRepository) TailLogsFor(appGuid string, filter LogFilter, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+	return repo.TailLogsForApps([]string{appGuid}, filter, onConnect, nil, onMessage)
+}
+
+// TailLogsForApps tails the logs of several apps at once. The messages of all
+// the apps go through the same sorted queue, so they are yielded in timestamp
+// order. onConnect is called once all the apps are connected.
+//
+// A tail that drops is connected again until the repository is closed, and
+// onReconnect is told how long the app was not tailed. When the tail cannot be
+// connected again for any other reason than the network, all the tails are
+// closed and the error is returned. It is called like
+// onMessage, after the messages received before the tail dropped. When the
+// filter has a Since time, the recent logs are used to fill in the logs from
+// before the tail started and the logs missed while reconnecting.
+func (repo *LoggregatorLogsRepository) TailLogsForApps(appGuids []string, filter LogFilter, onConnect func(), onReconnect func(appGuid string, missing time.Duration), onMessage func(*logmessage.LogMessage)) error {
 	repo.onMessage = onMessage
+	repo.open()
 
 	endpoint := repo.config.LoggregatorEndpoint()
 	if endpoint == "" {
 		return errors.New(T("Loggregator endpoint missing from config file"))
 	}
 
-	repo.consumer.SetOnConnectCallback(onConnect)
-	logChan, err := repo.consumer.Tail(appGuid, repo.config.AccessToken())
+	connected := 0
+	connectedMutex := &sync.Mutex{}
+	onStreamConnect := func() {
+		connectedMutex.Lock()
+		defer connectedMutex.Unlock()
+
+		connected++
+		if connected == len(appGuids) {
+			onConnect()
+		}
+	}
+
+	streams := []*logStream{}
+	for i, appGuid := range appGuids {
+		stream := &logStream{appGuid: appGuid, consumer: repo.streamConsumer(i), onConnect: onStreamConnect}
+		if err := repo.connect(stream); err != nil {
+			if i > 0 {
+				repo.closeConsumers()
+			}
+			return err
+		}
+		streams = append(streams, stream)
+	}
+
+	done := make(chan struct{})
+	reconnects := make(chan reconnectedStream)
+	errs := make(chan error, len(streams))
+	closeOnError := &sync.Once{}
+	wg := &sync.WaitGroup{}
+	for _, stream := range streams {
+		wg.Add(1)
+		go func(stream *logStream) {
+			defer wg.Done()
+			if !filter.Since.IsZero() {
+				repo.backfill(stream.appGuid, 0, stream.connectedAt, filter)
+				stream.lastTimestamp = stream.connectedAt
+			}
+			if err := repo.keepTailing(stream, filter, reconnects); err != nil {
+				errs <- err
+				closeOnError.Do(func() {
+					repo.markClosed()
+					repo.closeConsumers()
+				})
+			}
+		}(stream)
+	}
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	repo.bufferMessages(done, reconnects, onMessage, onReconnect)
+
+	select {
+	case err := <-errs:
+		repo.flushMessageQueue()
+		return err
+	default:
+		return nil
+	}
+}
+
+type logStream struct {
+	appGuid       string
+	consumer      consumer.LoggregatorConsumer
+	onConnect     func()
+	logChan       <-chan *logmessage.LogMessage
+	connectedAt   int64
+	lastTimestamp int64
+}
+
+// reconnectedStream tells that the tail of an app was connected again, after
+// missing its logs for a while. lastTimestamp is the timestamp of the last
+// message received before the tail dropped.
+type reconnectedStream struct {
+	appGuid       string
+	missing       time.Duration
+	lastTimestamp int64
+}
+
+func (repo *LoggregatorLogsRepository) connect(stream *logStream) error {
+	logChan, err := repo.tail(stream.consumer, stream.appGuid, stream.onConnect)
+	if err != nil {
+		return err
+	}
+
+	stream.logChan = logChan
+	stream.connectedAt = time.Now().UnixNano()
+	return nil
+}
+
+// keepTailing queues the messages of the stream until the repository is
+// closed, connecting again whenever the connection drops. Reconnections are
+// sent to reconnects, so that they are reported in order with the messages.
+// It returns the error that kept the stream from being connected again.
+func (repo *LoggregatorLogsRepository) keepTailing(stream *logStream, filter LogFilter, reconnects chan<- reconnectedStream) error {
+	for {
+		for msg := range stream.logChan {
+			if msg.GetTimestamp() > stream.lastTimestamp {
+				stream.lastTimestamp = msg.GetTimestamp()
+			}
+			repo.queueMessage(msg, filter)
+		}
+
+		droppedAt := time.Now()
+		connected, err := repo.reconnect(stream)
+		if !connected {
+			return err
+		}
+
+		reconnects <- reconnectedStream{
+			appGuid:       stream.appGuid,
+			missing:       time.Since(droppedAt),
+			lastTimestamp: stream.lastTimestamp,
+		}
+
+		if !filter.Since.IsZero() {
+			repo.backfill(stream.appGuid, stream.lastTimestamp, stream.connectedAt, filter)
+		}
+	}
+}
+
+// reconnect connects the stream again with an exponential backoff, as the
+// connection may drop because of a network blip, an idle timeout or an expired
+// token. It keeps trying while the server cannot be reached, and gives up with
+// the error when the server turns the tail down or keeps refusing the token.
+// It gives up without an error when the repository is closed.
+func (repo *LoggregatorLogsRepository) reconnect(stream *logStream) (bool, error) {
+	backoff := ReconnectBackoff
+	tokenRefreshes := 0
+	for {
+		select {
+		case <-repo.closingChan():
+			return false, nil
+		case <-time.After(backoff):
+		}
+
+		err := repo.connect(stream)
+		switch err.(type) {
+		case nil:
+			if repo.isClosed() {
+				stream.consumer.Close()
+				return false, nil
+			}
+			return true, nil
+		case *noaa_errors.UnauthorizedError:
+			// connecting has refreshed the token already
+			tokenRefreshes++
+			if tokenRefreshes >= maxTokenRefreshes {
+				return false, err
+			}
+		default:
+			if !isConnectionError(err) {
+				return false, err
+			}
+		}
+
+		backoff *= 2
+		if backoff > maxReconnectBackoff {
+			backoff = maxReconnectBackoff
+		}
+	}
+}
+
+// isConnectionError tells whether err is a failure to reach the log server,
+// which a new attempt may fix. The consumer turns the errors of dialing into
+// plain errors, so they are recognized by their message, leaving out the
+// handshakes the server answered with an error status.
+func isConnectionError(err error) bool {
+	if _, ok := err.(net.Error); ok {
+		return true
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+
+	message := err.Error()
+	return strings.HasPrefix(message, "Error dialing loggregator server") &&
+		!strings.Contains(message, websocket.ErrBadHandshake.Error())
+}
+
+// backfill queues the recent logs of the app between the after and before
+// timestamps. It does its best, so errors are ignored.
+func (repo *LoggregatorLogsRepository) backfill(appGuid string, after int64, before int64, filter LogFilter) {
+	messages, err := repo.RecentLogsFor(appGuid, LogFilter{})
+	if err != nil {
+		return
+	}
+
+	for _, msg := range messages {
+		if msg.GetTimestamp() > after && msg.GetTimestamp() < before {
+			repo.queueMessage(msg, filter)
+		}
+	}
+}
+
+func (repo *LoggregatorLogsRepository) queueMessage(msg *logmessage.LogMessage, filter LogFilter) {
+	if filter.Matches(msg) {
+		repo.messageQueue.PushMessage(msg)
+	}
+}
+
+func (repo *LoggregatorLogsRepository) tail(cnsmr consumer.LoggregatorConsumer, appGuid string, onConnect func()) (<-chan *logmessage.LogMessage, error) {
+	cnsmr.SetOnConnectCallback(onConnect)
+	logChan, err := cnsmr.Tail(appGuid, repo.config.AccessToken())
 	switch err.(type) {
 	case nil: // do nothing
 	case *noaa_errors.UnauthorizedError:
 		repo.tokenRefresher.RefreshAuthToken()
-		logChan, err = repo.consumer.Tail(appGuid, repo.config.AccessToken())
+		logChan, err = cnsmr.Tail(appGuid, repo.config.AccessToken())
 	default:
-		return err
+		return nil, err
 	}
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	repo.bufferMessages(logChan, onMessage)
-	return nil
+	return logChan, nil
+}
+
+// streamConsumer returns the consumer of the i-th app being tailed. The first
+// app uses the consumer of the repository and the other ones get their own.
+func (repo *LoggregatorLogsRepository) streamConsumer(i int) consumer.LoggregatorConsumer {
+	if i == 0 {
+		return repo.consumer
+	}
+
+	repo.consumersMutex.Lock()
+	defer repo.consumersMutex.Unlock()
+
+	cnsmr := repo.newConsumer()
+	repo.streamConsumers = append(repo.streamConsumers, cnsmr)
+	return cnsmr
+}
+
+func (repo *LoggregatorLogsRepository) open() {
+	repo.consumersMutex.Lock()
+	defer repo.consumersMutex.Unlock()
+
+	repo.closed = false
+	repo.closing = make(chan struct{})
+}
+
+func (repo *LoggregatorLogsRepository) markClosed() {
+	repo.consumersMutex.Lock()
+	defer repo.consumersMutex.Unlock()
+
+	if !repo.closed && repo.closing != nil {
+		close(repo.closing)
+	}
+	repo.closed = true
+}
+
+func (repo *LoggregatorLogsRepository) closingChan() <-chan struct{} {
+	repo.consumersMutex.Lock()
+	defer repo.consumersMutex.Unlock()
+
+	return repo.closing
+}
+
+func (repo *LoggregatorLogsRepository) isClosed() bool {
+	repo.consumersMutex.Lock()
+	defer repo.consumersMutex.Unlock()
+
+	return repo.closed
+}
+
+func (repo *LoggregatorLogsRepository) closeConsumers() {
+	repo.consumer.Close()
+
+	repo.consumersMutex.Lock()
+	defer repo.consumersMutex.Unlock()
+
+	for _, cnsmr := range repo.streamConsumers {
+		cnsmr.Close()
+	}
+	repo.streamConsumers = nil
 }
 
-func (repo *LoggregatorLogsRepository) bufferMessages(logChan <-chan *logmessage.LogMessage, onMessage func(*logmessage.LogMessage)) {
+// bufferMessages yields the queued messages once they have been buffered for
+// long enough. A reconnection is reported after the messages received before
+// the tail dropped, which are yielded right away.
+func (repo *LoggregatorLogsRepository) bufferMessages(done <-chan struct{}, reconnects <-chan reconnectedStream, onMessage func(*logmessage.LogMessage), onReconnect func(string, time.Duration)) {
 
 	for {
 		sendMessages(repo.messageQueue, onMessage)
 
 		select {
-		case msg, ok := <-logChan:
-			if !ok {
-				return
+		case <-done:
+			return
+		case reconnected := <-reconnects:
+			sendMessagesUntil(repo.messageQueue, reconnected.lastTimestamp, onMessage)
+			if onReconnect != nil {
+				onReconnect(reconnected.appGuid, reconnected.missing)
 			}
-			repo.messageQueue.PushMessage(msg)
-		default:
-			time.Sleep(1 * time.Millisecond)
+		case <-time.After(1 * time.Millisecond):
 		}
 	}
 }
@@ -129,3 +443,15 @@ func sendMessages(queue *Loggregator_SortedMessageQueue, onMessage func(*logmess
 		onMessage(msg)
 	}
 }
+
+// sendMessagesUntil yields the queued messages logged up to timestamp, without
+// waiting for them to be buffered.
+func sendMessagesUntil(queue *Loggregator_SortedMessageQueue, timestamp int64, onMessage func(*logmessage.LogMessage)) {
+	for {
+		msg := queue.PopMessageUntil(timestamp)
+		if msg == nil {
+			return
+		}
+		onMessage(msg)
+	}
+}
@@ -6,10 +6,12 @@ import (
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
 	"github.com/cloudfoundry/cli/cf/errors"
 	testconfig "github.com/cloudfoundry/cli/testhelpers/configuration"
+	consumer "github.com/cloudfoundry/loggregator_consumer"
 	"github.com/cloudfoundry/loggregatorlib/logmessage"
 	noaa_errors "github.com/cloudfoundry/noaa/errors"
 	"github.com/gogo/protobuf/proto"
 
+	"regexp"
 	"time"
 
 	. "github.com/cloudfoundry/cli/cf/api"
@@ -35,7 +37,7 @@ var _ = Describe("loggregator logs repository", func() {
 	})
 
 	JustBeforeEach(func() {
-		logsRepo = NewLoggregatorLogsRepository(configRepo, fakeConsumer, authRepo)
+		logsRepo = NewLoggregatorLogsRepository(configRepo, func() consumer.LoggregatorConsumer { return fakeConsumer }, authRepo)
 	})
 
 	Describe("RecentLogsFor", func() {
@@ -48,7 +50,7 @@ var _ = Describe("loggregator logs repository", func() {
 			})
 
 			It("refreshes the access token", func() {
-				_, err := logsRepo.RecentLogsFor("app-guid")
+				_, err := logsRepo.RecentLogsFor("app-guid", LogFilter{})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(authRepo.RefreshAuthTokenCallCount()).To(Equal(1))
 			})
@@ -60,11 +62,25 @@ var _ = Describe("loggregator logs repository", func() {
 			})
 
 			It("returns the error", func() {
-				_, err := logsRepo.RecentLogsFor("app-guid")
+				_, err := logsRepo.RecentLogsFor("app-guid", LogFilter{})
 				Expect(err).To(Equal(errors.New("oops")))
 			})
 		})
 
+		It("asks a consumer of its own, so that it does not share the connection of a tail", func() {
+			recentConsumer := testapi.NewFakeLoggregatorConsumer()
+			consumers := []consumer.LoggregatorConsumer{fakeConsumer, recentConsumer}
+			logsRepo = NewLoggregatorLogsRepository(configRepo, func() consumer.LoggregatorConsumer {
+				next := consumers[0]
+				consumers = consumers[1:]
+				return next
+			}, authRepo)
+
+			logsRepo.RecentLogsFor("app-guid", LogFilter{})
+			Expect(recentConsumer.RecentCalledWith.AppGuid).To(Equal("app-guid"))
+			Expect(fakeConsumer.RecentCalledWith.AppGuid).To(BeEmpty())
+		})
+
 		Context("when an error does not occur", func() {
 			BeforeEach(func() {
 				fakeConsumer.RecentReturns.Messages = []*logmessage.LogMessage{
@@ -74,17 +90,25 @@ var _ = Describe("loggregator logs repository", func() {
 			})
 
 			It("gets the logs for the requested app", func() {
-				logsRepo.RecentLogsFor("app-guid")
+				logsRepo.RecentLogsFor("app-guid", LogFilter{})
 				Expect(fakeConsumer.RecentCalledWith.AppGuid).To(Equal("app-guid"))
 			})
 
 			It("writes the sorted log messages onto the provided channel", func() {
-				messages, err := logsRepo.RecentLogsFor("app-guid")
+				messages, err := logsRepo.RecentLogsFor("app-guid", LogFilter{})
 				Expect(err).NotTo(HaveOccurred())
 
 				Expect(string(messages[0].Message)).To(Equal("My message 1"))
 				Expect(string(messages[1].Message)).To(Equal("My message 2"))
 			})
+
+			It("leaves out the log messages that do not match the filter", func() {
+				messages, err := logsRepo.RecentLogsFor("app-guid", LogFilter{Pattern: regexp.MustCompile("2$")})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(messages).To(HaveLen(1))
+				Expect(string(messages[0].Message)).To(Equal("My message 2"))
+			})
 		})
 	})
 
@@ -97,7 +121,7 @@ var _ = Describe("loggregator logs repository", func() {
 			})
 
 			It("returns an error", func() {
-				err := logsRepo.TailLogsFor("app-guid", func() {}, func(*logmessage.LogMessage) {})
+				err := logsRepo.TailLogsFor("app-guid", LogFilter{}, func() {}, func(*logmessage.LogMessage) {})
 				Expect(err).To(Equal(errors.New("oops")))
 			})
 		})
@@ -116,7 +140,7 @@ var _ = Describe("loggregator logs repository", func() {
 					}
 				}
 
-				err := logsRepo.TailLogsFor("app-guid", func() {}, func(*logmessage.LogMessage) {})
+				err := logsRepo.TailLogsFor("app-guid", LogFilter{}, func() {}, func(*logmessage.LogMessage) {})
 				Expect(err).ToNot(HaveOccurred())
 				Expect(authRepo.RefreshAuthTokenCallCount()).To(Equal(1))
 			})
@@ -127,7 +151,7 @@ var _ = Describe("loggregator logs repository", func() {
 						return nil, noaa_errors.NewUnauthorizedError("All the errors")
 					}
 
-					err := logsRepo.TailLogsFor("app-guid", func() {}, func(*logmessage.LogMessage) {})
+					err := logsRepo.TailLogsFor("app-guid", LogFilter{}, func() {}, func(*logmessage.LogMessage) {})
 					Expect(err).To(HaveOccurred())
 					close(done)
 				})
@@ -143,7 +167,7 @@ var _ = Describe("loggregator logs repository", func() {
 					return nil, nil
 				}
 
-				logsRepo.TailLogsFor("app-guid", func() {}, func(msg *logmessage.LogMessage) {})
+				logsRepo.TailLogsFor("app-guid", LogFilter{}, func() {}, func(msg *logmessage.LogMessage) {})
 			})
 
 			It("sets the on connect callback", func(done Done) {
@@ -153,7 +177,7 @@ var _ = Describe("loggregator logs repository", func() {
 				}
 
 				called := false
-				logsRepo.TailLogsFor("app-guid", func() { called = true }, func(msg *logmessage.LogMessage) {})
+				logsRepo.TailLogsFor("app-guid", LogFilter{}, func() { called = true }, func(msg *logmessage.LogMessage) {})
 				fakeConsumer.OnConnectCallback()
 				Expect(called).To(BeTrue())
 			})
@@ -178,7 +202,7 @@ var _ = Describe("loggregator logs repository", func() {
 					}
 
 					receivedMessages := []*logmessage.LogMessage{}
-					err := logsRepo.TailLogsFor("app-guid", func() {}, func(msg *logmessage.LogMessage) {
+					err := logsRepo.TailLogsFor("app-guid", LogFilter{}, func() {}, func(msg *logmessage.LogMessage) {
 						receivedMessages = append(receivedMessages, msg)
 						if len(receivedMessages) >= 3 {
 							logsRepo.Close()
@@ -197,6 +221,44 @@ var _ = Describe("loggregator logs repository", func() {
 				})
 			})
 
+			Context("and a filter is given", func() {
+				BeforeEach(func() {
+					BufferTime = 250 * time.Millisecond
+				})
+
+				It("only yields the messages matching the filter", func(done Done) {
+					fakeConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
+						logChan := make(chan *logmessage.LogMessage)
+						go func() {
+							logChan <- makeLogMessage("hello1", 100)
+							logChan <- makeLogMessage("skipped", 200)
+							logChan <- makeLogMessage("hello3", 300)
+							fakeConsumer.WaitForClose()
+							close(logChan)
+						}()
+
+						return logChan, nil
+					}
+
+					receivedMessages := []*logmessage.LogMessage{}
+					err := logsRepo.TailLogsFor("app-guid", LogFilter{Pattern: regexp.MustCompile("hello")}, func() {}, func(msg *logmessage.LogMessage) {
+						receivedMessages = append(receivedMessages, msg)
+						if len(receivedMessages) >= 2 {
+							logsRepo.Close()
+						}
+					})
+
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(receivedMessages).To(Equal([]*logmessage.LogMessage{
+						makeLogMessage("hello1", 100),
+						makeLogMessage("hello3", 300),
+					}))
+
+					close(done)
+				})
+			})
+
 			Context("and the buffer time is very long", func() {
 				BeforeEach(func() {
 					BufferTime = 30 * time.Second
@@ -204,14 +266,20 @@ var _ = Describe("loggregator logs repository", func() {
 
 				It("flushes remaining log messages when Close is called", func(done Done) {
 					synchronizationChannel := make(chan (bool))
+					closed := make(chan (bool))
 
-					fakeConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
-						fakeConsumer.OnConnectCallback()
+					// the tail outlives the call to Close, so it must not use
+					// fakeConsumer, which the next spec replaces
+					tailConsumer := fakeConsumer
+					tailConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
+						tailConsumer.OnConnectCallback()
 						logChan := make(chan *logmessage.LogMessage)
 						go func() {
 							logChan <- makeLogMessage("One does not simply consume a log message", 1000)
+							// the first message is queued once the second one is received
+							logChan <- makeLogMessage("Nor a second one", 2000)
 							synchronizationChannel <- true
-							fakeConsumer.WaitForClose()
+							tailConsumer.WaitForClose()
 							close(logChan)
 						}()
 
@@ -229,15 +297,302 @@ var _ = Describe("loggregator logs repository", func() {
 						logsRepo.Close()
 						Expect(receivedMessages).ToNot(BeEmpty())
 
-						done <- true
+						close(closed)
 					}()
 
-					err := logsRepo.TailLogsFor("app-guid", func() {}, func(msg *logmessage.LogMessage) {
+					err := logsRepo.TailLogsFor("app-guid", LogFilter{}, func() {}, func(msg *logmessage.LogMessage) {
 						receivedMessages = append(receivedMessages, msg)
 					})
 
 					Expect(err).NotTo(HaveOccurred())
+					<-closed
+					close(done)
+				})
+			})
+		})
+	})
+
+	Describe("tailing logs for several apps", func() {
+		var otherConsumer *testapi.FakeLoggregatorConsumer
+
+		BeforeEach(func() {
+			BufferTime = 250 * time.Millisecond
+			otherConsumer = testapi.NewFakeLoggregatorConsumer()
+		})
+
+		JustBeforeEach(func() {
+			consumers := []consumer.LoggregatorConsumer{fakeConsumer, otherConsumer}
+			logsRepo = NewLoggregatorLogsRepository(configRepo, func() consumer.LoggregatorConsumer {
+				next := consumers[0]
+				consumers = consumers[1:]
+				return next
+			}, authRepo)
+		})
+
+		It("tails every app with its own consumer", func(done Done) {
+			fakeConsumer.TailFunc = func(appGuid, _ string) (<-chan *logmessage.LogMessage, error) {
+				Expect(appGuid).To(Equal("app1-guid"))
+				logChan := make(chan *logmessage.LogMessage)
+				go func() {
+					logChan <- makeLogMessage("app1 first", 100)
+					logChan <- makeLogMessage("app1 second", 300)
+					fakeConsumer.WaitForClose()
+					close(logChan)
+				}()
+				return logChan, nil
+			}
+			otherConsumer.TailFunc = func(appGuid, _ string) (<-chan *logmessage.LogMessage, error) {
+				Expect(appGuid).To(Equal("app2-guid"))
+				logChan := make(chan *logmessage.LogMessage)
+				go func() {
+					logChan <- makeLogMessage("app2 first", 200)
+					otherConsumer.WaitForClose()
+					close(logChan)
+				}()
+				return logChan, nil
+			}
+
+			receivedMessages := []string{}
+			err := logsRepo.TailLogsForApps([]string{"app1-guid", "app2-guid"}, LogFilter{}, func() {}, nil, func(msg *logmessage.LogMessage) {
+				receivedMessages = append(receivedMessages, string(msg.GetMessage()))
+				if len(receivedMessages) >= 3 {
+					logsRepo.Close()
+				}
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(receivedMessages).To(Equal([]string{"app1 first", "app2 first", "app1 second"}))
+			Expect(otherConsumer.IsClosed).To(BeTrue())
+			close(done)
+		})
+
+		It("calls the on connect callback once all the apps are connected", func() {
+			fakeConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
+				return nil, nil
+			}
+			otherConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
+				fakeConsumer.OnConnectCallback()
+				return nil, errors.New("oops")
+			}
+
+			calls := 0
+			err := logsRepo.TailLogsForApps([]string{"app1-guid", "app2-guid"}, LogFilter{}, func() { calls++ }, nil, func(*logmessage.LogMessage) {})
+			Expect(err).To(Equal(errors.New("oops")))
+			Expect(calls).To(Equal(0))
+
+			otherConsumer.OnConnectCallback()
+			Expect(calls).To(Equal(1))
+		})
+
+		It("closes the connections already opened when an app cannot be tailed", func() {
+			fakeConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
+				return nil, nil
+			}
+			otherConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
+				return nil, errors.New("oops")
+			}
+
+			err := logsRepo.TailLogsForApps([]string{"app1-guid", "app2-guid"}, LogFilter{}, func() {}, nil, func(*logmessage.LogMessage) {})
+			Expect(err).To(Equal(errors.New("oops")))
+			Expect(fakeConsumer.IsClosed).To(BeTrue())
+			Expect(otherConsumer.IsClosed).To(BeTrue())
+		})
+	})
+
+	Describe("reconnecting a tail that drops", func() {
+		var tailCalls int
+
+		BeforeEach(func() {
+			BufferTime = 250 * time.Millisecond
+			ReconnectBackoff = 1 * time.Millisecond
+			tailCalls = 0
+		})
+
+		AfterEach(func() {
+			ReconnectBackoff = 1 * time.Second
+		})
+
+		dropOnce := func(firstTail func() (<-chan *logmessage.LogMessage, error)) {
+			fakeConsumer.TailFunc = func(_, _ string) (<-chan *logmessage.LogMessage, error) {
+				tailCalls++
+				if tailCalls == 1 {
+					return firstTail()
+				}
+
+				logChan := make(chan *logmessage.LogMessage)
+				go func() {
+					logChan <- makeLogMessage("after reconnect", time.Now().UnixNano())
+					fakeConsumer.WaitForClose()
+					close(logChan)
+				}()
+				return logChan, nil
+			}
+		}
+
+		droppingTail := func() (<-chan *logmessage.LogMessage, error) {
+			logChan := make(chan *logmessage.LogMessage)
+			go func() {
+				logChan <- makeLogMessage("before drop", time.Now().UnixNano())
+				close(logChan)
+			}()
+			return logChan, nil
+		}
+
+		It("connects again and tells how long the app was not tailed", func(done Done) {
+			dropOnce(droppingTail)
+
+			events := []string{}
+			err := logsRepo.TailLogsForApps([]string{"app-guid"}, LogFilter{}, func() {}, func(appGuid string, missing time.Duration) {
+				events = append(events, "reconnected "+appGuid)
+				Expect(missing).To(BeNumerically(">", 0))
+			}, func(msg *logmessage.LogMessage) {
+				events = append(events, string(msg.GetMessage()))
+				if len(events) >= 3 {
+					logsRepo.Close()
+				}
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tailCalls).To(Equal(2))
+			Expect(events).To(Equal([]string{"before drop", "reconnected app-guid", "after reconnect"}))
+			close(done)
+		})
+
+		It("refreshes the access token when reconnecting is unauthorized", func(done Done) {
+			dropOnce(droppingTail)
+			secondTail := fakeConsumer.TailFunc
+			fakeConsumer.TailFunc = func(appGuid, token string) (<-chan *logmessage.LogMessage, error) {
+				if tailCalls == 1 {
+					tailCalls++
+					return nil, noaa_errors.NewUnauthorizedError("token expired")
+				}
+				return secondTail(appGuid, token)
+			}
+
+			receivedMessages := []string{}
+			err := logsRepo.TailLogsForApps([]string{"app-guid"}, LogFilter{}, func() {}, nil, func(msg *logmessage.LogMessage) {
+				receivedMessages = append(receivedMessages, string(msg.GetMessage()))
+				if len(receivedMessages) >= 2 {
+					logsRepo.Close()
+				}
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(authRepo.RefreshAuthTokenCallCount()).To(Equal(1))
+			Expect(receivedMessages).To(Equal([]string{"before drop", "after reconnect"}))
+			close(done)
+		})
+
+		It("keeps reconnecting while the server cannot be reached", func(done Done) {
+			dropOnce(droppingTail)
+			reconnectTail := fakeConsumer.TailFunc
+			fakeConsumer.TailFunc = func(appGuid, token string) (<-chan *logmessage.LogMessage, error) {
+				if tailCalls > 0 && tailCalls < 3 {
+					tailCalls++
+					return nil, errors.New("Error dialing loggregator server: dial tcp: connection refused.")
+				}
+				return reconnectTail(appGuid, token)
+			}
+
+			receivedMessages := []string{}
+			err := logsRepo.TailLogsForApps([]string{"app-guid"}, LogFilter{}, func() {}, nil, func(msg *logmessage.LogMessage) {
+				receivedMessages = append(receivedMessages, string(msg.GetMessage()))
+				if len(receivedMessages) >= 2 {
+					logsRepo.Close()
+				}
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tailCalls).To(Equal(4))
+			Expect(receivedMessages).To(Equal([]string{"before drop", "after reconnect"}))
+			close(done)
+		})
+
+		It("gives up and returns the error when the server turns the tail down", func(done Done) {
+			dropOnce(droppingTail)
+			reconnectTail := fakeConsumer.TailFunc
+			fakeConsumer.TailFunc = func(appGuid, token string) (<-chan *logmessage.LogMessage, error) {
+				if tailCalls > 0 {
+					tailCalls++
+					return nil, errors.New("Error dialing loggregator server: websocket: bad handshake.")
+				}
+				return reconnectTail(appGuid, token)
+			}
+
+			receivedMessages := []string{}
+			err := logsRepo.TailLogsForApps([]string{"app-guid"}, LogFilter{}, func() {}, nil, func(msg *logmessage.LogMessage) {
+				receivedMessages = append(receivedMessages, string(msg.GetMessage()))
+			})
+
+			Expect(err).To(MatchError("Error dialing loggregator server: websocket: bad handshake."))
+			Expect(tailCalls).To(Equal(2))
+			Expect(receivedMessages).To(Equal([]string{"before drop"}))
+			close(done)
+		})
+
+		It("gives up when the token is refused after refreshing it a few times", func(done Done) {
+			dropOnce(droppingTail)
+			reconnectTail := fakeConsumer.TailFunc
+			fakeConsumer.TailFunc = func(appGuid, token string) (<-chan *logmessage.LogMessage, error) {
+				if tailCalls > 0 {
+					tailCalls++
+					return nil, noaa_errors.NewUnauthorizedError("token refused")
+				}
+				return reconnectTail(appGuid, token)
+			}
+
+			err := logsRepo.TailLogsForApps([]string{"app-guid"}, LogFilter{}, func() {}, nil, func(*logmessage.LogMessage) {})
+
+			Expect(err).To(BeAssignableToTypeOf(&noaa_errors.UnauthorizedError{}))
+			Expect(authRepo.RefreshAuthTokenCallCount()).To(Equal(3))
+			close(done)
+		})
+
+		It("stops reconnecting when closed", func(done Done) {
+			ReconnectBackoff = 1 * time.Hour
+			dropOnce(droppingTail)
+
+			closing := false
+			err := logsRepo.TailLogsForApps([]string{"app-guid"}, LogFilter{}, func() {}, nil, func(msg *logmessage.LogMessage) {
+				if !closing {
+					closing = true
+					go logsRepo.Close()
+				}
+			})
+
+			Expect(err).NotTo(HaveOccurred())
+			Expect(tailCalls).To(Equal(1))
+			close(done)
+		})
+
+		Context("when a since time is given", func() {
+			It("fills in the logs from before the tail and the logs missed while reconnecting", func(done Done) {
+				fakeConsumer.RecentReturns.Messages = []*logmessage.LogMessage{
+					makeLogMessage("too old", time.Now().Add(-2*time.Hour).UnixNano()),
+					makeLogMessage("backfilled", time.Now().Add(-30*time.Minute).UnixNano()),
+				}
+				dropOnce(droppingTail)
+				reconnectTail := fakeConsumer.TailFunc
+				fakeConsumer.TailFunc = func(appGuid, token string) (<-chan *logmessage.LogMessage, error) {
+					if tailCalls == 1 {
+						fakeConsumer.RecentReturns.Messages = append(fakeConsumer.RecentReturns.Messages,
+							makeLogMessage("missed", time.Now().UnixNano()))
+					}
+					return reconnectTail(appGuid, token)
+				}
+
+				receivedMessages := []string{}
+				filter := LogFilter{Since: time.Now().Add(-1 * time.Hour)}
+				err := logsRepo.TailLogsForApps([]string{"app-guid"}, filter, func() {}, nil, func(msg *logmessage.LogMessage) {
+					receivedMessages = append(receivedMessages, string(msg.GetMessage()))
+					if len(receivedMessages) >= 4 {
+						logsRepo.Close()
+					}
 				})
+
+				Expect(err).NotTo(HaveOccurred())
+				Expect(receivedMessages).To(Equal([]string{"backfilled", "before drop", "missed", "after reconnect"}))
+				close(done)
 			})
 		})
 	})
@@ -54,6 +54,22 @@ func (pq *Loggregator_SortedMessageQueue) PopMessage() *logmessage.LogMessage {
 	return item.message
 }
 
+// PopMessageUntil pops the first message if it was logged at or before
+// timestamp, and returns nil otherwise.
+func (pq *Loggregator_SortedMessageQueue) PopMessageUntil(timestamp int64) *logmessage.LogMessage {
+	pq.mutex.Lock()
+	defer pq.mutex.Unlock()
+
+	if len(pq.items) == 0 || pq.items[0].message.GetTimestamp() > timestamp {
+		return nil
+	}
+
+	item := pq.items[0]
+	pq.items = pq.items[1:len(pq.items)]
+
+	return item.message
+}
+
 func (pq *Loggregator_SortedMessageQueue) NextTimestamp() int64 {
 	pq.mutex.Lock()
 	defer pq.mutex.Unlock()
@@ -35,6 +35,20 @@ var _ = Describe("is a priority queue used to sort loggregator messages", func()
 		Expect(pq.PopMessage()).To(BeNil())
 	})
 
+	It("PopMessageUntil pops the messages logged up to a timestamp", func() {
+		pq := NewLoggregator_SortedMessageQueue(5*time.Second, time.Now)
+
+		msg2 := logLoggregatorMessageWithTime("message 2", 120)
+		pq.PushMessage(msg2)
+		msg1 := logLoggregatorMessageWithTime("message 1", 110)
+		pq.PushMessage(msg1)
+
+		Expect(getLoggregatorMsgString(pq.PopMessageUntil(115))).To(Equal(getLoggregatorMsgString(msg1)))
+		Expect(pq.PopMessageUntil(115)).To(BeNil())
+		Expect(getLoggregatorMsgString(pq.PopMessageUntil(120))).To(Equal(getLoggregatorMsgString(msg2)))
+		Expect(pq.PopMessageUntil(120)).To(BeNil())
+	})
+
 	It("NextTimeStamp returns the timestamp of the log message at the head of the queue", func() {
 		currentTime := time.Unix(5, 0)
 		clock := func() time.Time {
@@ -40,7 +40,7 @@ type RepositoryLocator struct {
 	quotaRepo                       quotas.QuotaRepository
 	spaceRepo                       spaces.SpaceRepository
 	appRepo                         applications.ApplicationRepository
-	appBitsRepo                     application_bits.CloudControllerApplicationBitsRepository
+	appBitsRepo                     application_bits.ApplicationBitsRepository
 	appSummaryRepo                  AppSummaryRepository
 	appInstancesRepo                app_instances.AppInstancesRepository
 	appEventsRepo                   app_events.AppEventsRepository
@@ -57,6 +57,7 @@ type RepositoryLocator struct {
 	userRepo                        UserRepository
 	passwordRepo                    password.PasswordRepository
 	logsRepo                        LogsRepository
+	newLogsRepo                     func() LogsRepository
 	authTokenRepo                   ServiceAuthTokenRepository
 	serviceBrokerRepo               ServiceBrokerRepository
 	servicePlanRepo                 CloudControllerServicePlanRepository
@@ -87,8 +88,11 @@ func NewRepositoryLocator(config core_config.ReadWriter, gatewaysByName map[stri
 	uaaGateway.SetTokenRefresher(loc.authRepo)
 
 	tlsConfig := net.NewTLSConfig([]tls.Certificate{}, config.IsSSLDisabled())
-	loggregatorConsumer := consumer.New(config.LoggregatorEndpoint(), tlsConfig, http.ProxyFromEnvironment)
-	loggregatorConsumer.SetDebugPrinter(terminal.DebugPrinter{})
+	newLoggregatorConsumer := func() consumer.LoggregatorConsumer {
+		loggregatorConsumer := consumer.New(config.LoggregatorEndpoint(), tlsConfig, http.ProxyFromEnvironment)
+		loggregatorConsumer.SetDebugPrinter(terminal.DebugPrinter{})
+		return loggregatorConsumer
+	}
 
 	loc.appBitsRepo = application_bits.NewCloudControllerApplicationBitsRepository(config, cloudControllerGateway)
 	loc.appEventsRepo = app_events.NewCloudControllerAppEventsRepository(config, cloudControllerGateway, strategy)
@@ -100,7 +104,10 @@ func NewRepositoryLocator(config core_config.ReadWriter, gatewaysByName map[stri
 	loc.curlRepo = NewCloudControllerCurlRepository(config, cloudControllerGateway)
 	loc.domainRepo = NewCloudControllerDomainRepository(config, cloudControllerGateway, strategy)
 	loc.endpointRepo = NewEndpointRepository(config, cloudControllerGateway)
-	loc.logsRepo = NewLoggregatorLogsRepository(config, loggregatorConsumer, loc.authRepo)
+	loc.newLogsRepo = func() LogsRepository {
+		return NewLoggregatorLogsRepository(config, newLoggregatorConsumer, loc.authRepo)
+	}
+	loc.logsRepo = loc.newLogsRepo()
 	loc.organizationRepo = organizations.NewCloudControllerOrganizationRepository(config, cloudControllerGateway)
 	loc.passwordRepo = password.NewCloudControllerPasswordRepository(config, uaaGateway)
 	loc.quotaRepo = quotas.NewCloudControllerQuotaRepository(config, cloudControllerGateway)
@@ -194,6 +201,11 @@ func (locator RepositoryLocator) GetApplicationRepository() applications.Applica
 	return locator.appRepo
 }
 
+func (locator RepositoryLocator) SetApplicationBitsRepository(repo application_bits.ApplicationBitsRepository) RepositoryLocator {
+	locator.appBitsRepo = repo
+	return locator
+}
+
 func (locator RepositoryLocator) GetApplicationBitsRepository() application_bits.ApplicationBitsRepository {
 	return locator.appBitsRepo
 }
@@ -334,6 +346,7 @@ func (locator RepositoryLocator) GetPasswordRepository() password.PasswordReposi
 
 func (locator RepositoryLocator) SetLogsRepository(repo LogsRepository) RepositoryLocator {
 	locator.logsRepo = repo
+	locator.newLogsRepo = nil
 	return locator
 }
 
@@ -341,6 +354,16 @@ func (locator RepositoryLocator) GetLogsRepository() LogsRepository {
 	return locator.logsRepo
 }
 
+// NewLogsRepository returns a logs repository with its own connections and
+// message queue, for tailing logs alongside the shared one. A repository set
+// with SetLogsRepository is returned as is.
+func (locator RepositoryLocator) NewLogsRepository() LogsRepository {
+	if locator.newLogsRepo == nil {
+		return locator.logsRepo
+	}
+	return locator.newLogsRepo()
+}
+
 func (locator RepositoryLocator) SetServiceAuthTokenRepository(repo ServiceAuthTokenRepository) RepositoryLocator {
 	locator.authTokenRepo = repo
 	return locator
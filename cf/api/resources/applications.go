@@ -64,10 +64,16 @@ type ApplicationEntity struct {
 	StagingFailedReason  *string                 `json:"staging_failed_reason,omitempty"`
 	Diego                *bool                   `json:"diego,omitempty"`
 	DockerImage          *string                 `json:"docker_image,omitempty"`
+	DockerCredentials    *DockerCredentials      `json:"docker_credentials,omitempty"`
 	EnableSsh            *bool                   `json:"enable_ssh,omitempty"`
 	PackageUpdatedAt     *time.Time              `json:"package_updated_at,omitempty"`
 }
 
+type DockerCredentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
 func (resource AppRouteResource) ToFields() (route models.RouteSummary) {
 	route.Guid = resource.Metadata.Guid
 	route.Host = resource.Entity.Host
@@ -107,6 +113,12 @@ func NewApplicationEntityFromAppParams(app models.AppParams) ApplicationEntity {
 		PackageUpdatedAt:   app.PackageUpdatedAt,
 	}
 
+	if app.DockerCredentials != nil {
+		entity.DockerCredentials = &DockerCredentials{
+			Username: app.DockerCredentials.Username,
+			Password: app.DockerCredentials.Password,
+		}
+	}
 	if app.State != nil {
 		state := strings.ToUpper(*app.State)
 		entity.State = &state
@@ -1,13 +1,11 @@
 package app_files
 
 import (
-	"crypto/sha1"
-	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
 
 	"github.com/cloudfoundry/cli/cf/models"
 	"github.com/cloudfoundry/gofileutils/fileutils"
@@ -17,14 +15,29 @@ const windowsPathPrefix = `\\?\`
 
 type AppFiles interface {
 	AppFilesInDir(dir string) (appFiles []models.AppFileFields, err error)
+	AppFilesInArchiveDir(dir string) (appFiles []models.AppFileFields, err error)
 	CopyFiles(appFiles []models.AppFileFields, fromDir, toDir string) (err error)
 	CountFiles(directory string) int64
 	WalkAppFiles(dir string, onEachFile func(string, string) error) (err error)
+	IgnoredFilesInDir(dir string) (ignoredFiles []string, err error)
 }
 
-type ApplicationFiles struct{}
+type ApplicationFiles struct {
+	HashCache FileHashCache
+}
 
 func (appfiles ApplicationFiles) AppFilesInDir(dir string) (appFiles []models.AppFileFields, err error) {
+	return appfiles.appFilesInDir(dir, appfiles.HashCache)
+}
+
+// AppFilesInArchiveDir lists the files of an archive extracted to dir. Their
+// hashes are not cached, as dir is a temporary directory that is removed
+// after the push.
+func (appfiles ApplicationFiles) AppFilesInArchiveDir(dir string) (appFiles []models.AppFileFields, err error) {
+	return appfiles.appFilesInDir(dir, nil)
+}
+
+func (appfiles ApplicationFiles) appFilesInDir(dir string, hashCache FileHashCache) (appFiles []models.AppFileFields, err error) {
 	dir, err = filepath.Abs(dir)
 	if err != nil {
 		return
@@ -45,19 +58,14 @@ func (appfiles ApplicationFiles) AppFilesInDir(dir string) (appFiles []models.Ap
 			appFile.Sha1 = "0"
 			appFile.Size = 0
 		} else {
-			hash := sha1.New()
-			file, err := os.Open(fullPath)
-			if err != nil {
-				return err
+			if hashCache != nil {
+				appFile.Sha1, err = hashCache.Sha1(fullPath, fileInfo)
+			} else {
+				appFile.Sha1, err = fileSha1(fullPath)
 			}
-			defer file.Close()
-
-			_, err = io.Copy(hash, file)
 			if err != nil {
 				return err
 			}
-
-			appFile.Sha1 = fmt.Sprintf("%x", hash.Sum(nil))
 		}
 
 		appFiles = append(appFiles, appFile)
@@ -65,6 +73,12 @@ func (appfiles ApplicationFiles) AppFilesInDir(dir string) (appFiles []models.Ap
 		return nil
 	})
 
+	if err == nil && hashCache != nil {
+		// the cache only saves time on the next push, failing to write it
+		// should not fail this one
+		hashCache.Save()
+	}
+
 	return
 }
 
@@ -143,7 +157,22 @@ func (appfiles ApplicationFiles) CountFiles(directory string) int64 {
 }
 
 func (appfiles ApplicationFiles) WalkAppFiles(dir string, onEachFile func(string, string) error) error {
-	cfIgnore := loadIgnoreFile(dir)
+	return walkAppFiles(dir, onEachFile, func(string) {})
+}
+
+// IgnoredFilesInDir lists the files and directories excluded by the
+// .cfignore files of dir. The contents of excluded directories are not
+// listed, and directories are given with a trailing slash.
+func (appfiles ApplicationFiles) IgnoredFilesInDir(dir string) ([]string, error) {
+	ignoredFiles := []string{}
+	err := walkAppFiles(dir, func(_, _ string) error { return nil }, func(fileRelativeUnixPath string) {
+		ignoredFiles = append(ignoredFiles, fileRelativeUnixPath)
+	})
+	return ignoredFiles, err
+}
+
+func walkAppFiles(dir string, onEachFile func(string, string) error, onIgnoredFile func(string)) error {
+	cfIgnore := newCfIgnore()
 	walkFunc := func(fullPath string, f os.FileInfo, err error) error {
 		fileRelativePath, _ := filepath.Rel(dir, fullPath)
 		fileRelativeUnixPath := filepath.ToSlash(fileRelativePath)
@@ -156,8 +185,21 @@ func (appfiles ApplicationFiles) WalkAppFiles(dir string, onEachFile func(string
 			fullPath = windowsPathPrefix + fullPath
 		}
 
+		isDir := err == nil && f.IsDir()
+		if fullPath == dir {
+			if isDir {
+				cfIgnore.addIgnoreFile(fullPath, "")
+			}
+			return err
+		}
+
+		if isDir {
+			fileRelativeUnixPath += "/"
+		}
+
 		if cfIgnore.FileShouldBeIgnored(fileRelativeUnixPath) {
-			if err == nil && f.IsDir() {
+			onIgnoredFile(fileRelativeUnixPath)
+			if isDir {
 				return filepath.SkipDir
 			}
 			return nil
@@ -167,12 +209,12 @@ func (appfiles ApplicationFiles) WalkAppFiles(dir string, onEachFile func(string
 			return err
 		}
 
-		if fullPath == dir {
+		if !f.Mode().IsRegular() && !f.IsDir() {
 			return nil
 		}
 
-		if !f.Mode().IsRegular() && !f.IsDir() {
-			return nil
+		if isDir {
+			cfIgnore.addIgnoreFile(fullPath, strings.TrimSuffix(fileRelativeUnixPath, "/"))
 		}
 
 		return onEachFile(fileRelativePath, fullPath)
@@ -180,12 +222,3 @@ func (appfiles ApplicationFiles) WalkAppFiles(dir string, onEachFile func(string
 
 	return filepath.Walk(dir, walkFunc)
 }
-
-func loadIgnoreFile(dir string) CfIgnore {
-	fileContents, err := ioutil.ReadFile(filepath.Join(dir, ".cfignore"))
-	if err != nil {
-		return NewCfIgnore("")
-	}
-
-	return NewCfIgnore(string(fileContents))
-}
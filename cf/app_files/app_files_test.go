@@ -1,6 +1,7 @@
 package app_files_test
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -8,6 +9,7 @@ import (
 	"strings"
 
 	"github.com/cloudfoundry/cli/cf/app_files"
+	"github.com/cloudfoundry/cli/cf/app_files/fakes"
 	"github.com/nu7hatch/gouuid"
 
 	"github.com/cloudfoundry/cli/cf/models"
@@ -70,10 +72,28 @@ var _ = Describe("AppFiles", func() {
 				"dir1/child-dir/file3.txt",
 				"dir1/file1.txt",
 				"dir2",
+			}))
+		})
+
+		It("applies nested .cfignore files relative to their own directory", func() {
+			appPath := filepath.Join(fixturePath, "app-with-nested-cfignore")
+			files, err := appFiles.AppFilesInDir(appPath)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			paths := []string{}
+			for _, file := range files {
+				paths = append(paths, file.Path)
+			}
 
-				// TODO: this should be excluded.
-				// .cfignore doesn't handle ** patterns right now
-				"dir2/child-dir2",
+			Expect(paths).To(Equal([]string{
+				"app.rb",
+				"logs",
+				"logs/keep.txt",
+				"src",
+				"src/build",
+				"src/build/out.txt",
+				"src/important.log",
+				"src/main.rb",
 			}))
 		})
 
@@ -98,6 +118,63 @@ var _ = Describe("AppFiles", func() {
 				Expect(sizes).To(Equal([]int64{0}))
 			})
 		})
+
+		Context("when a hash cache is given", func() {
+			var hashCache *fakes.FakeFileHashCache
+
+			BeforeEach(func() {
+				hashCache = &fakes.FakeFileHashCache{}
+				hashCache.Sha1Returns("cached-sha", nil)
+				appFiles.HashCache = hashCache
+			})
+
+			It("takes the sha1 of files from the cache and saves it", func() {
+				appPath := filepath.Join(fixturePath, "app-with-cfignore")
+				files, err := appFiles.AppFilesInDir(appPath)
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, file := range files {
+					if file.Sha1 != "0" {
+						Expect(file.Sha1).To(Equal("cached-sha"))
+					}
+				}
+				Expect(hashCache.Sha1CallCount()).To(Equal(2))
+				Expect(hashCache.SaveCallCount()).To(Equal(1))
+			})
+
+			It("returns errors from the cache", func() {
+				hashCache.Sha1Returns("", errors.New("unreadable"))
+
+				_, err := appFiles.AppFilesInDir(filepath.Join(fixturePath, "app-with-cfignore"))
+				Expect(err).To(MatchError("unreadable"))
+				Expect(hashCache.SaveCallCount()).To(Equal(0))
+			})
+
+			It("does not use the cache for the files of an extracted archive", func() {
+				files, err := appFiles.AppFilesInArchiveDir(filepath.Join(fixturePath, "app-with-cfignore"))
+				Expect(err).ToNot(HaveOccurred())
+
+				for _, file := range files {
+					Expect(file.Sha1).ToNot(Equal("cached-sha"))
+				}
+				Expect(hashCache.Sha1CallCount()).To(BeZero())
+				Expect(hashCache.SaveCallCount()).To(BeZero())
+			})
+		})
+	})
+
+	Describe("IgnoredFilesInDir", func() {
+		It("lists the excluded files without the contents of excluded directories", func() {
+			ignoredFiles, err := appFiles.IgnoredFilesInDir(filepath.Join(fixturePath, "app-with-nested-cfignore"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ignoredFiles).To(Equal([]string{
+				".cfignore",
+				"logs/debug.log",
+				"src/.cfignore",
+				"src/tmp/",
+			}))
+		})
 	})
 
 	Describe("CopyFiles", func() {
@@ -1,50 +1,61 @@
 package app_files
 
 import (
+	"io/ioutil"
 	"path"
+	"path/filepath"
+	"regexp"
 	"strings"
-
-	"github.com/cloudfoundry/cli/glob"
 )
 
 type CfIgnore interface {
 	FileShouldBeIgnored(path string) bool
 }
 
+// NewCfIgnore parses the text of a top level .cfignore file. The patterns
+// follow the gitignore rules.
 func NewCfIgnore(text string) CfIgnore {
-	patterns := []ignorePattern{}
-	lines := strings.Split(text, "\n")
-	lines = append(defaultIgnoreLines, lines...)
-
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
+	ignore := newCfIgnore()
+	ignore.addPatterns("", text)
+	return ignore
+}
 
-		ignore := true
-		if strings.HasPrefix(line, "!") {
-			line = line[1:]
-			ignore = false
-		}
+func newCfIgnore() *cfIgnore {
+	ignore := &cfIgnore{}
+	ignore.addPatterns("", strings.Join(defaultIgnoreLines, "\n"))
+	return ignore
+}
 
-		for _, p := range globsForPattern(path.Clean(line)) {
-			patterns = append(patterns, ignorePattern{ignore, p})
+// FileShouldBeIgnored tells whether the file at the given slash separated
+// path, relative to the app directory, is excluded. Directories are given with
+// a trailing slash. A file is also excluded when one of its parent directories
+// is, as a pattern cannot include a file back from an excluded directory.
+func (ignore *cfIgnore) FileShouldBeIgnored(filePath string) bool {
+	isDir := strings.HasSuffix(filePath, "/")
+	parts := strings.Split(strings.Trim(filePath, "/"), "/")
+
+	for i := range parts {
+		if ignore.matches(strings.Join(parts[:i+1], "/"), isDir || i < len(parts)-1) {
+			return true
 		}
 	}
 
-	return cfIgnore(patterns)
+	return false
 }
 
-func (ignore cfIgnore) FileShouldBeIgnored(path string) bool {
+func (ignore *cfIgnore) matches(filePath string, isDir bool) bool {
 	result := false
 
-	for _, pattern := range ignore {
-		if strings.HasPrefix(pattern.glob.String(), "/") && !strings.HasPrefix(path, "/") {
-			path = "/" + path
+	for _, pattern := range ignore.patterns {
+		relativePath := filePath
+		if pattern.dir != "" {
+			if !strings.HasPrefix(filePath, pattern.dir+"/") {
+				continue
+			}
+			relativePath = filePath[len(pattern.dir)+1:]
 		}
 
-		if pattern.glob.Match(path) {
+		if pattern.match(relativePath, isDir) {
 			result = pattern.exclude
 		}
 	}
@@ -52,26 +63,168 @@ func (ignore cfIgnore) FileShouldBeIgnored(path string) bool {
 	return result
 }
 
-func globsForPattern(pattern string) (globs []glob.Glob) {
-	globs = append(globs, glob.MustCompileGlob(pattern))
-	globs = append(globs, glob.MustCompileGlob(path.Join(pattern, "*")))
-	globs = append(globs, glob.MustCompileGlob(path.Join(pattern, "**", "*")))
+// addIgnoreFile adds the patterns of the .cfignore file found in fullPath,
+// the directory at dir relative to the app directory. Patterns of deeper
+// files are added later, so they take precedence over the ones of their
+// parents.
+func (ignore *cfIgnore) addIgnoreFile(fullPath string, dir string) {
+	fileContents, err := ioutil.ReadFile(filepath.Join(fullPath, ".cfignore"))
+	if err != nil {
+		return
+	}
 
-	if !strings.HasPrefix(pattern, "/") {
-		globs = append(globs, glob.MustCompileGlob(path.Join("**", pattern)))
-		globs = append(globs, glob.MustCompileGlob(path.Join("**", pattern, "*")))
-		globs = append(globs, glob.MustCompileGlob(path.Join("**", pattern, "**", "*")))
+	ignore.addPatterns(dir, string(fileContents))
+}
+
+func (ignore *cfIgnore) addPatterns(dir string, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if pattern, ok := parseIgnorePattern(dir, line); ok {
+			ignore.patterns = append(ignore.patterns, pattern)
+		}
 	}
+}
 
-	return
+func parseIgnorePattern(dir string, line string) (ignorePattern, bool) {
+	line = trimTrailingSpaces(strings.TrimSuffix(line, "\r"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignorePattern{}, false
+	}
+
+	pattern := ignorePattern{dir: dir, exclude: true}
+	if strings.HasPrefix(line, "!") {
+		line = line[1:]
+		pattern.exclude = false
+	}
+
+	if strings.HasSuffix(line, "/") {
+		line = strings.TrimSuffix(line, "/")
+		pattern.dirOnly = true
+	}
+
+	pattern.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+	if line == "" {
+		return ignorePattern{}, false
+	}
+
+	regex, err := regexp.Compile("^" + ignorePatternRegex(line) + "$")
+	if err != nil {
+		return ignorePattern{}, false
+	}
+	pattern.regex = regex
+
+	return pattern, true
+}
+
+// trimTrailingSpaces removes the trailing spaces of a line, except for the
+// ones escaped with a backslash.
+func trimTrailingSpaces(line string) string {
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, `\ `) {
+		line = line[:len(line)-1]
+	}
+	return line
+}
+
+// ignorePatternRegex translates a gitignore pattern to a regular expression.
+// "*" and "?" do not match slashes, "**" matches any number of directories
+// when it is a whole path segment, and a backslash escapes the next character.
+func ignorePatternRegex(pattern string) string {
+	regex := ""
+
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			end := i
+			for end < len(pattern) && pattern[end] == '*' {
+				end++
+			}
+
+			wholeSegment := end-i >= 2 && (i == 0 || pattern[i-1] == '/') && (end == len(pattern) || pattern[end] == '/')
+			switch {
+			case wholeSegment && end == len(pattern):
+				regex += ".*"
+			case wholeSegment:
+				regex += "(?:.*/)?"
+				end++
+			default:
+				regex += "[^/]*"
+			}
+			i = end - 1
+		case '?':
+			regex += "[^/]"
+		case '[':
+			class, length := ignorePatternClass(pattern[i:])
+			if length == 0 {
+				regex += regexp.QuoteMeta("[")
+				continue
+			}
+			regex += class
+			i += length - 1
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+			regex += regexp.QuoteMeta(string(pattern[i]))
+		default:
+			regex += regexp.QuoteMeta(string(c))
+		}
+	}
+
+	return regex
+}
+
+// ignorePatternClass translates the bracket expression at the start of
+// pattern, returning its length in the pattern, or 0 if it is not closed.
+func ignorePatternClass(pattern string) (string, int) {
+	i := 1
+	class := "["
+	if i < len(pattern) && (pattern[i] == '!' || pattern[i] == '^') {
+		class += "^/"
+		i++
+	}
+
+	for start := i; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case c == ']' && i > start:
+			return class + "]", i + 1
+		case c == '\\' && i+1 < len(pattern):
+			i++
+			class += regexp.QuoteMeta(string(pattern[i]))
+		case c == '-':
+			class += "-"
+		default:
+			class += regexp.QuoteMeta(string(c))
+		}
+	}
+
+	return "", 0
 }
 
 type ignorePattern struct {
-	exclude bool
-	glob    glob.Glob
+	dir      string
+	exclude  bool
+	dirOnly  bool
+	anchored bool
+	regex    *regexp.Regexp
+}
+
+// match tells whether the pattern matches the path, relative to the
+// directory of the .cfignore file declaring the pattern. Patterns without a
+// slash match the name of the file at any depth.
+func (pattern ignorePattern) match(relativePath string, isDir bool) bool {
+	if pattern.dirOnly && !isDir {
+		return false
+	}
+
+	if pattern.anchored {
+		return pattern.regex.MatchString(relativePath)
+	}
+	return pattern.regex.MatchString(path.Base(relativePath))
 }
 
-type cfIgnore []ignorePattern
+type cfIgnore struct {
+	patterns []ignorePattern
+}
 
 var defaultIgnoreLines = []string{
 	".cfignore",
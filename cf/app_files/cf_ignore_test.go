@@ -59,6 +59,73 @@ stuff/exclude.c`)
 		Expect(ignore.FileShouldBeIgnored(".git/objects")).To(BeFalse())
 	})
 
+	It("does not include files back from an excluded directory", func() {
+		ignore := NewCfIgnore(`
+build
+!build/keep.txt`)
+
+		Expect(ignore.FileShouldBeIgnored("build/keep.txt")).To(BeTrue())
+	})
+
+	It("anchors patterns containing a slash to the app directory", func() {
+		ignore := NewCfIgnore(`/tmp
+logs/*.log`)
+
+		Expect(ignore.FileShouldBeIgnored("tmp")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("src/tmp")).To(BeFalse())
+		Expect(ignore.FileShouldBeIgnored("logs/debug.log")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("src/logs/debug.log")).To(BeFalse())
+	})
+
+	It("matches patterns without a slash at any depth", func() {
+		ignore := NewCfIgnore(`*.log`)
+		Expect(ignore.FileShouldBeIgnored("debug.log")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("src/logs/debug.log")).To(BeTrue())
+	})
+
+	It("matches patterns with a trailing slash only against directories", func() {
+		ignore := NewCfIgnore(`cache/`)
+		Expect(ignore.FileShouldBeIgnored("cache/")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("src/cache/data.bin")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("cache")).To(BeFalse())
+	})
+
+	It("matches any number of directories with a double-star in the middle of a pattern", func() {
+		ignore := NewCfIgnore(`a/**/b`)
+		Expect(ignore.FileShouldBeIgnored("a/b")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("a/x/b")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("a/x/y/b")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("a/xb")).To(BeFalse())
+	})
+
+	It("does not let single stars and question marks match slashes", func() {
+		ignore := NewCfIgnore(`dir/*.txt
+dir/?.md`)
+		Expect(ignore.FileShouldBeIgnored("dir/sub/file.txt")).To(BeFalse())
+		Expect(ignore.FileShouldBeIgnored("dir/a.md")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("dir/ab.md")).To(BeFalse())
+	})
+
+	It("supports bracket expressions", func() {
+		ignore := NewCfIgnore(`file[0-9].txt
+other[!a].txt`)
+		Expect(ignore.FileShouldBeIgnored("file1.txt")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("filea.txt")).To(BeFalse())
+		Expect(ignore.FileShouldBeIgnored("otherb.txt")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("othera.txt")).To(BeFalse())
+	})
+
+	It("skips comments and understands escaped special characters", func() {
+		ignore := NewCfIgnore(`# a comment
+\#hash
+\!bang
+trailing\ `)
+		Expect(ignore.FileShouldBeIgnored("# a comment")).To(BeFalse())
+		Expect(ignore.FileShouldBeIgnored("#hash")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("!bang")).To(BeTrue())
+		Expect(ignore.FileShouldBeIgnored("trailing ")).To(BeTrue())
+	})
+
 	Describe("files named manifest.yml", func() {
 		var (
 			ignore CfIgnore
@@ -18,6 +18,15 @@ type FakeAppFiles struct {
 		result1 []models.AppFileFields
 		result2 error
 	}
+	AppFilesInArchiveDirStub        func(dir string) (appFiles []models.AppFileFields, err error)
+	appFilesInArchiveDirMutex       sync.RWMutex
+	appFilesInArchiveDirArgsForCall []struct {
+		dir string
+	}
+	appFilesInArchiveDirReturns struct {
+		result1 []models.AppFileFields
+		result2 error
+	}
 	CopyFilesStub        func(appFiles []models.AppFileFields, fromDir, toDir string) (err error)
 	copyFilesMutex       sync.RWMutex
 	copyFilesArgsForCall []struct {
@@ -45,6 +54,15 @@ type FakeAppFiles struct {
 	walkAppFilesReturns struct {
 		result1 error
 	}
+	IgnoredFilesInDirStub        func(dir string) (ignoredFiles []string, err error)
+	ignoredFilesInDirMutex       sync.RWMutex
+	ignoredFilesInDirArgsForCall []struct {
+		dir string
+	}
+	ignoredFilesInDirReturns struct {
+		result1 []string
+		result2 error
+	}
 }
 
 func (fake *FakeAppFiles) AppFilesInDir(dir string) (appFiles []models.AppFileFields, err error) {
@@ -79,6 +97,38 @@ func (fake *FakeAppFiles) AppFilesInDirReturns(result1 []models.AppFileFields, r
 	}{result1, result2}
 }
 
+func (fake *FakeAppFiles) AppFilesInArchiveDir(dir string) (appFiles []models.AppFileFields, err error) {
+	fake.appFilesInArchiveDirMutex.Lock()
+	defer fake.appFilesInArchiveDirMutex.Unlock()
+	fake.appFilesInArchiveDirArgsForCall = append(fake.appFilesInArchiveDirArgsForCall, struct {
+		dir string
+	}{dir})
+	if fake.AppFilesInArchiveDirStub != nil {
+		return fake.AppFilesInArchiveDirStub(dir)
+	} else {
+		return fake.appFilesInArchiveDirReturns.result1, fake.appFilesInArchiveDirReturns.result2
+	}
+}
+
+func (fake *FakeAppFiles) AppFilesInArchiveDirCallCount() int {
+	fake.appFilesInArchiveDirMutex.RLock()
+	defer fake.appFilesInArchiveDirMutex.RUnlock()
+	return len(fake.appFilesInArchiveDirArgsForCall)
+}
+
+func (fake *FakeAppFiles) AppFilesInArchiveDirArgsForCall(i int) string {
+	fake.appFilesInArchiveDirMutex.RLock()
+	defer fake.appFilesInArchiveDirMutex.RUnlock()
+	return fake.appFilesInArchiveDirArgsForCall[i].dir
+}
+
+func (fake *FakeAppFiles) AppFilesInArchiveDirReturns(result1 []models.AppFileFields, result2 error) {
+	fake.appFilesInArchiveDirReturns = struct {
+		result1 []models.AppFileFields
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *FakeAppFiles) CopyFiles(appFiles []models.AppFileFields, fromDir string, toDir string) (err error) {
 	fake.copyFilesMutex.Lock()
 	defer fake.copyFilesMutex.Unlock()
@@ -175,4 +225,36 @@ func (fake *FakeAppFiles) WalkAppFilesReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppFiles) IgnoredFilesInDir(dir string) (ignoredFiles []string, err error) {
+	fake.ignoredFilesInDirMutex.Lock()
+	defer fake.ignoredFilesInDirMutex.Unlock()
+	fake.ignoredFilesInDirArgsForCall = append(fake.ignoredFilesInDirArgsForCall, struct {
+		dir string
+	}{dir})
+	if fake.IgnoredFilesInDirStub != nil {
+		return fake.IgnoredFilesInDirStub(dir)
+	} else {
+		return fake.ignoredFilesInDirReturns.result1, fake.ignoredFilesInDirReturns.result2
+	}
+}
+
+func (fake *FakeAppFiles) IgnoredFilesInDirCallCount() int {
+	fake.ignoredFilesInDirMutex.RLock()
+	defer fake.ignoredFilesInDirMutex.RUnlock()
+	return len(fake.ignoredFilesInDirArgsForCall)
+}
+
+func (fake *FakeAppFiles) IgnoredFilesInDirArgsForCall(i int) string {
+	fake.ignoredFilesInDirMutex.RLock()
+	defer fake.ignoredFilesInDirMutex.RUnlock()
+	return fake.ignoredFilesInDirArgsForCall[i].dir
+}
+
+func (fake *FakeAppFiles) IgnoredFilesInDirReturns(result1 []string, result2 error) {
+	fake.ignoredFilesInDirReturns = struct {
+		result1 []string
+		result2 error
+	}{result1, result2}
+}
+
 var _ AppFiles = new(FakeAppFiles)
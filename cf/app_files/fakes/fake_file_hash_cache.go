@@ -0,0 +1,118 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"os"
+	"sync"
+
+	. "github.com/cloudfoundry/cli/cf/app_files"
+)
+
+type FakeFileHashCache struct {
+	Sha1Stub        func(fullPath string, fileInfo os.FileInfo) (string, error)
+	sha1Mutex       sync.RWMutex
+	sha1ArgsForCall []struct {
+		fullPath string
+		fileInfo os.FileInfo
+	}
+	sha1Returns struct {
+		result1 string
+		result2 error
+	}
+	SaveStub        func() error
+	saveMutex       sync.RWMutex
+	saveArgsForCall []struct{}
+	saveReturns     struct {
+		result1 error
+	}
+	ClearStub        func() error
+	clearMutex       sync.RWMutex
+	clearArgsForCall []struct{}
+	clearReturns     struct {
+		result1 error
+	}
+}
+
+func (fake *FakeFileHashCache) Sha1(fullPath string, fileInfo os.FileInfo) (string, error) {
+	fake.sha1Mutex.Lock()
+	fake.sha1ArgsForCall = append(fake.sha1ArgsForCall, struct {
+		fullPath string
+		fileInfo os.FileInfo
+	}{fullPath, fileInfo})
+	fake.sha1Mutex.Unlock()
+	if fake.Sha1Stub != nil {
+		return fake.Sha1Stub(fullPath, fileInfo)
+	} else {
+		return fake.sha1Returns.result1, fake.sha1Returns.result2
+	}
+}
+
+func (fake *FakeFileHashCache) Sha1CallCount() int {
+	fake.sha1Mutex.RLock()
+	defer fake.sha1Mutex.RUnlock()
+	return len(fake.sha1ArgsForCall)
+}
+
+func (fake *FakeFileHashCache) Sha1ArgsForCall(i int) (string, os.FileInfo) {
+	fake.sha1Mutex.RLock()
+	defer fake.sha1Mutex.RUnlock()
+	return fake.sha1ArgsForCall[i].fullPath, fake.sha1ArgsForCall[i].fileInfo
+}
+
+func (fake *FakeFileHashCache) Sha1Returns(result1 string, result2 error) {
+	fake.Sha1Stub = nil
+	fake.sha1Returns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *FakeFileHashCache) Save() error {
+	fake.saveMutex.Lock()
+	fake.saveArgsForCall = append(fake.saveArgsForCall, struct{}{})
+	fake.saveMutex.Unlock()
+	if fake.SaveStub != nil {
+		return fake.SaveStub()
+	} else {
+		return fake.saveReturns.result1
+	}
+}
+
+func (fake *FakeFileHashCache) SaveCallCount() int {
+	fake.saveMutex.RLock()
+	defer fake.saveMutex.RUnlock()
+	return len(fake.saveArgsForCall)
+}
+
+func (fake *FakeFileHashCache) SaveReturns(result1 error) {
+	fake.SaveStub = nil
+	fake.saveReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *FakeFileHashCache) Clear() error {
+	fake.clearMutex.Lock()
+	fake.clearArgsForCall = append(fake.clearArgsForCall, struct{}{})
+	fake.clearMutex.Unlock()
+	if fake.ClearStub != nil {
+		return fake.ClearStub()
+	} else {
+		return fake.clearReturns.result1
+	}
+}
+
+func (fake *FakeFileHashCache) ClearCallCount() int {
+	fake.clearMutex.RLock()
+	defer fake.clearMutex.RUnlock()
+	return len(fake.clearArgsForCall)
+}
+
+func (fake *FakeFileHashCache) ClearReturns(result1 error) {
+	fake.ClearStub = nil
+	fake.clearReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ FileHashCache = new(FakeFileHashCache)
@@ -4,20 +4,30 @@ package fakes
 import (
 	. "github.com/cloudfoundry/cli/cf/app_files"
 
+	"io"
 	"os"
 	"sync"
 )
 
 type FakeZipper struct {
-	ZipStub        func(dirToZip string, targetFile *os.File) (err error)
+	ZipStub        func(dirToZip string, target io.Writer) (err error)
 	zipMutex       sync.RWMutex
 	zipArgsForCall []struct {
-		dirToZip   string
-		targetFile *os.File
+		dirToZip string
+		target   io.Writer
 	}
 	zipReturns struct {
 		result1 error
 	}
+	ZipReproduciblyStub        func(dirToZip string, target io.Writer) (err error)
+	zipReproduciblyMutex       sync.RWMutex
+	zipReproduciblyArgsForCall []struct {
+		dirToZip string
+		target   io.Writer
+	}
+	zipReproduciblyReturns struct {
+		result1 error
+	}
 	IsZipFileStub        func(path string) bool
 	isZipFileMutex       sync.RWMutex
 	isZipFileArgsForCall []struct {
@@ -35,6 +45,23 @@ type FakeZipper struct {
 	unzipReturns struct {
 		result1 error
 	}
+	IsTarFileStub        func(path string) bool
+	isTarFileMutex       sync.RWMutex
+	isTarFileArgsForCall []struct {
+		path string
+	}
+	isTarFileReturns struct {
+		result1 bool
+	}
+	UntarStub        func(appDir string, destDir string) (err error)
+	untarMutex       sync.RWMutex
+	untarArgsForCall []struct {
+		appDir  string
+		destDir string
+	}
+	untarReturns struct {
+		result1 error
+	}
 	GetZipSizeStub        func(zipFile *os.File) (int64, error)
 	getZipSizeMutex       sync.RWMutex
 	getZipSizeArgsForCall []struct {
@@ -46,15 +73,15 @@ type FakeZipper struct {
 	}
 }
 
-func (fake *FakeZipper) Zip(dirToZip string, targetFile *os.File) (err error) {
+func (fake *FakeZipper) Zip(dirToZip string, target io.Writer) (err error) {
 	fake.zipMutex.Lock()
 	defer fake.zipMutex.Unlock()
 	fake.zipArgsForCall = append(fake.zipArgsForCall, struct {
-		dirToZip   string
-		targetFile *os.File
-	}{dirToZip, targetFile})
+		dirToZip string
+		target   io.Writer
+	}{dirToZip, target})
 	if fake.ZipStub != nil {
-		return fake.ZipStub(dirToZip, targetFile)
+		return fake.ZipStub(dirToZip, target)
 	} else {
 		return fake.zipReturns.result1
 	}
@@ -66,10 +93,10 @@ func (fake *FakeZipper) ZipCallCount() int {
 	return len(fake.zipArgsForCall)
 }
 
-func (fake *FakeZipper) ZipArgsForCall(i int) (string, *os.File) {
+func (fake *FakeZipper) ZipArgsForCall(i int) (string, io.Writer) {
 	fake.zipMutex.RLock()
 	defer fake.zipMutex.RUnlock()
-	return fake.zipArgsForCall[i].dirToZip, fake.zipArgsForCall[i].targetFile
+	return fake.zipArgsForCall[i].dirToZip, fake.zipArgsForCall[i].target
 }
 
 func (fake *FakeZipper) ZipReturns(result1 error) {
@@ -78,6 +105,38 @@ func (fake *FakeZipper) ZipReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeZipper) ZipReproducibly(dirToZip string, target io.Writer) (err error) {
+	fake.zipReproduciblyMutex.Lock()
+	defer fake.zipReproduciblyMutex.Unlock()
+	fake.zipReproduciblyArgsForCall = append(fake.zipReproduciblyArgsForCall, struct {
+		dirToZip string
+		target   io.Writer
+	}{dirToZip, target})
+	if fake.ZipReproduciblyStub != nil {
+		return fake.ZipReproduciblyStub(dirToZip, target)
+	} else {
+		return fake.zipReproduciblyReturns.result1
+	}
+}
+
+func (fake *FakeZipper) ZipReproduciblyCallCount() int {
+	fake.zipReproduciblyMutex.RLock()
+	defer fake.zipReproduciblyMutex.RUnlock()
+	return len(fake.zipReproduciblyArgsForCall)
+}
+
+func (fake *FakeZipper) ZipReproduciblyArgsForCall(i int) (string, io.Writer) {
+	fake.zipReproduciblyMutex.RLock()
+	defer fake.zipReproduciblyMutex.RUnlock()
+	return fake.zipReproduciblyArgsForCall[i].dirToZip, fake.zipReproduciblyArgsForCall[i].target
+}
+
+func (fake *FakeZipper) ZipReproduciblyReturns(result1 error) {
+	fake.zipReproduciblyReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeZipper) IsZipFile(path string) bool {
 	fake.isZipFileMutex.Lock()
 	defer fake.isZipFileMutex.Unlock()
@@ -141,6 +200,69 @@ func (fake *FakeZipper) UnzipReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeZipper) IsTarFile(path string) bool {
+	fake.isTarFileMutex.Lock()
+	defer fake.isTarFileMutex.Unlock()
+	fake.isTarFileArgsForCall = append(fake.isTarFileArgsForCall, struct {
+		path string
+	}{path})
+	if fake.IsTarFileStub != nil {
+		return fake.IsTarFileStub(path)
+	} else {
+		return fake.isTarFileReturns.result1
+	}
+}
+
+func (fake *FakeZipper) IsTarFileCallCount() int {
+	fake.isTarFileMutex.RLock()
+	defer fake.isTarFileMutex.RUnlock()
+	return len(fake.isTarFileArgsForCall)
+}
+
+func (fake *FakeZipper) IsTarFileArgsForCall(i int) string {
+	fake.isTarFileMutex.RLock()
+	defer fake.isTarFileMutex.RUnlock()
+	return fake.isTarFileArgsForCall[i].path
+}
+
+func (fake *FakeZipper) IsTarFileReturns(result1 bool) {
+	fake.isTarFileReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *FakeZipper) Untar(appDir string, destDir string) (err error) {
+	fake.untarMutex.Lock()
+	defer fake.untarMutex.Unlock()
+	fake.untarArgsForCall = append(fake.untarArgsForCall, struct {
+		appDir  string
+		destDir string
+	}{appDir, destDir})
+	if fake.UntarStub != nil {
+		return fake.UntarStub(appDir, destDir)
+	} else {
+		return fake.untarReturns.result1
+	}
+}
+
+func (fake *FakeZipper) UntarCallCount() int {
+	fake.untarMutex.RLock()
+	defer fake.untarMutex.RUnlock()
+	return len(fake.untarArgsForCall)
+}
+
+func (fake *FakeZipper) UntarArgsForCall(i int) (string, string) {
+	fake.untarMutex.RLock()
+	defer fake.untarMutex.RUnlock()
+	return fake.untarArgsForCall[i].appDir, fake.untarArgsForCall[i].destDir
+}
+
+func (fake *FakeZipper) UntarReturns(result1 error) {
+	fake.untarReturns = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *FakeZipper) GetZipSize(zipFile *os.File) (int64, error) {
 	fake.getZipSizeMutex.Lock()
 	defer fake.getZipSizeMutex.Unlock()
@@ -0,0 +1,168 @@
+package app_files
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//go:generate counterfeiter -o fakes/fake_file_hash_cache.go . FileHashCache
+type FileHashCache interface {
+	Sha1(fullPath string, fileInfo os.FileInfo) (string, error)
+	Save() error
+	Clear() error
+}
+
+type fileHashEntry struct {
+	Size    int64  `json:"size"`
+	ModTime int64  `json:"mtime"`
+	Sha1    string `json:"sha1"`
+}
+
+// diskFileHashCache remembers the SHA1 of files by their path, size and
+// modification time, so that unchanged files are not read again on every push.
+type diskFileHashCache struct {
+	path    string
+	mutex   *sync.Mutex
+	loaded  bool
+	changed bool
+	entries map[string]fileHashEntry
+}
+
+func NewFileHashCache(path string) FileHashCache {
+	return &diskFileHashCache{
+		path:  path,
+		mutex: &sync.Mutex{},
+	}
+}
+
+func (cache *diskFileHashCache) Sha1(fullPath string, fileInfo os.FileInfo) (string, error) {
+	cache.mutex.Lock()
+	cache.load()
+	entry, found := cache.entries[fullPath]
+	cache.mutex.Unlock()
+
+	if found && entry.Size == fileInfo.Size() && entry.ModTime == fileInfo.ModTime().UnixNano() {
+		return entry.Sha1, nil
+	}
+
+	sha, err := fileSha1(fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	cache.mutex.Lock()
+	cache.entries[fullPath] = fileHashEntry{
+		Size:    fileInfo.Size(),
+		ModTime: fileInfo.ModTime().UnixNano(),
+		Sha1:    sha,
+	}
+	cache.changed = true
+	cache.mutex.Unlock()
+
+	return sha, nil
+}
+
+// Save writes the cache to disk, dropping the entries of files that no
+// longer exist.
+func (cache *diskFileHashCache) Save() error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if !cache.changed {
+		return nil
+	}
+
+	for fullPath := range cache.entries {
+		if _, err := os.Lstat(fullPath); err != nil {
+			delete(cache.entries, fullPath)
+		}
+	}
+
+	content, err := json.Marshal(cache.entries)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(cache.path), 0700)
+	if err != nil {
+		return err
+	}
+
+	tempFile, err := ioutil.TempFile(filepath.Dir(cache.path), filepath.Base(cache.path))
+	if err != nil {
+		return err
+	}
+
+	_, err = tempFile.Write(content)
+	tempFile.Close()
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+
+	err = os.Rename(tempFile.Name(), cache.path)
+	if err != nil {
+		os.Remove(tempFile.Name())
+		return err
+	}
+
+	cache.changed = false
+	return nil
+}
+
+func (cache *diskFileHashCache) Clear() error {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.entries = map[string]fileHashEntry{}
+	cache.loaded = true
+	cache.changed = false
+
+	err := os.Remove(cache.path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// load reads the cache from disk the first time it is needed. A missing or
+// unreadable cache file is treated as an empty cache.
+func (cache *diskFileHashCache) load() {
+	if cache.loaded {
+		return
+	}
+	cache.loaded = true
+	cache.entries = map[string]fileHashEntry{}
+
+	content, err := ioutil.ReadFile(cache.path)
+	if err != nil {
+		return
+	}
+
+	entries := map[string]fileHashEntry{}
+	if json.Unmarshal(content, &entries) == nil {
+		cache.entries = entries
+	}
+}
+
+func fileSha1(fullPath string) (string, error) {
+	file, err := os.Open(fullPath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha1.New()
+	_, err = io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%x", hash.Sum(nil)), nil
+}
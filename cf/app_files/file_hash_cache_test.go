@@ -0,0 +1,122 @@
+package app_files_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cloudfoundry/cli/cf/app_files"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("FileHashCache", func() {
+	var (
+		tempDir   string
+		cachePath string
+		filePath  string
+		cache     app_files.FileHashCache
+	)
+
+	statFile := func() os.FileInfo {
+		fileInfo, err := os.Lstat(filePath)
+		Expect(err).NotTo(HaveOccurred())
+		return fileInfo
+	}
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "file-hash-cache")
+		Expect(err).NotTo(HaveOccurred())
+
+		cachePath = filepath.Join(tempDir, ".cf", "file_hashes.json")
+		filePath = filepath.Join(tempDir, "app.txt")
+		err = ioutil.WriteFile(filePath, []byte("hello"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+
+		cache = app_files.NewFileHashCache(cachePath)
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	It("computes the sha1 of files it has not seen", func() {
+		sha, err := cache.Sha1(filePath, statFile())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sha).To(Equal("aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"))
+	})
+
+	It("reuses the saved sha1 of files whose size and modification time did not change", func() {
+		fileInfo := statFile()
+		_, err := cache.Sha1(filePath, fileInfo)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cache.Save()).To(Succeed())
+
+		err = ioutil.WriteFile(filePath, []byte("HELLO"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		err = os.Chtimes(filePath, fileInfo.ModTime(), fileInfo.ModTime())
+		Expect(err).NotTo(HaveOccurred())
+
+		sha, err := app_files.NewFileHashCache(cachePath).Sha1(filePath, statFile())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sha).To(Equal("aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"))
+	})
+
+	It("hashes files again when their modification time changed", func() {
+		_, err := cache.Sha1(filePath, statFile())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cache.Save()).To(Succeed())
+
+		err = ioutil.WriteFile(filePath, []byte("HELLO"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+		later := time.Now().Add(time.Hour)
+		err = os.Chtimes(filePath, later, later)
+		Expect(err).NotTo(HaveOccurred())
+
+		sha, err := app_files.NewFileHashCache(cachePath).Sha1(filePath, statFile())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sha).To(Equal("c65f99f8c5376adadddc46d5cbcf5762f9e55eb7"))
+	})
+
+	It("ignores a cache file it cannot parse", func() {
+		err := os.MkdirAll(filepath.Dir(cachePath), 0700)
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(cachePath, []byte("{not json"), 0600)
+		Expect(err).NotTo(HaveOccurred())
+
+		sha, err := cache.Sha1(filePath, statFile())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sha).To(Equal("aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"))
+	})
+
+	It("drops the entries of deleted files when saving", func() {
+		_, err := cache.Sha1(filePath, statFile())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.Remove(filePath)).To(Succeed())
+		Expect(cache.Save()).To(Succeed())
+
+		content, err := ioutil.ReadFile(cachePath)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(content)).NotTo(ContainSubstring("app.txt"))
+	})
+
+	Describe("Clear", func() {
+		It("removes the cache file", func() {
+			_, err := cache.Sha1(filePath, statFile())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(cache.Save()).To(Succeed())
+
+			Expect(cache.Clear()).To(Succeed())
+
+			_, err = os.Stat(cachePath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("succeeds when there is no cache file", func() {
+			Expect(cache.Clear()).To(Succeed())
+		})
+	})
+})
@@ -1,28 +1,52 @@
 package app_files
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bufio"
 	"bytes"
+	"compress/gzip"
 	"io"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/cloudfoundry/cli/cf/errors"
+	. "github.com/cloudfoundry/cli/cf/i18n"
 	"github.com/cloudfoundry/gofileutils/fileutils"
 )
 
 type Zipper interface {
-	Zip(dirToZip string, targetFile *os.File) (err error)
+	Zip(dirToZip string, target io.Writer) (err error)
+	ZipReproducibly(dirToZip string, target io.Writer) (err error)
 	IsZipFile(path string) bool
 	Unzip(appDir string, destDir string) (err error)
+	IsTarFile(path string) bool
+	Untar(appDir string, destDir string) (err error)
 	GetZipSize(zipFile *os.File) (int64, error)
 }
 
 type ApplicationZipper struct{}
 
-func (zipper ApplicationZipper) Zip(dirOrZipFilePath string, targetFile *os.File) error {
+// reproducibleModTime is the modification time of every entry of a
+// reproducible zip, the earliest date a zip file can hold.
+var reproducibleModTime = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func (zipper ApplicationZipper) Zip(dirOrZipFilePath string, target io.Writer) error {
+	return zipper.zip(dirOrZipFilePath, target, false)
+}
+
+// ZipReproducibly zips a directory so that the same tree of files always
+// gives the same bytes: the entries are sorted by name, and their times and
+// permissions are normalized, keeping only whether a file is executable.
+func (zipper ApplicationZipper) ZipReproducibly(dirOrZipFilePath string, target io.Writer) error {
+	return zipper.zip(dirOrZipFilePath, target, true)
+}
+
+func (zipper ApplicationZipper) zip(dirOrZipFilePath string, target io.Writer, reproducible bool) error {
 	if zipper.IsZipFile(dirOrZipFilePath) {
 		zipFile, err := os.Open(dirOrZipFilePath)
 		if err != nil {
@@ -30,18 +54,20 @@ func (zipper ApplicationZipper) Zip(dirOrZipFilePath string, targetFile *os.File
 		}
 		defer zipFile.Close()
 
-		_, err = io.Copy(targetFile, zipFile)
+		_, err = io.Copy(target, zipFile)
 		if err != nil {
 			return err
 		}
 	} else {
-		err := writeZipFile(dirOrZipFilePath, targetFile)
+		err := writeZipFile(dirOrZipFilePath, target, reproducible)
 		if err != nil {
 			return err
 		}
 	}
 
-	targetFile.Seek(0, os.SEEK_SET)
+	if seeker, ok := target.(io.Seeker); ok {
+		seeker.Seek(0, os.SEEK_SET)
+	}
 
 	return nil
 }
@@ -118,6 +144,119 @@ func (zipper ApplicationZipper) Unzip(name string, destDir string) error {
 	return nil
 }
 
+// IsTarFile tells whether the file is a tar archive, either plain or
+// compressed with gzip.
+func (zipper ApplicationZipper) IsTarFile(name string) bool {
+	f, err := os.Open(name)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil || fi.IsDir() {
+		return false
+	}
+
+	reader, err := newTarReader(f)
+	if err != nil {
+		return false
+	}
+
+	_, err = reader.Next()
+	return err == nil
+}
+
+// Untar extracts the directories and regular files of a tar archive. Links
+// and other special files are skipped, as they are when pushing a directory.
+func (zipper ApplicationZipper) Untar(name string, destDir string) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader, err := newTarReader(f)
+	if err != nil {
+		return err
+	}
+
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		destPath, err := archiveEntryPath(destDir, header.Name)
+		if err != nil {
+			return err
+		}
+
+		fileInfo := header.FileInfo()
+		switch {
+		case fileInfo.IsDir():
+			err = os.MkdirAll(destPath, os.ModeDir|os.ModePerm)
+		case fileInfo.Mode().IsRegular():
+			err = extractTarFile(reader, destPath, fileInfo.Mode())
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func newTarReader(f *os.File) (*tar.Reader, error) {
+	bufferedFile := bufio.NewReader(f)
+
+	magic, err := bufferedFile.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gzipReader, err := gzip.NewReader(bufferedFile)
+		if err != nil {
+			return nil, err
+		}
+		return tar.NewReader(gzipReader), nil
+	}
+
+	return tar.NewReader(bufferedFile), nil
+}
+
+// archiveEntryPath gives the path an archive entry is extracted to, refusing
+// entries that would end up outside of destDir.
+func archiveEntryPath(destDir string, name string) (string, error) {
+	destDir = filepath.Clean(destDir)
+	destPath := filepath.Join(destDir, filepath.FromSlash(name))
+
+	if destPath != destDir && !strings.HasPrefix(destPath, destDir+string(os.PathSeparator)) {
+		return "", errors.New(T("Invalid archive entry {{.Name}}: the path leaves the app directory",
+			map[string]interface{}{"Name": name}))
+	}
+
+	return destPath, nil
+}
+
+func extractTarFile(src io.Reader, destFilePath string, mode os.FileMode) error {
+	err := os.MkdirAll(filepath.Dir(destFilePath), os.ModeDir|os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	destFile, err := os.Create(destFilePath)
+	if err != nil {
+		return err
+	}
+	defer destFile.Close()
+
+	_, err = io.Copy(destFile, src)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(destFilePath, mode)
+}
+
 func (zipper ApplicationZipper) GetZipSize(zipFile *os.File) (int64, error) {
 	zipFileSize := int64(0)
 
@@ -131,7 +270,12 @@ func (zipper ApplicationZipper) GetZipSize(zipFile *os.File) (int64, error) {
 	return zipFileSize, nil
 }
 
-func writeZipFile(dir string, targetFile *os.File) error {
+type zipEntry struct {
+	fullPath string
+	header   *zip.FileHeader
+}
+
+func writeZipFile(dir string, target io.Writer, reproducible bool) error {
 	isEmpty, err := fileutils.IsDirEmpty(dir)
 	if err != nil {
 		return err
@@ -141,53 +285,109 @@ func writeZipFile(dir string, targetFile *os.File) error {
 		return errors.NewEmptyDirError(dir)
 	}
 
-	writer := zip.NewWriter(targetFile)
+	writer := zip.NewWriter(target)
 	defer writer.Close()
 
+	entries := []zipEntry{}
 	appfiles := ApplicationFiles{}
-	return appfiles.WalkAppFiles(dir, func(fileName string, fullPath string) error {
+	err = appfiles.WalkAppFiles(dir, func(fileName string, fullPath string) error {
 		fileInfo, err := os.Stat(fullPath)
 		if err != nil {
 			return err
 		}
 
-		header, err := zip.FileInfoHeader(fileInfo)
+		header, err := zipFileHeader(fileInfo, reproducible)
 		if err != nil {
 			return err
 		}
 
-		if runtime.GOOS == "windows" {
-			header.SetMode(header.Mode() | 0700)
-		}
-
 		header.Name = filepath.ToSlash(fileName)
 
 		if fileInfo.IsDir() {
 			header.Name += "/"
 		}
 
-		zipFilePart, err := writer.CreateHeader(header)
-		if err != nil {
-			return err
-		}
-
-		if fileInfo.IsDir() {
+		if reproducible {
+			entries = append(entries, zipEntry{fullPath: fullPath, header: header})
 			return nil
 		}
 
-		file, err := os.Open(fullPath)
+		return writeZipEntry(writer, zipEntry{fullPath: fullPath, header: header})
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Sort(zipEntriesByName(entries))
+	for _, entry := range entries {
+		err = writeZipEntry(writer, entry)
 		if err != nil {
 			return err
 		}
-		defer file.Close()
+	}
 
-		_, err = io.Copy(zipFilePart, file)
+	return nil
+}
+
+func zipFileHeader(fileInfo os.FileInfo, reproducible bool) (*zip.FileHeader, error) {
+	if !reproducible {
+		header, err := zip.FileInfoHeader(fileInfo)
 		if err != nil {
-			return err
+			return nil, err
+		}
+
+		if runtime.GOOS == "windows" {
+			header.SetMode(header.Mode() | 0700)
 		}
+		return header, nil
+	}
+
+	header := &zip.FileHeader{Method: zip.Deflate}
+	header.SetModTime(reproducibleModTime)
+
+	switch {
+	case fileInfo.IsDir():
+		header.Method = zip.Store
+		header.SetMode(os.ModeDir | 0755)
+	case fileInfo.Mode()&0111 != 0:
+		header.SetMode(0755)
+	default:
+		header.SetMode(0644)
+	}
+
+	return header, nil
+}
+
+func writeZipEntry(writer *zip.Writer, entry zipEntry) error {
+	zipFilePart, err := writer.CreateHeader(entry.header)
+	if err != nil {
+		return err
+	}
 
+	if strings.HasSuffix(entry.header.Name, "/") {
 		return nil
-	})
+	}
+
+	file, err := os.Open(entry.fullPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(zipFilePart, file)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+type zipEntriesByName []zipEntry
+
+func (entries zipEntriesByName) Len() int      { return len(entries) }
+func (entries zipEntriesByName) Swap(i, j int) { entries[i], entries[j] = entries[j], entries[i] }
+func (entries zipEntriesByName) Less(i, j int) bool {
+	return entries[i].header.Name < entries[j].header.Name
 }
 
 func (zipper ApplicationZipper) zipFileHeaderLocation(name string) (int64, error) {
@@ -1,6 +1,7 @@
 package app_files_test
 
 import (
+	"archive/tar"
 	"archive/zip"
 	"bytes"
 	"fmt"
@@ -11,6 +12,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	. "github.com/cloudfoundry/cli/cf/app_files"
 	"github.com/cloudfoundry/gofileutils/fileutils"
@@ -198,6 +200,75 @@ var _ = Describe("Zipper", func() {
 			Expect(fmt.Sprintf("%o", reader.File[5].FileInfo().Mode())).To(Equal("766"))
 		})
 
+		Describe("reproducibly", func() {
+			var (
+				dir         string
+				barPath     string
+				barFileInfo os.FileInfo
+			)
+
+			BeforeEach(func() {
+				workingDir, err := os.Getwd()
+				Expect(err).NotTo(HaveOccurred())
+				dir = filepath.Join(workingDir, "../../fixtures/zip/")
+
+				barPath = filepath.Join(dir, "subDir/bar.txt")
+				barFileInfo, err = os.Stat(barPath)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.Chmod(barPath, barFileInfo.Mode())
+				os.Chtimes(barPath, barFileInfo.ModTime(), barFileInfo.ModTime())
+			})
+
+			zipBytes := func() []byte {
+				buffer := &bytes.Buffer{}
+				err := zipper.ZipReproducibly(dir, buffer)
+				Expect(err).NotTo(HaveOccurred())
+				return buffer.Bytes()
+			}
+
+			It("creates the same bytes when the times and permissions of the files change", func() {
+				if runtime.GOOS == "windows" {
+					Skip("This test does not run on Windows")
+				}
+
+				firstZip := zipBytes()
+
+				err := os.Chtimes(barPath, time.Now(), time.Now().Add(time.Hour))
+				Expect(err).NotTo(HaveOccurred())
+				err = os.Chmod(barPath, 0600)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(zipBytes()).To(Equal(firstZip))
+			})
+
+			It("sorts the entries and normalizes their times and modes", func() {
+				if runtime.GOOS == "windows" {
+					Skip("This test does not run on Windows")
+				}
+
+				err := os.Chmod(barPath, 0750)
+				Expect(err).NotTo(HaveOccurred())
+
+				contents := zipBytes()
+				reader, err := zip.NewReader(bytes.NewReader(contents), int64(len(contents)))
+				Expect(err).NotTo(HaveOccurred())
+
+				filenames := []string{}
+				for _, file := range reader.File {
+					filenames = append(filenames, file.Name)
+					Expect(file.ModTime().Year()).To(Equal(1980))
+				}
+				Expect(filenames).To(Equal(filesInZip))
+
+				Expect(reader.File[0].FileInfo().Mode()).To(Equal(os.FileMode(0644)))
+				Expect(reader.File[1].FileInfo().Mode()).To(Equal(os.ModeDir | 0755))
+				Expect(reader.File[5].FileInfo().Mode()).To(Equal(os.FileMode(0755)))
+			})
+		})
+
 		It("is a no-op for a zipfile", func() {
 			dir, err := os.Getwd()
 			Expect(err).NotTo(HaveOccurred())
@@ -286,6 +357,25 @@ var _ = Describe("Zipper", func() {
 			})
 		})
 
+		Context("when given a jar or war file", func() {
+			It("returns true", func() {
+				workingDir, err := os.Getwd()
+				Expect(err).NotTo(HaveOccurred())
+
+				outDir, err = ioutil.TempDir("", "zipper-jar")
+				Expect(err).NotTo(HaveOccurred())
+
+				contents, err := ioutil.ReadFile(filepath.Join(workingDir, "../../fixtures/applications/example-app.zip"))
+				Expect(err).NotTo(HaveOccurred())
+
+				for _, name := range []string{"app.jar", "app.war"} {
+					err = ioutil.WriteFile(filepath.Join(outDir, name), contents, 0644)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(zipper.IsZipFile(filepath.Join(outDir, name))).To(BeTrue())
+				}
+			})
+		})
+
 		Context("when given a file that is not a zip", func() {
 			var fileName string
 
@@ -326,6 +416,78 @@ var _ = Describe("Zipper", func() {
 		})
 	})
 
+	Describe("tar files", func() {
+		var (
+			zipper      ApplicationZipper
+			fixturesDir string
+			outDir      string
+		)
+
+		BeforeEach(func() {
+			fixturesDir = filepath.Join("..", "..", "fixtures", "applications")
+
+			var err error
+			outDir, err = ioutil.TempDir("", "zipper-untar-out")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(outDir)
+		})
+
+		It("recognizes plain and gzipped tar files", func() {
+			Expect(zipper.IsTarFile(filepath.Join(fixturesDir, "example-app.tar"))).To(BeTrue())
+			Expect(zipper.IsTarFile(filepath.Join(fixturesDir, "example-app.tgz"))).To(BeTrue())
+		})
+
+		It("does not recognize zip files, other files and directories", func() {
+			Expect(zipper.IsTarFile(filepath.Join(fixturesDir, "example-app.zip"))).To(BeFalse())
+			Expect(zipper.IsTarFile(filepath.Join(fixturesDir, "example-app", "app.rb"))).To(BeFalse())
+			Expect(zipper.IsTarFile(filepath.Join(fixturesDir, "example-app"))).To(BeFalse())
+		})
+
+		It("extracts the files with their modes", func() {
+			if runtime.GOOS == "windows" {
+				Skip("This test does not run on Windows")
+			}
+
+			err := zipper.Untar(filepath.Join(fixturesDir, "example-app.tgz"), outDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := ioutil.ReadFile(filepath.Join(outDir, "example-app", "config.ru"))
+			Expect(err).NotTo(HaveOccurred())
+			expectedContents, err := ioutil.ReadFile(filepath.Join(fixturesDir, "example-app", "config.ru"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(Equal(expectedContents))
+
+			fileInfo, err := os.Stat(filepath.Join(outDir, "example-app", "app.rb"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fileInfo.Mode() & 0100).NotTo(BeZero())
+		})
+
+		It("refuses entries leaving the destination directory", func() {
+			tarPath := filepath.Join(outDir, "evil.tar")
+			tarFile, err := os.Create(tarPath)
+			Expect(err).NotTo(HaveOccurred())
+
+			writer := tar.NewWriter(tarFile)
+			err = writer.WriteHeader(&tar.Header{Name: "../evil.txt", Mode: 0644, Size: 4, Typeflag: tar.TypeReg})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = writer.Write([]byte("evil"))
+			Expect(err).NotTo(HaveOccurred())
+			writer.Close()
+			tarFile.Close()
+
+			extractDir := filepath.Join(outDir, "app")
+			err = zipper.Untar(tarPath, extractDir)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("../evil.txt"))
+
+			_, err = os.Stat(filepath.Join(outDir, "evil.txt"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+
 	Describe(".Unzip", func() {
 		var (
 			inDir, outDir string
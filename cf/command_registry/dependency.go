@@ -43,8 +43,10 @@ type Dependency struct {
 	WordGenerator      generator.WordGenerator
 	AppZipper          app_files.Zipper
 	AppFiles           app_files.AppFiles
+	FileHashCache      app_files.FileHashCache
 	PushActor          actors.PushActor
 	ChecksumUtil       utils.Sha1Checksum
+	ShellRunner        utils.ShellRunner
 	WilecardDependency interface{} //use for injecting fakes
 }
 
@@ -130,11 +132,13 @@ func NewDependency() Dependency {
 	deps.WordGenerator = generator.NewWordGenerator()
 
 	deps.AppZipper = app_files.ApplicationZipper{}
-	deps.AppFiles = app_files.ApplicationFiles{}
+	deps.FileHashCache = app_files.NewFileHashCache(config_helpers.FileHashCachePath())
+	deps.AppFiles = app_files.ApplicationFiles{HashCache: deps.FileHashCache}
 
 	deps.PushActor = actors.NewPushActor(deps.RepoLocator.GetApplicationBitsRepository(), deps.AppZipper, deps.AppFiles)
 
 	deps.ChecksumUtil = utils.NewSha1Checksum("")
+	deps.ShellRunner = utils.NewShellRunner()
 
 	return deps
 }
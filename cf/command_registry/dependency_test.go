@@ -33,5 +33,6 @@ var _ = Describe("Dependency", func() {
 		Ω(dependency.AppFiles).ToNot(BeNil())
 		Ω(dependency.PushActor).ToNot(BeNil())
 		Ω(dependency.ChecksumUtil).ToNot(BeNil())
+		Ω(dependency.ShellRunner).ToNot(BeNil())
 	})
 })
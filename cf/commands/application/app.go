@@ -2,7 +2,11 @@ package application
 
 import (
 	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
 	"strings"
+	"time"
 
 	. "github.com/cloudfoundry/cli/cf/i18n"
 	"github.com/cloudfoundry/cli/flags"
@@ -33,8 +37,12 @@ type ShowApp struct {
 	appReq           requirements.ApplicationRequirement
 	pluginAppModel   *plugin_models.GetAppModel
 	pluginCall       bool
+	watchInterval    time.Duration
+	tty              bool
 }
 
+const DefaultWatchInterval = 2 * time.Second
+
 func init() {
 	command_registry.Register(&ShowApp{})
 }
@@ -42,21 +50,32 @@ func init() {
 func (cmd *ShowApp) MetaData() command_registry.CommandMetadata {
 	fs := make(map[string]flags.FlagSet)
 	fs["guid"] = &cliFlags.BoolFlag{Name: "guid", Usage: T("Retrieve and display the given app's guid.  All other health and status output for the app is suppressed.")}
+	fs["watch"] = &cliFlags.BoolFlag{Name: "watch", Usage: T("Refresh the instances with live CPU, memory and disk usage every INTERVAL (e.g. 5s, default 2s) until Ctrl-C is pressed")}
 
 	return command_registry.CommandMetadata{
 		Name:        "app",
 		Description: T("Display health and status for app"),
-		Usage:       T("CF_NAME app APP_NAME"),
+		Usage:       T("CF_NAME app APP_NAME [--watch [INTERVAL]]"),
 		Flags:       fs,
 	}
 }
 
 func (cmd *ShowApp) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) (reqs []requirements.Requirement, err error) {
-	if len(fc.Args()) != 1 {
+	args := fc.Args()
+
+	cmd.watchInterval = 0
+	if fc.Bool("watch") {
+		cmd.watchInterval = DefaultWatchInterval
+		if len(args) == 2 {
+			cmd.watchInterval, args = cmd.watchIntervalFromArgs(args)
+		}
+	}
+
+	if len(args) != 1 {
 		cmd.ui.Failed(T("Incorrect Usage. Requires an argument\n\n") + command_registry.Commands.CommandUsage("app"))
 	}
 
-	cmd.appReq = requirementsFactory.NewApplicationRequirement(fc.Args()[0])
+	cmd.appReq = requirementsFactory.NewApplicationRequirement(args[0])
 
 	reqs = []requirements.Requirement{
 		requirementsFactory.NewLoginRequirement(),
@@ -74,21 +93,159 @@ func (cmd *ShowApp) SetDependency(deps command_registry.Dependency, pluginCall b
 
 	cmd.pluginAppModel = deps.PluginModels.Application
 	cmd.pluginCall = pluginCall
+	cmd.tty = terminal.IsTerminal()
 
 	return cmd
 }
 
+// SetTTY tells whether the output is a terminal, in which --watch redraws the
+// instances in place instead of printing them again below.
+func (cmd *ShowApp) SetTTY(tty bool) {
+	cmd.tty = tty
+}
+
 func (cmd *ShowApp) Execute(c flags.FlagContext) {
 	app := cmd.appReq.GetApplication()
 
 	if c.Bool("guid") {
 		cmd.ui.Say(app.Guid)
+	} else if cmd.watchInterval > 0 {
+		cmd.watchApp(app, cmd.config.OrganizationFields().Name, cmd.config.SpaceFields().Name)
 	} else {
 		cmd.ShowApp(app, cmd.config.OrganizationFields().Name, cmd.config.SpaceFields().Name)
 	}
 }
 
+// watchIntervalFromArgs takes the optional INTERVAL of --watch out of the
+// arguments. It may be given before or after the app name, either as a
+// duration or as a number of seconds.
+func (cmd *ShowApp) watchIntervalFromArgs(args []string) (time.Duration, []string) {
+	if interval, ok := parseWatchInterval(args[1]); ok {
+		return interval, args[:1]
+	}
+	if interval, ok := parseWatchInterval(args[0]); ok {
+		return interval, args[1:]
+	}
+
+	cmd.ui.Failed(T("Incorrect Usage. Invalid watch interval '{{.Interval}}'\n\n",
+		map[string]interface{}{"Interval": args[1]}) + command_registry.Commands.CommandUsage("app"))
+	return 0, args
+}
+
+func parseWatchInterval(value string) (time.Duration, bool) {
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return 0, false
+		}
+		interval = time.Duration(seconds) * time.Second
+	}
+
+	return interval, interval > 0
+}
+
 func (cmd *ShowApp) ShowApp(app models.Application, orgName, spaceName string) {
+	instances, appIsStopped, ok := cmd.showAppDetails(app, orgName, spaceName)
+	if !ok {
+		return
+	}
+
+	if appIsStopped {
+		cmd.ui.Say(T("There are no running instances of this app."))
+		return
+	}
+
+	cmd.printInstances(instances, nil)
+}
+
+// watchApp shows the app, then redraws its instances every watch interval
+// until the user presses Ctrl-C or the app is deleted. On a terminal the table is redrawn in place,
+// otherwise every refresh is printed after the previous one.
+func (cmd *ShowApp) watchApp(app models.Application, orgName, spaceName string) {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	instances, appIsStopped, ok := cmd.showAppDetails(app, orgName, spaceName)
+	if !ok {
+		return
+	}
+
+	lines := cmd.printWatchedInstances(instances, nil, appIsStopped, nil)
+
+	for {
+		select {
+		case <-interrupt:
+			cmd.ui.Say("")
+			return
+		case <-time.After(cmd.watchInterval):
+		}
+
+		previous := instances
+		refreshed, err := cmd.appInstancesRepo.GetInstances(app.Guid)
+		if _, ok := err.(*errors.HttpNotFoundError); ok {
+			cmd.ui.Say(T("App {{.AppName}} no longer exists.", map[string]interface{}{"AppName": terminal.EntityNameColor(app.Name)}))
+			return
+		}
+
+		appIsStopped = isAppStoppedError(err)
+		if err == nil || appIsStopped {
+			instances = refreshed
+			err = nil
+		}
+
+		if cmd.tty {
+			cmd.ui.PrintCapturingNoOutput("\033[%dA\033[J", lines)
+		} else {
+			cmd.ui.Say("")
+		}
+		lines = cmd.printWatchedInstances(instances, previous, appIsStopped, err)
+	}
+}
+
+// printWatchedInstances prints a refresh of the watched app and returns the
+// number of lines printed, so that the next refresh can overwrite them. The
+// instances of the last successful refresh are kept when a refresh fails.
+func (cmd *ShowApp) printWatchedInstances(instances, previous []models.AppInstanceFields, appIsStopped bool, err error) int {
+	// the refresh is printed to a buffer first, so that the lines it takes are
+	// counted whatever the table and the messages contain
+	printer := terminal.NewBufferedPrinter("")
+	refreshCmd := *cmd
+	refreshCmd.ui = terminal.NewUI(os.Stdin, printer)
+
+	if appIsStopped {
+		refreshCmd.ui.Say(T("There are no running instances of this app."))
+	} else {
+		refreshCmd.printInstances(instances, previous)
+	}
+
+	if err != nil {
+		refreshCmd.ui.Say(terminal.FailureColor(T("Could not refresh the instances: {{.Error}}", map[string]interface{}{"Error": err.Error()})))
+	}
+
+	refreshCmd.ui.Say(T("Refreshed at {{.Time}}, every {{.Interval}}. Press Ctrl-C to stop.",
+		map[string]interface{}{
+			"Time":     time.Now().Format("15:04:05"),
+			"Interval": cmd.watchInterval}))
+
+	lines := printer.TakeLines(true)
+	for _, line := range lines {
+		cmd.ui.Say("%s", line)
+	}
+	return len(lines)
+}
+
+func isAppStoppedError(err error) bool {
+	if httpErr, ok := err.(errors.HttpError); ok {
+		return httpErr.ErrorCode() == errors.APP_STOPPED || httpErr.ErrorCode() == errors.APP_NOT_STAGED
+	}
+	return false
+}
+
+// showAppDetails prints everything but the instances of the app, and returns
+// the instances for the caller to print.
+func (cmd *ShowApp) showAppDetails(app models.Application, orgName, spaceName string) (instances []models.AppInstanceFields, appIsStopped bool, ok bool) {
 	cmd.ui.Say(T("Showing health and status for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}}...",
 		map[string]interface{}{
 			"AppName":   terminal.EntityNameColor(app.Name),
@@ -98,19 +255,13 @@ func (cmd *ShowApp) ShowApp(app models.Application, orgName, spaceName string) {
 
 	application, apiErr := cmd.appSummaryRepo.GetSummary(app.Guid)
 
-	appIsStopped := (application.State == "stopped")
-	if err, ok := apiErr.(errors.HttpError); ok {
-		if err.ErrorCode() == errors.APP_STOPPED || err.ErrorCode() == errors.APP_NOT_STAGED {
-			appIsStopped = true
-		}
-	}
+	appIsStopped = (application.State == "stopped") || isAppStoppedError(apiErr)
 
 	if apiErr != nil && !appIsStopped {
 		cmd.ui.Failed(apiErr.Error())
 		return
 	}
 
-	var instances []models.AppInstanceFields
 	instances, apiErr = cmd.appInstancesRepo.GetInstances(app.Guid)
 	if apiErr != nil && !appIsStopped {
 		cmd.ui.Failed(apiErr.Error())
@@ -162,16 +313,29 @@ func (cmd *ShowApp) ShowApp(app models.Application, orgName, spaceName string) {
 		cmd.ui.Say("%s %s\n", terminal.HeaderColor(T("buildpack:")), "unknown")
 	}
 
-	if appIsStopped {
-		cmd.ui.Say(T("There are no running instances of this app."))
-		return
-	}
+	return instances, appIsStopped, true
+}
 
+// printInstances prints the table of the instances and returns the number of
+// lines printed. Crashed and flapping instances are highlighted, as are the
+// instances restarted since the previous instances given.
+func (cmd *ShowApp) printInstances(instances, previous []models.AppInstanceFields) {
 	table := terminal.NewTable(cmd.ui, []string{"", T("state"), T("since"), T("cpu"), T("memory"), T("disk"), T("details")})
 
 	for index, instance := range instances {
+		indexCell := fmt.Sprintf("#%d", index)
+		details := fmt.Sprintf("%s", instance.Details)
+
+		switch {
+		case isUnhealthyInstance(instance):
+			indexCell = terminal.CrashedColor(indexCell)
+			details = terminal.CrashedColor(details)
+		case index < len(previous) && instance.Since.After(previous[index].Since):
+			details = strings.TrimSpace(details + " " + terminal.WarningColor(T("restarted")))
+		}
+
 		table.Add(
-			fmt.Sprintf("#%d", index),
+			indexCell,
 			ui_helpers.ColoredInstanceState(instance),
 			instance.Since.Format("2006-01-02 03:04:05 PM"),
 			fmt.Sprintf("%.1f%%", instance.CpuUsage*100),
@@ -183,13 +347,21 @@ func (cmd *ShowApp) ShowApp(app models.Application, orgName, spaceName string) {
 				map[string]interface{}{
 					"DiskUsage": formatters.ByteSize(instance.DiskUsage),
 					"DiskQuota": formatters.ByteSize(instance.DiskQuota)})),
-			fmt.Sprintf("%s", instance.Details),
+			details,
 		)
 	}
 
 	table.Print()
 }
 
+func isUnhealthyInstance(instance models.AppInstanceFields) bool {
+	switch instance.State {
+	case models.InstanceCrashed, models.InstanceFlapping, models.InstanceDown:
+		return true
+	}
+	return false
+}
+
 func (cmd *ShowApp) populatePluginModel(
 	getSummaryApp models.Application,
 	stack *models.Stack,
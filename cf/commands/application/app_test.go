@@ -136,6 +136,30 @@ var _ = Describe("App", func() {
 				Expect(actualRequirements).To(ContainElement(applicationRequirement))
 			})
 		})
+
+		Context("when --watch is given with an interval", func() {
+			It("takes the interval after the app name out of the arguments", func() {
+				flagContext.Parse("app-name", "--watch", "5s")
+				_, err := cmd.Requirements(factory, flagContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(factory.NewApplicationRequirementArgsForCall(0)).To(Equal("app-name"))
+			})
+
+			It("takes an interval in seconds before the app name out of the arguments", func() {
+				flagContext.Parse("--watch", "10", "app-name")
+				_, err := cmd.Requirements(factory, flagContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(factory.NewApplicationRequirementArgsForCall(0)).To(Equal("app-name"))
+			})
+
+			It("fails with usage when the interval is invalid", func() {
+				flagContext.Parse("app-name", "--watch", "soon")
+				Expect(func() { cmd.Requirements(factory, flagContext) }).To(Panic())
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Incorrect Usage", "Invalid watch interval 'soon'"},
+				))
+			})
+		})
 	})
 
 	Describe("Execute", func() {
@@ -252,6 +276,73 @@ var _ = Describe("App", func() {
 			})
 		})
 
+		Context("when --watch is given", func() {
+			var refreshErr error
+
+			BeforeEach(func() {
+				refreshErr = errors.New("refresh-error")
+
+				flagContext = flags.NewFlagContext(cmd.MetaData().Flags)
+				err := flagContext.Parse("app-name", "--watch", "10ms")
+				Expect(err).NotTo(HaveOccurred())
+				_, err = cmd.Requirements(factory, flagContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				running := appInstanceFields[0]
+				crashed := running
+				crashed.State = models.InstanceCrashed
+				crashed.Details = "crash-details"
+				restarted := running
+				restarted.Since = running.Since.Add(time.Minute)
+
+				refreshes := [][]models.AppInstanceFields{
+					{running},
+					nil,
+					{crashed},
+					{restarted},
+				}
+				appInstancesRepo.GetInstancesStub = func(string) ([]models.AppInstanceFields, error) {
+					call := appInstancesRepo.GetInstancesCallCount() - 1
+					switch {
+					case call == 1:
+						return nil, refreshErr
+					case call >= len(refreshes):
+						return nil, errors.NewHttpError(404, "100004", "The app could not be found")
+					}
+					return refreshes[call], nil
+				}
+			})
+
+			It("refreshes the instances until the app is deleted", func() {
+				cmd.Execute(flagContext)
+
+				Expect(appSummaryRepo.GetSummaryCallCount()).To(Equal(1))
+				Expect(appInstancesRepo.GetInstancesCallCount()).To(Equal(5))
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Showing health and status for app fake-app-name"},
+					[]string{"#0", "running", "25.0%", "24M of 32M", "1G of 2G"},
+					[]string{"Refreshed at", "every 10ms", "Ctrl-C"},
+					[]string{"Could not refresh the instances: refresh-error"},
+					[]string{"#0", "crashed", "crash-details"},
+					[]string{"#0", "running", "2015-11-19 01:02:17 AM", "restarted"},
+					[]string{"App", "fake-app-name", "no longer exists"},
+				))
+			})
+
+			It("moves the cursor up by the lines of the last refresh to redraw it on a terminal", func() {
+				refreshErr = errors.New("refresh-error\nwith details")
+				cmd.(*application.ShowApp).SetTTY(true)
+
+				cmd.Execute(flagContext)
+
+				Expect(ui.UncapturedOutput).To(Equal([]string{
+					"\033[3A\033[J",
+					"\033[5A\033[J",
+					"\033[3A\033[J",
+				}))
+			})
+		})
+
 		Context("when getting the application summary fails for any other reason", func() {
 			BeforeEach(func() {
 				getAppSummaryModel.RunningInstances = 0
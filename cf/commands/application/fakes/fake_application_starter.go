@@ -8,6 +8,7 @@ import (
 	"github.com/cloudfoundry/cli/cf/commands/application"
 	"github.com/cloudfoundry/cli/cf/models"
 	"github.com/cloudfoundry/cli/cf/requirements"
+	"github.com/cloudfoundry/cli/cf/terminal"
 	"github.com/cloudfoundry/cli/flags"
 )
 
@@ -58,6 +59,14 @@ type FakeApplicationStarter struct {
 		result1 models.Application
 		result2 error
 	}
+	WithUIStub        func(ui terminal.UI) application.ApplicationStarter
+	withUIMutex       sync.RWMutex
+	withUIArgsForCall []struct {
+		ui terminal.UI
+	}
+	withUIReturns struct {
+		result1 application.ApplicationStarter
+	}
 }
 
 func (fake *FakeApplicationStarter) MetaData() command_registry.CommandMetadata {
@@ -232,4 +241,36 @@ func (fake *FakeApplicationStarter) ApplicationStartReturns(result1 models.Appli
 	}{result1, result2}
 }
 
+func (fake *FakeApplicationStarter) WithUI(ui terminal.UI) application.ApplicationStarter {
+	fake.withUIMutex.Lock()
+	fake.withUIArgsForCall = append(fake.withUIArgsForCall, struct {
+		ui terminal.UI
+	}{ui})
+	fake.withUIMutex.Unlock()
+	if fake.WithUIStub != nil {
+		return fake.WithUIStub(ui)
+	} else {
+		return fake.withUIReturns.result1
+	}
+}
+
+func (fake *FakeApplicationStarter) WithUICallCount() int {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return len(fake.withUIArgsForCall)
+}
+
+func (fake *FakeApplicationStarter) WithUIArgsForCall(i int) terminal.UI {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return fake.withUIArgsForCall[i].ui
+}
+
+func (fake *FakeApplicationStarter) WithUIReturns(result1 application.ApplicationStarter) {
+	fake.WithUIStub = nil
+	fake.withUIReturns = struct {
+		result1 application.ApplicationStarter
+	}{result1}
+}
+
 var _ application.ApplicationStarter = new(FakeApplicationStarter)
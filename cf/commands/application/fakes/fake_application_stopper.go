@@ -8,6 +8,7 @@ import (
 	"github.com/cloudfoundry/cli/cf/commands/application"
 	"github.com/cloudfoundry/cli/cf/models"
 	"github.com/cloudfoundry/cli/cf/requirements"
+	"github.com/cloudfoundry/cli/cf/terminal"
 	"github.com/cloudfoundry/cli/flags"
 )
 
@@ -53,6 +54,14 @@ type FakeApplicationStopper struct {
 		result1 models.Application
 		result2 error
 	}
+	WithUIStub        func(ui terminal.UI) application.ApplicationStopper
+	withUIMutex       sync.RWMutex
+	withUIArgsForCall []struct {
+		ui terminal.UI
+	}
+	withUIReturns struct {
+		result1 application.ApplicationStopper
+	}
 }
 
 func (fake *FakeApplicationStopper) MetaData() command_registry.CommandMetadata {
@@ -204,4 +213,36 @@ func (fake *FakeApplicationStopper) ApplicationStopReturns(result1 models.Applic
 	}{result1, result2}
 }
 
+func (fake *FakeApplicationStopper) WithUI(ui terminal.UI) application.ApplicationStopper {
+	fake.withUIMutex.Lock()
+	fake.withUIArgsForCall = append(fake.withUIArgsForCall, struct {
+		ui terminal.UI
+	}{ui})
+	fake.withUIMutex.Unlock()
+	if fake.WithUIStub != nil {
+		return fake.WithUIStub(ui)
+	} else {
+		return fake.withUIReturns.result1
+	}
+}
+
+func (fake *FakeApplicationStopper) WithUICallCount() int {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return len(fake.withUIArgsForCall)
+}
+
+func (fake *FakeApplicationStopper) WithUIArgsForCall(i int) terminal.UI {
+	fake.withUIMutex.RLock()
+	defer fake.withUIMutex.RUnlock()
+	return fake.withUIArgsForCall[i].ui
+}
+
+func (fake *FakeApplicationStopper) WithUIReturns(result1 application.ApplicationStopper) {
+	fake.WithUIStub = nil
+	fake.withUIReturns = struct {
+		result1 application.ApplicationStopper
+	}{result1}
+}
+
 var _ application.ApplicationStopper = new(FakeApplicationStopper)
@@ -1,10 +1,16 @@
 package application
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/cloudfoundry/cli/cf/api"
+	"github.com/cloudfoundry/cli/cf/api/applications"
 	"github.com/cloudfoundry/cli/cf/command_registry"
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
 	"github.com/cloudfoundry/cli/cf/errors"
@@ -19,12 +25,19 @@ import (
 )
 
 type Logs struct {
-	ui       terminal.UI
-	config   core_config.Reader
-	logsRepo api.LogsRepository
-	appReq   requirements.ApplicationRequirement
+	ui             terminal.UI
+	config         core_config.Reader
+	logsRepo       api.LogsRepository
+	appRepo        applications.ApplicationRepository
+	appSummaryRepo api.AppSummaryRepository
+	appReq         requirements.ApplicationRequirement
+	jsonOutput     bool
+	filter         api.LogFilter
+	appPrefixes    map[string]string
 }
 
+var logSourceTypes = []string{"API", "APP", "CELL", "DEA", "HEALTH", "LGR", "RTR", "SSH", "STG"}
+
 func init() {
 	command_registry.Register(&Logs{})
 }
@@ -32,26 +45,51 @@ func init() {
 func (cmd *Logs) MetaData() command_registry.CommandMetadata {
 	fs := make(map[string]flags.FlagSet)
 	fs["recent"] = &cliFlags.BoolFlag{Name: "recent", Usage: T("Dump recent logs instead of tailing")}
+	fs["all-apps"] = &cliFlags.BoolFlag{Name: "all-apps", Usage: T("Show the logs of all the apps in the targeted space")}
+	fs["format"] = &cliFlags.StringFlag{Name: "format", Usage: T("Output format of the logs, 'json' prints one JSON object per line")}
+	fs["source"] = &cliFlags.StringFlag{Name: "source", Usage: T("Only show logs from the comma separated source types (e.g. APP,RTR,STG,API,CELL)")}
+	fs["instance"] = &cliFlags.StringFlag{Name: "instance", Usage: T("Only show logs from the comma separated instance indexes (e.g. 0,2)")}
+	fs["stream"] = &cliFlags.StringFlag{Name: "stream", Usage: T("Only show logs written to the given stream, stdout or stderr")}
+	fs["match"] = &cliFlags.StringFlag{Name: "match", Usage: T("Only show logs whose message matches the regular expression")}
+	fs["since"] = &cliFlags.StringFlag{Name: "since", Usage: T("Only show logs from the given duration ago (e.g. 90s, 10m), the recent logs fill in the logs missed while reconnecting")}
 
 	return command_registry.CommandMetadata{
 		Name:        "logs",
 		Description: T("Tail or show recent logs for an app"),
-		Usage:       T("CF_NAME logs APP_NAME"),
+		Usage:       T("CF_NAME logs (APP_NAME [APP_NAME...] | --all-apps) [--recent] [--format json] [--source TYPE[,TYPE]] [--instance INDEX[,INDEX]] [--stream stdout|stderr] [--match REGEX] [--since DURATION]"),
 		Flags:       fs,
 	}
 }
 
 func (cmd *Logs) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) (reqs []requirements.Requirement, err error) {
-	if len(fc.Args()) != 1 {
+	if fc.Bool("all-apps") && len(fc.Args()) != 0 {
+		cmd.ui.Failed(T("Incorrect Usage. --all-apps cannot be given together with app names\n\n") + command_registry.Commands.CommandUsage("logs"))
+	}
+
+	if !fc.Bool("all-apps") && len(fc.Args()) == 0 {
 		cmd.ui.Failed(T("Incorrect Usage. Requires an argument\n\n") + command_registry.Commands.CommandUsage("logs"))
 	}
 
-	cmd.appReq = requirementsFactory.NewApplicationRequirement(fc.Args()[0])
+	if fc.IsSet("format") && fc.String("format") != "json" {
+		cmd.ui.Failed(T("Incorrect Usage. Unknown log format '{{.Format}}', the only format is json\n\n",
+			map[string]interface{}{"Format": fc.String("format")}) + command_registry.Commands.CommandUsage("logs"))
+	}
+
+	cmd.filter, err = logFilterFromFlags(fc)
+	if err != nil {
+		cmd.ui.Failed(T("Incorrect Usage. ") + err.Error() + "\n\n" + command_registry.Commands.CommandUsage("logs"))
+	}
 
 	reqs = []requirements.Requirement{
 		requirementsFactory.NewLoginRequirement(),
 		requirementsFactory.NewTargetedSpaceRequirement(),
-		cmd.appReq,
+	}
+
+	// The apps are looked up in Execute when there are several of them
+	cmd.appReq = nil
+	if len(fc.Args()) == 1 {
+		cmd.appReq = requirementsFactory.NewApplicationRequirement(fc.Args()[0])
+		reqs = append(reqs, cmd.appReq)
 	}
 
 	return
@@ -61,40 +99,115 @@ func (cmd *Logs) SetDependency(deps command_registry.Dependency, pluginCall bool
 	cmd.ui = deps.Ui
 	cmd.config = deps.Config
 	cmd.logsRepo = deps.RepoLocator.GetLogsRepository()
+	cmd.appRepo = deps.RepoLocator.GetApplicationRepository()
+	cmd.appSummaryRepo = deps.RepoLocator.GetAppSummaryRepository()
 	return cmd
 }
 
 func (cmd *Logs) Execute(c flags.FlagContext) {
-	app := cmd.appReq.GetApplication()
+	cmd.jsonOutput = c.String("format") == "json"
+	cmd.appPrefixes = nil
+
+	if cmd.appReq != nil {
+		app := cmd.appReq.GetApplication()
+		if c.Bool("recent") {
+			cmd.recentLogsFor(app)
+		} else {
+			cmd.tailLogsFor(app)
+		}
+		return
+	}
 
+	apps := cmd.findApps(c)
+	if len(apps) == 0 {
+		cmd.ui.Say(T("No apps found in org {{.OrgName}} / space {{.SpaceName}}",
+			map[string]interface{}{
+				"OrgName":   terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+				"SpaceName": terminal.EntityNameColor(cmd.config.SpaceFields().Name)}))
+		return
+	}
+
+	cmd.appPrefixes = appLogPrefixes(apps)
 	if c.Bool("recent") {
-		cmd.recentLogsFor(app)
+		cmd.recentLogsForApps(apps)
 	} else {
-		cmd.tailLogsFor(app)
+		cmd.tailLogsForApps(apps)
+	}
+}
+
+func (cmd *Logs) findApps(c flags.FlagContext) []models.Application {
+	if c.Bool("all-apps") {
+		apps, err := cmd.appSummaryRepo.GetSummariesInCurrentSpace()
+		if err != nil {
+			cmd.ui.Failed(err.Error())
+		}
+		return apps
+	}
+
+	apps := []models.Application{}
+	seen := map[string]bool{}
+	for _, appName := range c.Args() {
+		if seen[appName] {
+			continue
+		}
+		seen[appName] = true
+
+		app, err := cmd.appRepo.Read(appName)
+		if err != nil {
+			cmd.ui.Failed(err.Error())
+		}
+		apps = append(apps, app)
 	}
+	return apps
 }
 
 func (cmd *Logs) recentLogsFor(app models.Application) {
-	cmd.ui.Say(T("Connected, dumping recent logs for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}}...\n",
+	cmd.sayConnected(T("Connected, dumping recent logs for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}}...\n",
 		map[string]interface{}{
 			"AppName":   terminal.EntityNameColor(app.Name),
 			"OrgName":   terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
 			"SpaceName": terminal.EntityNameColor(cmd.config.SpaceFields().Name),
 			"Username":  terminal.EntityNameColor(cmd.config.Username())}))
 
-	messages, err := cmd.logsRepo.RecentLogsFor(app.Guid)
+	messages, err := cmd.logsRepo.RecentLogsFor(app.Guid, cmd.filter)
 	if err != nil {
 		cmd.handleError(err)
 	}
 
 	for _, msg := range messages {
-		cmd.ui.Say("%s", LogMessageOutput(msg, time.Local))
+		cmd.printLogMessage(msg)
+	}
+}
+
+func (cmd *Logs) recentLogsForApps(apps []models.Application) {
+	cmd.sayConnected(T("Connected, dumping recent logs for apps {{.AppNames}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}}...\n",
+		map[string]interface{}{
+			"AppNames":  terminal.EntityNameColor(appNames(apps)),
+			"OrgName":   terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+			"SpaceName": terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+			"Username":  terminal.EntityNameColor(cmd.config.Username())}))
+
+	// the queue sorts the messages of all the apps by time, without holding
+	// them back as there is nothing else to wait for
+	messageQueue := api.NewLoggregator_SortedMessageQueue(0, time.Now)
+	for _, app := range apps {
+		appMessages, err := cmd.logsRepo.RecentLogsFor(app.Guid, cmd.filter)
+		if err != nil {
+			cmd.handleError(err)
+		}
+		for _, msg := range appMessages {
+			messageQueue.PushMessage(msg)
+		}
+	}
+
+	for msg := messageQueue.PopMessage(); msg != nil; msg = messageQueue.PopMessage() {
+		cmd.printLogMessage(msg)
 	}
 }
 
 func (cmd *Logs) tailLogsFor(app models.Application) {
 	onConnect := func() {
-		cmd.ui.Say(T("Connected, tailing logs for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}}...\n",
+		cmd.sayConnected(T("Connected, tailing logs for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}}...\n",
 			map[string]interface{}{
 				"AppName":   terminal.EntityNameColor(app.Name),
 				"OrgName":   terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
@@ -102,15 +215,182 @@ func (cmd *Logs) tailLogsFor(app models.Application) {
 				"Username":  terminal.EntityNameColor(cmd.config.Username())}))
 	}
 
-	err := cmd.logsRepo.TailLogsFor(app.Guid, onConnect, func(msg *logmessage.LogMessage) {
-		cmd.ui.Say("%s", LogMessageOutput(msg, time.Local))
-	})
+	err := cmd.logsRepo.TailLogsForApps([]string{app.Guid}, cmd.filter, onConnect, cmd.reconnectHandler([]models.Application{app}), cmd.printLogMessage)
 
 	if err != nil {
 		cmd.handleError(err)
 	}
 }
 
+func (cmd *Logs) tailLogsForApps(apps []models.Application) {
+	onConnect := func() {
+		cmd.sayConnected(T("Connected, tailing logs for apps {{.AppNames}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}}...\n",
+			map[string]interface{}{
+				"AppNames":  terminal.EntityNameColor(appNames(apps)),
+				"OrgName":   terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+				"SpaceName": terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+				"Username":  terminal.EntityNameColor(cmd.config.Username())}))
+	}
+
+	appGuids := []string{}
+	for _, app := range apps {
+		appGuids = append(appGuids, app.Guid)
+	}
+
+	err := cmd.logsRepo.TailLogsForApps(appGuids, cmd.filter, onConnect, cmd.reconnectHandler(apps), cmd.printLogMessage)
+
+	if err != nil {
+		cmd.handleError(err)
+	}
+}
+
+// sayConnected is left out of JSON output, so that every line printed can be
+// parsed on its own.
+func (cmd *Logs) sayConnected(message string) {
+	if !cmd.jsonOutput {
+		cmd.ui.Say(message)
+	}
+}
+
+// reconnectHandler marks the place in the logs where the tail of an app
+// dropped, as the logs sent while reconnecting may be missing.
+func (cmd *Logs) reconnectHandler(apps []models.Application) func(string, time.Duration) {
+	names := map[string]string{}
+	for _, app := range apps {
+		names[app.Guid] = app.Name
+	}
+
+	return func(appGuid string, missing time.Duration) {
+		seconds := int64(math.Ceil(missing.Seconds()))
+
+		message := T("Reconnected to the logs of app {{.AppName}}, {{.Seconds}} seconds of logs may be missing",
+			map[string]interface{}{"AppName": names[appGuid], "Seconds": seconds})
+		if !cmd.filter.Since.IsZero() {
+			message = T("Reconnected to the logs of app {{.AppName}} after {{.Seconds}} seconds, the missing logs were filled in from the recent logs",
+				map[string]interface{}{"AppName": names[appGuid], "Seconds": seconds})
+		}
+
+		if cmd.jsonOutput {
+			jsonBytes, _ := json.Marshal(logMessageJSON{
+				Type:      reconnectedJSONType,
+				Timestamp: time.Now().Format(time.RFC3339Nano),
+				AppGuid:   appGuid,
+				Message:   message,
+			})
+			cmd.ui.Say("%s", string(jsonBytes))
+			return
+		}
+		cmd.ui.Say(terminal.WarningColor("--- " + message + " ---"))
+	}
+}
+
+func (cmd *Logs) printLogMessage(msg *logmessage.LogMessage) {
+	if cmd.jsonOutput {
+		cmd.ui.Say("%s", LogMessageJSON(msg, time.Local))
+		return
+	}
+
+	output := LogMessageOutput(msg, time.Local)
+	if prefix, ok := cmd.appPrefixes[msg.GetAppId()]; ok {
+		output = prefix + strings.Replace(output, "\n", "\n"+prefix, -1)
+	}
+	cmd.ui.Say("%s", output)
+}
+
+// appLogPrefixes gives each app a colored prefix, padded to the longest app
+// name so that the log lines of all the apps stay aligned.
+func appLogPrefixes(apps []models.Application) map[string]string {
+	width := 0
+	for _, app := range apps {
+		if len(app.Name) > width {
+			width = len(app.Name)
+		}
+	}
+
+	prefixes := map[string]string{}
+	for i, app := range apps {
+		prefixes[app.Guid] = terminal.LogAppNameColor(fmt.Sprintf("%-*s", width, app.Name), i) + " | "
+	}
+	return prefixes
+}
+
+func appNames(apps []models.Application) string {
+	names := []string{}
+	for _, app := range apps {
+		names = append(names, app.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func logFilterFromFlags(fc flags.FlagContext) (api.LogFilter, error) {
+	filter := api.LogFilter{}
+
+	for _, source := range splitFlagList(fc.String("source")) {
+		if !isLogSourceType(source) {
+			return filter, errors.New(T("Unknown log source '{{.Source}}', the sources are {{.Sources}}",
+				map[string]interface{}{"Source": source, "Sources": strings.Join(logSourceTypes, ", ")}))
+		}
+		filter.SourceTypes = append(filter.SourceTypes, source)
+	}
+
+	for _, instance := range splitFlagList(fc.String("instance")) {
+		if index, err := strconv.Atoi(instance); err != nil || index < 0 {
+			return filter, errors.New(T("Invalid instance index '{{.Instance}}'", map[string]interface{}{"Instance": instance}))
+		}
+		filter.Instances = append(filter.Instances, instance)
+	}
+
+	switch stream := fc.String("stream"); stream {
+	case "":
+	case "stdout":
+		filter.MessageTypes = []logmessage.LogMessage_MessageType{logmessage.LogMessage_OUT}
+	case "stderr":
+		filter.MessageTypes = []logmessage.LogMessage_MessageType{logmessage.LogMessage_ERR}
+	default:
+		return filter, errors.New(T("Unknown stream '{{.Stream}}', expected stdout or stderr", map[string]interface{}{"Stream": stream}))
+	}
+
+	if fc.String("match") != "" {
+		pattern, err := regexp.Compile(fc.String("match"))
+		if err != nil {
+			return filter, errors.New(T("Invalid regular expression for --match: {{.Error}}", map[string]interface{}{"Error": err.Error()}))
+		}
+		filter.Pattern = pattern
+	}
+
+	if fc.String("since") != "" {
+		since, err := time.ParseDuration(fc.String("since"))
+		if err == nil && since <= 0 {
+			err = errors.New(T("the duration must be positive"))
+		}
+		if err != nil {
+			return filter, errors.New(T("Invalid value for --since: {{.Error}}", map[string]interface{}{"Error": err.Error()}))
+		}
+		filter.Since = time.Now().Add(-since)
+	}
+
+	return filter, nil
+}
+
+func isLogSourceType(source string) bool {
+	for _, sourceType := range logSourceTypes {
+		if strings.EqualFold(source, sourceType) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitFlagList(list string) []string {
+	values := []string{}
+	for _, value := range strings.Split(list, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
 func (cmd *Logs) handleError(err error) {
 	switch err.(type) {
 	case nil:
@@ -127,3 +407,40 @@ func LogMessageOutput(msg *logmessage.LogMessage, loc *time.Location) string {
 
 	return fmt.Sprintf("%s%s", coloredLogHeader, logContent)
 }
+
+// The type of a JSON line tells a log message from a notice of the CLI about
+// the logs, which has the same fields.
+const (
+	logJSONType         = "log"
+	reconnectedJSONType = "reconnected"
+)
+
+type logMessageJSON struct {
+	Type           string `json:"type"`
+	Timestamp      string `json:"timestamp"`
+	AppGuid        string `json:"app_guid"`
+	SourceType     string `json:"source_type"`
+	SourceInstance string `json:"source_instance"`
+	MessageType    string `json:"message_type"`
+	Message        string `json:"message"`
+}
+
+// LogMessageJSON formats the message as a single line JSON object.
+func LogMessageJSON(msg *logmessage.LogMessage, loc *time.Location) string {
+	messageType := "OUT"
+	if msg.GetMessageType() == logmessage.LogMessage_ERR {
+		messageType = "ERR"
+	}
+
+	jsonBytes, _ := json.Marshal(logMessageJSON{
+		Type:           logJSONType,
+		Timestamp:      time.Unix(0, msg.GetTimestamp()).In(loc).Format(time.RFC3339Nano),
+		AppGuid:        msg.GetAppId(),
+		SourceType:     msg.GetSourceName(),
+		SourceInstance: msg.GetSourceId(),
+		MessageType:    messageType,
+		Message:        strings.TrimRight(string(msg.GetMessage()), "\r\n"),
+	})
+
+	return string(jsonBytes)
+}
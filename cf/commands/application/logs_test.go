@@ -1,8 +1,12 @@
 package application_test
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
 
+	"github.com/cloudfoundry/cli/cf/api"
+	testApplication "github.com/cloudfoundry/cli/cf/api/applications/fakes"
 	testapi "github.com/cloudfoundry/cli/cf/api/fakes"
 	"github.com/cloudfoundry/cli/cf/command_registry"
 	"github.com/cloudfoundry/cli/cf/errors"
@@ -26,6 +30,8 @@ var _ = Describe("logs command", func() {
 	var (
 		ui                  *testterm.FakeUI
 		logsRepo            *testapi.FakeLogsRepository
+		appRepo             *testApplication.FakeApplicationRepository
+		appSummaryRepo      *testapi.FakeAppSummaryRepo
 		requirementsFactory *testreq.FakeReqFactory
 		configRepo          core_config.Repository
 		deps                command_registry.Dependency
@@ -34,6 +40,8 @@ var _ = Describe("logs command", func() {
 	updateCommandDependency := func(pluginCall bool) {
 		deps.Ui = ui
 		deps.RepoLocator = deps.RepoLocator.SetLogsRepository(logsRepo)
+		deps.RepoLocator = deps.RepoLocator.SetApplicationRepository(appRepo)
+		deps.RepoLocator = deps.RepoLocator.SetAppSummaryRepository(appSummaryRepo)
 		deps.Config = configRepo
 		command_registry.Commands.SetCommand(command_registry.Commands.FindCommand("logs").SetDependency(deps, pluginCall))
 	}
@@ -42,6 +50,8 @@ var _ = Describe("logs command", func() {
 		ui = &testterm.FakeUI{}
 		configRepo = testconfig.NewRepositoryWithDefaults()
 		logsRepo = &testapi.FakeLogsRepository{}
+		appRepo = &testApplication.FakeApplicationRepository{}
+		appSummaryRepo = &testapi.FakeAppSummaryRepo{}
 		requirementsFactory = &testreq.FakeReqFactory{}
 	})
 
@@ -68,6 +78,76 @@ var _ = Describe("logs command", func() {
 			Expect(runCommand("--recent", "my-app")).To(BeFalse())
 		})
 
+		Describe("filter flags", func() {
+			BeforeEach(func() {
+				requirementsFactory.LoginSuccess = true
+				requirementsFactory.TargetedSpaceSuccess = true
+			})
+
+			It("fails with usage when given an unknown source", func() {
+				runCommand("--source", "APP,FOO", "my-app")
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Incorrect Usage", "Unknown log source 'FOO'"},
+				))
+			})
+
+			It("fails with usage when given an invalid instance index", func() {
+				runCommand("--instance", "first", "my-app")
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Incorrect Usage", "Invalid instance index 'first'"},
+				))
+			})
+
+			It("fails with usage when given an unknown stream", func() {
+				runCommand("--stream", "stdin", "my-app")
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Incorrect Usage", "Unknown stream 'stdin'"},
+				))
+			})
+
+			It("fails with usage when given an invalid regular expression", func() {
+				runCommand("--match", "(", "my-app")
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Incorrect Usage", "Invalid regular expression for --match"},
+				))
+			})
+
+			It("fails with usage when given an invalid --since duration", func() {
+				runCommand("--since", "-5m", "my-app")
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Incorrect Usage", "Invalid value for --since"},
+				))
+			})
+		})
+
+		It("fails with usage when given app names and --all-apps", func() {
+			requirementsFactory.LoginSuccess = true
+			requirementsFactory.TargetedSpaceSuccess = true
+
+			runCommand("--all-apps", "my-app")
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Incorrect Usage", "--all-apps cannot be given together with app names"},
+			))
+		})
+
+		It("does not require an app when given several apps", func() {
+			requirementsFactory.LoginSuccess = true
+			requirementsFactory.TargetedSpaceSuccess = true
+
+			runCommand("--recent", "app1", "app2")
+			Expect(requirementsFactory.ApplicationName).To(BeEmpty())
+		})
+
+		It("fails with usage when given an unknown format", func() {
+			requirementsFactory.LoginSuccess = true
+			requirementsFactory.TargetedSpaceSuccess = true
+
+			runCommand("--format", "xml", "my-app")
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Incorrect Usage", "Unknown log format 'xml'"},
+			))
+		})
+
 	})
 
 	Context("when logged in", func() {
@@ -95,7 +175,7 @@ var _ = Describe("logs command", func() {
 
 			requirementsFactory.Application = app
 			logsRepo.RecentLogsForReturns(recentLogs, nil)
-			logsRepo.TailLogsForStub = func(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+			logsRepo.TailLogsForAppsStub = func(appGuids []string, filter api.LogFilter, onConnect func(), onReconnect func(string, time.Duration), onMessage func(*logmessage.LogMessage)) error {
 				onConnect()
 				for _, log := range appLogs {
 					onMessage(log)
@@ -109,7 +189,8 @@ var _ = Describe("logs command", func() {
 			runCommand("--recent", "my-app")
 
 			Expect(requirementsFactory.ApplicationName).To(Equal("my-app"))
-			Expect(app.Guid).To(Equal(logsRepo.RecentLogsForArgsForCall(0)))
+			appGuid, _ := logsRepo.RecentLogsForArgsForCall(0)
+			Expect(app.Guid).To(Equal(appGuid))
 			Expect(ui.Outputs).To(ContainSubstrings(
 				[]string{"Connected, dumping recent logs for app", "my-app", "my-org", "my-space", "my-user"},
 				[]string{"Log Line 1"},
@@ -134,18 +215,213 @@ var _ = Describe("logs command", func() {
 			runCommand("my-app")
 
 			Expect(requirementsFactory.ApplicationName).To(Equal("my-app"))
-			appGuid, _, _ := logsRepo.TailLogsForArgsForCall(0)
-			Expect(app.Guid).To(Equal(appGuid))
+			appGuids, _, _, _, _ := logsRepo.TailLogsForAppsArgsForCall(0)
+			Expect(appGuids).To(Equal([]string{app.Guid}))
 			Expect(ui.Outputs).To(ContainSubstrings(
 				[]string{"Connected, tailing logs for app", "my-app", "my-org", "my-space", "my-user"},
 				[]string{"Log Line 1"},
 			))
 		})
 
+		Context("when several apps are given", func() {
+			var date time.Time
+
+			BeforeEach(func() {
+				date = time.Date(2014, 4, 4, 11, 39, 20, 5, time.UTC)
+
+				appRepo.ReadStub = func(name string) (models.Application, error) {
+					app := models.Application{}
+					app.Name = name
+					app.Guid = name + "-guid"
+					return app, nil
+				}
+
+				logsRepo.RecentLogsForStub = func(appGuid string, filter api.LogFilter) ([]*logmessage.LogMessage, error) {
+					if appGuid == "app1-guid" {
+						return []*logmessage.LogMessage{
+							testlogs.NewLogMessage("app1 first", appGuid, "App", "0", logmessage.LogMessage_OUT, date),
+							testlogs.NewLogMessage("app1 second", appGuid, "App", "0", logmessage.LogMessage_OUT, date.Add(2*time.Second)),
+						}, nil
+					}
+					return []*logmessage.LogMessage{
+						testlogs.NewLogMessage("app-two first", appGuid, "App", "0", logmessage.LogMessage_OUT, date.Add(time.Second)),
+					}, nil
+				}
+
+				logsRepo.TailLogsForAppsStub = func(appGuids []string, filter api.LogFilter, onConnect func(), onReconnect func(string, time.Duration), onMessage func(*logmessage.LogMessage)) error {
+					onConnect()
+					onMessage(testlogs.NewLogMessage("tailed line\nsecond line", "app1-guid", "App", "0", logmessage.LogMessage_OUT, date))
+					return nil
+				}
+			})
+
+			It("interleaves the recent logs of the apps by time", func() {
+				runCommand("--recent", "app1", "app-two")
+
+				Expect(appRepo.ReadCallCount()).To(Equal(2))
+				Expect(logsRepo.RecentLogsForCallCount()).To(Equal(2))
+				Expect(terminal.Decolorize(strings.Join(ui.Outputs, "\n"))).To(ContainSubstring("Connected, dumping recent logs for apps app1, app-two"))
+
+				lines := []string{}
+				for _, line := range ui.Outputs {
+					if strings.Contains(line, " | ") {
+						lines = append(lines, terminal.Decolorize(line))
+					}
+				}
+				Expect(lines).To(HaveLen(3))
+				Expect(lines[0]).To(HavePrefix("app1    | "))
+				Expect(lines[0]).To(HaveSuffix("app1 first"))
+				Expect(lines[1]).To(HavePrefix("app-two | "))
+				Expect(lines[1]).To(HaveSuffix("app-two first"))
+				Expect(lines[2]).To(HaveSuffix("app1 second"))
+			})
+
+			It("tails the logs of all the apps together", func() {
+				runCommand("app1", "app-two")
+
+				appGuids, _, _, _, _ := logsRepo.TailLogsForAppsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"app1-guid", "app-two-guid"}))
+				Expect(logsRepo.TailLogsForCallCount()).To(Equal(0))
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Connected, tailing logs for apps", "app1, app-two", "my-org", "my-space"},
+					[]string{"app1    | ", "tailed line"},
+					[]string{"app1    | ", "second line"},
+				))
+			})
+
+			It("looks up an app given more than once only once", func() {
+				runCommand("app1", "app-two", "app1")
+
+				Expect(appRepo.ReadCallCount()).To(Equal(2))
+				appGuids, _, _, _, _ := logsRepo.TailLogsForAppsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"app1-guid", "app-two-guid"}))
+			})
+
+			It("tails the logs of every app in the space with --all-apps", func() {
+				app1 := models.Application{}
+				app1.Name = "app1"
+				app1.Guid = "app1-guid"
+				app2 := models.Application{}
+				app2.Name = "app2"
+				app2.Guid = "app2-guid"
+				appSummaryRepo.GetSummariesInCurrentSpaceApps = []models.Application{app1, app2}
+
+				runCommand("--all-apps")
+
+				Expect(appRepo.ReadCallCount()).To(Equal(0))
+				appGuids, _, _, _, _ := logsRepo.TailLogsForAppsArgsForCall(0)
+				Expect(appGuids).To(Equal([]string{"app1-guid", "app2-guid"}))
+			})
+
+			It("says so when there are no apps in the space", func() {
+				runCommand("--all-apps")
+
+				Expect(logsRepo.TailLogsForAppsCallCount()).To(Equal(0))
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"No apps found in org", "my-org", "my-space"},
+				))
+			})
+		})
+
+		Context("when filter flags are provided", func() {
+			It("filters the recent logs", func() {
+				runCommand("--recent", "--source", "APP,rtr", "--instance", "0, 2", "--stream", "stderr", "--match", "^GET", "my-app")
+
+				_, filter := logsRepo.RecentLogsForArgsForCall(0)
+				Expect(filter.SourceTypes).To(Equal([]string{"APP", "rtr"}))
+				Expect(filter.Instances).To(Equal([]string{"0", "2"}))
+				Expect(filter.MessageTypes).To(Equal([]logmessage.LogMessage_MessageType{logmessage.LogMessage_ERR}))
+				Expect(filter.Pattern.String()).To(Equal("^GET"))
+			})
+
+			It("filters the tailed logs", func() {
+				runCommand("--stream", "stdout", "my-app")
+
+				_, filter, _, _, _ := logsRepo.TailLogsForAppsArgsForCall(0)
+				Expect(filter.MessageTypes).To(Equal([]logmessage.LogMessage_MessageType{logmessage.LogMessage_OUT}))
+				Expect(filter.SourceTypes).To(BeEmpty())
+				Expect(filter.Since.IsZero()).To(BeTrue())
+			})
+
+			It("only asks for the logs since the given duration ago", func() {
+				runCommand("--since", "10m", "my-app")
+
+				_, filter, _, _, _ := logsRepo.TailLogsForAppsArgsForCall(0)
+				Expect(filter.Since).To(BeTemporally("~", time.Now().Add(-10*time.Minute), time.Minute))
+			})
+		})
+
+		Context("when the tail of an app reconnects", func() {
+			BeforeEach(func() {
+				logsRepo.TailLogsForAppsStub = func(appGuids []string, filter api.LogFilter, onConnect func(), onReconnect func(string, time.Duration), onMessage func(*logmessage.LogMessage)) error {
+					onConnect()
+					onReconnect("my-app-guid", 2500*time.Millisecond)
+					return nil
+				}
+			})
+
+			It("says that logs may be missing", func() {
+				runCommand("my-app")
+
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Reconnected to the logs of app my-app", "3 seconds of logs may be missing"},
+				))
+			})
+
+			It("says that the missing logs were filled in with --since", func() {
+				runCommand("--since", "1h", "my-app")
+
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Reconnected to the logs of app my-app after 3 seconds", "filled in from the recent logs"},
+				))
+			})
+
+			It("prints the reconnection as a JSON object with --format json", func() {
+				runCommand("--format", "json", "my-app")
+
+				Expect(ui.Outputs).To(HaveLen(1))
+				reconnected := map[string]string{}
+				Expect(json.Unmarshal([]byte(ui.Outputs[0]), &reconnected)).To(Succeed())
+				Expect(reconnected).To(HaveLen(7))
+				Expect(reconnected["type"]).To(Equal("reconnected"))
+				Expect(reconnected["app_guid"]).To(Equal("my-app-guid"))
+				Expect(reconnected["message"]).To(Equal("Reconnected to the logs of app my-app, 3 seconds of logs may be missing"))
+				Expect(reconnected).To(HaveKey("source_type"))
+				Expect(reconnected).To(HaveKey("source_instance"))
+				Expect(reconnected).To(HaveKey("message_type"))
+
+				timestamp, err := time.Parse(time.RFC3339Nano, reconnected["timestamp"])
+				Expect(err).NotTo(HaveOccurred())
+				Expect(timestamp).To(BeTemporally("~", time.Now(), time.Minute))
+			})
+		})
+
+		Context("when the --format json flag is provided", func() {
+			It("prints only one JSON object per recent log message", func() {
+				runCommand("--recent", "--format", "json", "my-app")
+
+				Expect(ui.Outputs).To(HaveLen(2))
+				for _, line := range ui.Outputs {
+					var msg map[string]string
+					Expect(json.Unmarshal([]byte(line), &msg)).To(Succeed())
+					Expect(msg["app_guid"]).To(Equal("my-app-guid"))
+					Expect(msg["message_type"]).To(Equal("ERR"))
+				}
+				Expect(ui.Outputs[1]).To(ContainSubstring(`"message":"Log Line 2"`))
+			})
+
+			It("prints only one JSON object per tailed log message", func() {
+				runCommand("--format", "json", "my-app")
+
+				Expect(ui.Outputs).To(HaveLen(1))
+				Expect(ui.Outputs[0]).To(ContainSubstring(`"message":"Log Line 1"`))
+			})
+		})
+
 		Context("when the loggregator server has an invalid cert", func() {
 			Context("when the skip-ssl-validation flag is not set", func() {
 				It("fails and informs the user about the skip-ssl-validation flag", func() {
-					logsRepo.TailLogsForReturns(errors.NewInvalidSSLCert("https://example.com", "it don't work good"))
+					logsRepo.TailLogsForAppsReturns(errors.NewInvalidSSLCert("https://example.com", "it don't work good"))
 					runCommand("my-app")
 
 					Expect(ui.Outputs).To(ContainSubstrings(
@@ -201,6 +477,19 @@ var _ = Describe("logs command", func() {
 				})
 			})
 
+			It("formats the message as JSON", func() {
+				msg := testlogs.NewLogMessage("Hello World!\n", app.Guid, "DEA", "4", logmessage.LogMessage_OUT, date)
+				Expect(LogMessageJSON(msg, time.UTC)).To(MatchJSON(`{
+					"type": "log",
+					"timestamp": "2014-04-04T11:39:20.000000005Z",
+					"app_guid": "my-app-guid",
+					"source_type": "DEA",
+					"source_instance": "4",
+					"message_type": "OUT",
+					"message": "Hello World!"
+				}`))
+			})
+
 			It("formats the time in the given time zone", func() {
 				msg := testlogs.NewLogMessage("Hello World!", app.Guid, "DEA", "4", logmessage.LogMessage_ERR, date)
 				Expect(terminal.Decolorize(LogMessageOutput(msg, time.FixedZone("the-zone", 3*60*60)))).To(Equal("2014-04-04T14:39:20.00+0300 [DEA/4]      ERR Hello World!"))
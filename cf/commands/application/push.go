@@ -1,12 +1,17 @@
 package application
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/blang/semver"
 	. "github.com/cloudfoundry/cli/cf/i18n"
@@ -15,8 +20,10 @@ import (
 
 	"github.com/cloudfoundry/cli/cf/actors"
 	"github.com/cloudfoundry/cli/cf/api"
+	"github.com/cloudfoundry/cli/cf/api/application_bits"
 	"github.com/cloudfoundry/cli/cf/api/applications"
 	"github.com/cloudfoundry/cli/cf/api/authentication"
+	"github.com/cloudfoundry/cli/cf/api/resources"
 	"github.com/cloudfoundry/cli/cf/api/stacks"
 	"github.com/cloudfoundry/cli/cf/app_files"
 	"github.com/cloudfoundry/cli/cf/command_registry"
@@ -28,26 +35,53 @@ import (
 	"github.com/cloudfoundry/cli/cf/models"
 	"github.com/cloudfoundry/cli/cf/requirements"
 	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/utils"
 	"github.com/cloudfoundry/cli/words/generator"
 )
 
+const (
+	BlueGreenStrategy      = "blue-green"
+	BlueGreenTempAppSuffix = "-new"
+	BlueGreenOldAppSuffix  = "-old"
+
+	DefaultUploadRetries      = 2
+	DefaultUploadRetryBackoff = 2 * time.Second
+	maxUploadRetryBackoff     = 1 * time.Minute
+)
+
 type Push struct {
-	ui            terminal.UI
-	config        core_config.Reader
-	manifestRepo  manifest.ManifestRepository
-	appStarter    ApplicationStarter
-	appStopper    ApplicationStopper
-	serviceBinder service.ServiceBinder
-	appRepo       applications.ApplicationRepository
-	domainRepo    api.DomainRepository
-	routeRepo     api.RouteRepository
-	serviceRepo   api.ServiceRepository
-	stackRepo     stacks.StackRepository
-	authRepo      authentication.AuthenticationRepository
-	wordGenerator generator.WordGenerator
-	actor         actors.PushActor
-	zipper        app_files.Zipper
-	appfiles      app_files.AppFiles
+	ui             terminal.UI
+	config         core_config.Reader
+	manifestRepo   manifest.ManifestRepository
+	appStarter     ApplicationStarter
+	appStopper     ApplicationStopper
+	serviceBinder  service.ServiceBinder
+	appRepo        applications.ApplicationRepository
+	appSummaryRepo api.AppSummaryRepository
+	domainRepo     api.DomainRepository
+	routeRepo      api.RouteRepository
+	serviceRepo    api.ServiceRepository
+	bindingRepo    api.ServiceBindingRepository
+	stackRepo      stacks.StackRepository
+	authRepo       authentication.AuthenticationRepository
+	wordGenerator  generator.WordGenerator
+	actor          actors.PushActor
+	zipper         app_files.Zipper
+	appfiles       app_files.AppFiles
+	appBitsRepo    application_bits.ApplicationBitsRepository
+	shellRunner    utils.ShellRunner
+
+	// UploadRetryBackoff is the time to wait before the first retry of a
+	// failed upload. It doubles for each following retry.
+	UploadRetryBackoff time.Duration
+
+	// noRollbackPrompt is set when pushing apps in parallel, where asking
+	// whether to roll back a failed app would mix with the other apps.
+	noRollbackPrompt bool
+
+	// orgDomains are the domains of the targeted org, listed once per push
+	// when the manifest declares routes, to split these routes against.
+	orgDomains []models.DomainFields
 }
 
 func init() {
@@ -59,15 +93,16 @@ func (cmd *Push) MetaData() command_registry.CommandMetadata {
 	fs["b"] = &cliFlags.StringFlag{ShortName: "b", Usage: T("Custom buildpack by name (e.g. my-buildpack) or Git URL (e.g. 'https://github.com/cloudfoundry/java-buildpack.git') or Git URL with a branch or tag (e.g. 'https://github.com/cloudfoundry/java-buildpack.git#v3.3.0' for 'v3.3.0' tag). To use built-in buildpacks only, specify 'default' or 'null'")}
 	fs["c"] = &cliFlags.StringFlag{ShortName: "c", Usage: T("Startup command, set to null to reset to default start command")}
 	fs["d"] = &cliFlags.StringFlag{ShortName: "d", Usage: T("Domain (e.g. example.com)")}
-	fs["f"] = &cliFlags.StringFlag{ShortName: "f", Usage: T("Path to manifest")}
+	fs["f"] = &cliFlags.StringSliceFlag{ShortName: "f", Usage: T("Path to manifest; can specify multiple times to merge each manifest over the previous ones")}
 	fs["i"] = &cliFlags.IntFlag{ShortName: "i", Usage: T("Number of instances")}
 	fs["k"] = &cliFlags.StringFlag{ShortName: "k", Usage: T("Disk limit (e.g. 256M, 1024M, 1G)")}
 	fs["m"] = &cliFlags.StringFlag{ShortName: "m", Usage: T("Memory limit (e.g. 256M, 1024M, 1G)")}
 	fs["hostname"] = &cliFlags.StringFlag{Name: "hostname", ShortName: "n", Usage: T("Hostname (e.g. my-subdomain)")}
-	fs["p"] = &cliFlags.StringFlag{ShortName: "p", Usage: T("Path to app directory or to a zip file of the contents of the app directory")}
+	fs["p"] = &cliFlags.StringFlag{ShortName: "p", Usage: T("Path to app directory or to a zip, jar, war, tar or tgz file of the contents of the app directory")}
 	fs["s"] = &cliFlags.StringFlag{ShortName: "s", Usage: T("Stack to use (a stack is a pre-built file system, including an operating system, that can run apps)")}
 	fs["t"] = &cliFlags.StringFlag{ShortName: "t", Usage: T("Maximum time (in seconds) for CLI to wait for application start, other server side timeouts may apply")}
 	fs["docker-image"] = &cliFlags.StringFlag{Name: "docker-image", ShortName: "o", Usage: T("docker-image to be used (e.g. user/docker-image-name)")}
+	fs["docker-username"] = &cliFlags.StringFlag{Name: "docker-username", Usage: T("Username of the private registry of the docker image; the password is read from the CF_DOCKER_PASSWORD environment variable")}
 	fs["health-check-type"] = &cliFlags.StringFlag{Name: "health-check-type", ShortName: "u", Usage: T("Application health check type (e.g. port or none)")}
 	fs["no-hostname"] = &cliFlags.BoolFlag{Name: "no-hostname", Usage: T("Map the root domain to this app")}
 	fs["no-manifest"] = &cliFlags.BoolFlag{Name: "no-manifest", Usage: T("Ignore manifest file")}
@@ -75,122 +110,1196 @@ func (cmd *Push) MetaData() command_registry.CommandMetadata {
 	fs["no-start"] = &cliFlags.BoolFlag{Name: "no-start", Usage: T("Do not start an app after pushing")}
 	fs["random-route"] = &cliFlags.BoolFlag{Name: "random-route", Usage: T("Create a random route for this app")}
 	fs["route-path"] = &cliFlags.StringFlag{Name: "route-path", Usage: T("Path for the route")}
+	fs["var"] = &cliFlags.StringSliceFlag{Name: "var", Usage: T("Variable key value pair for variable substitution in the manifest, (e.g. name=app1); can specify multiple times")}
+	fs["vars-file"] = &cliFlags.StringSliceFlag{Name: "vars-file", Usage: T("Path to a variable substitution file for the manifest; can specify multiple times")}
+	fs["parallel"] = &cliFlags.IntFlag{Name: "parallel", Usage: T("Number of apps from the manifest to push at the same time")}
+	fs["strategy"] = &cliFlags.StringFlag{Name: "strategy", Usage: T("Deployment strategy: 'blue-green' starts the new version under a temporary name and moves the routes once it is running")}
+	fs["rollback-on-failure"] = &cliFlags.BoolFlag{Name: "rollback-on-failure", Usage: T("Restore the previous settings, routes, service bindings and droplet of an existing app if it fails to stage or start")}
+	fs["dry-run"] = &cliFlags.BoolFlag{Name: "dry-run", Usage: T("Show the changes and actions the push would make, without making them")}
+	fs["reproducible"] = &cliFlags.BoolFlag{Name: "reproducible", Usage: T("Upload a zip that only depends on the app files: entries are sorted, and times and permissions are normalized")}
+	fs["upload-retries"] = &cliFlags.IntFlag{Name: "upload-retries", Usage: T("Number of times to retry uploading the app files after a network or server error, sending only the files the server does not have yet (Default: 2)")}
 
 	return command_registry.CommandMetadata{
 		Name:        "push",
 		ShortName:   "p",
 		Description: T("Push a new app or sync changes to an existing app"),
 		Usage: T("Push a single app (with or without a manifest):\n") + T("   CF_NAME push APP_NAME [-b BUILDPACK_NAME] [-c COMMAND] [-d DOMAIN] [-f MANIFEST_PATH] [--docker-image DOCKER_IMAGE]\n") + T("   [-i NUM_INSTANCES] [-k DISK] [-m MEMORY] [--hostname HOST] [-p PATH] [-s STACK] [-t TIMEOUT] [-u HEALTH_CHECK_TYPE] [--route-path ROUTE_PATH]\n") +
-			"   [--no-hostname] [--no-manifest] [--no-route] [--no-start]\n" +
-			"\n" + T("   Push multiple apps with a manifest:\n") + T("   CF_NAME push [-f MANIFEST_PATH]\n"),
+			"   [--no-hostname] [--no-manifest] [--no-route] [--no-start] [--strategy blue-green] [--dry-run]\n" +
+			"   [--docker-username USERNAME] [--rollback-on-failure] [--reproducible] [--upload-retries NUM_RETRIES]\n" +
+			"   [--var KEY=VALUE] [--vars-file VARS_FILE_PATH]\n" +
+			"\n" + T("   Push multiple apps with a manifest:\n") + T("   CF_NAME push [-f MANIFEST_PATH] [--parallel N]\n"),
 		Flags: fs,
 	}
 }
 
-func (cmd *Push) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) ([]requirements.Requirement, error) {
-	if len(fc.Args()) > 1 {
-		cmd.ui.Failed(T("Incorrect Usage.\n\n") + command_registry.Commands.CommandUsage("push"))
+func (cmd *Push) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) ([]requirements.Requirement, error) {
+	if len(fc.Args()) > 1 {
+		cmd.ui.Failed(T("Incorrect Usage.\n\n") + command_registry.Commands.CommandUsage("push"))
+	}
+
+	if strategy := fc.String("strategy"); strategy != "" {
+		if strategy != BlueGreenStrategy {
+			cmd.ui.Failed(T("Invalid strategy: {{.Strategy}}\nThe only supported strategy is '{{.BlueGreen}}'",
+				map[string]interface{}{"Strategy": strategy, "BlueGreen": BlueGreenStrategy}))
+		}
+
+		if fc.Bool("no-start") {
+			cmd.ui.Failed(T("Incorrect Usage. --strategy {{.BlueGreen}} cannot be used with --no-start.\n\n",
+				map[string]interface{}{"BlueGreen": BlueGreenStrategy}) + command_registry.Commands.CommandUsage("push"))
+		}
+
+		if fc.Bool("dry-run") {
+			cmd.ui.Failed(T("Incorrect Usage. --strategy {{.BlueGreen}} cannot be used with --dry-run.\n\n",
+				map[string]interface{}{"BlueGreen": BlueGreenStrategy}) + command_registry.Commands.CommandUsage("push"))
+		}
+	}
+
+	if fc.IsSet("parallel") && fc.Int("parallel") < 1 {
+		cmd.ui.Failed(T("Invalid value for --parallel: {{.Parallel}}\nThe number of apps to push at the same time must be a positive integer",
+			map[string]interface{}{"Parallel": fc.Int("parallel")}))
+	}
+
+	if fc.IsSet("upload-retries") && fc.Int("upload-retries") < 0 {
+		cmd.ui.Failed(T("Invalid value for --upload-retries: {{.Retries}}\nThe number of retries cannot be negative",
+			map[string]interface{}{"Retries": fc.Int("upload-retries")}))
+	}
+
+	var reqs []requirements.Requirement
+
+	if fc.String("route-path") != "" {
+		requiredVersion, err := semver.Make("2.36.0")
+		if err != nil {
+			panic(err.Error())
+		}
+
+		reqs = append(reqs, requirementsFactory.NewMinAPIVersionRequirement("Option '--route-path'", requiredVersion))
+	}
+
+	reqs = append(reqs, []requirements.Requirement{
+		requirementsFactory.NewLoginRequirement(),
+		requirementsFactory.NewTargetedSpaceRequirement(),
+	}...)
+
+	return reqs, nil
+}
+
+func (cmd *Push) SetDependency(deps command_registry.Dependency, pluginCall bool) command_registry.Command {
+	cmd.ui = deps.Ui
+	cmd.config = deps.Config
+	cmd.manifestRepo = deps.ManifestRepo
+
+	//set appStarter
+	appCommand := command_registry.Commands.FindCommand("start")
+	appCommand = appCommand.SetDependency(deps, false)
+	cmd.appStarter = appCommand.(ApplicationStarter)
+
+	//set appStopper
+	appCommand = command_registry.Commands.FindCommand("stop")
+	appCommand = appCommand.SetDependency(deps, false)
+	cmd.appStopper = appCommand.(ApplicationStopper)
+
+	//set serviceBinder
+	appCommand = command_registry.Commands.FindCommand("bind-service")
+	appCommand = appCommand.SetDependency(deps, false)
+	cmd.serviceBinder = appCommand.(service.ServiceBinder)
+
+	cmd.appRepo = deps.RepoLocator.GetApplicationRepository()
+	cmd.appSummaryRepo = deps.RepoLocator.GetAppSummaryRepository()
+	cmd.domainRepo = deps.RepoLocator.GetDomainRepository()
+	cmd.routeRepo = deps.RepoLocator.GetRouteRepository()
+	cmd.serviceRepo = deps.RepoLocator.GetServiceRepository()
+	cmd.bindingRepo = deps.RepoLocator.GetServiceBindingRepository()
+	cmd.stackRepo = deps.RepoLocator.GetStackRepository()
+	cmd.authRepo = deps.RepoLocator.GetAuthenticationRepository()
+	cmd.wordGenerator = deps.WordGenerator
+	cmd.actor = deps.PushActor
+	cmd.zipper = deps.AppZipper
+	cmd.appfiles = deps.AppFiles
+	cmd.appBitsRepo = deps.RepoLocator.GetApplicationBitsRepository()
+	cmd.shellRunner = deps.ShellRunner
+	cmd.UploadRetryBackoff = DefaultUploadRetryBackoff
+
+	return cmd
+}
+
+func (cmd *Push) Execute(c flags.FlagContext) {
+	appSet := cmd.findAndValidateAppsToPush(c)
+	_, apiErr := cmd.authRepo.RefreshAuthToken()
+	if apiErr != nil {
+		cmd.ui.Failed(apiErr.Error())
+		return
+	}
+
+	cmd.checkManifestRoutes(appSet)
+
+	if c.Bool("dry-run") {
+		for _, appParams := range appSet {
+			cmd.showPushPlan(appParams, c)
+		}
+		cmd.ui.Say(T("Dry run complete, nothing was changed."))
+		return
+	}
+
+	if parallel := c.Int("parallel"); parallel > 1 && len(appSet) > 1 {
+		cmd.pushInParallel(appSet, parallel, c)
+		return
+	}
+
+	routeActor := actors.NewRouteActor(cmd.ui, cmd.routeRepo)
+
+	for _, appParams := range appSet {
+		cmd.pushApp(routeActor, appParams, c)
+	}
+}
+
+func (cmd *Push) pushApp(routeActor actors.RouteActor, appParams models.AppParams, c flags.FlagContext) {
+	cmd.fetchStackGuid(&appParams)
+
+	if appParams.DockerImage != nil {
+		diego := true
+		appParams.Diego = &diego
+	}
+
+	existingApp, found := cmd.findExistingApp(appParams)
+	if !found {
+		existingApp.Name = *appParams.Name
+	}
+	cmd.runHooks(models.PrePushHook, appParams, existingApp)
+
+	if found && c.String("strategy") == BlueGreenStrategy {
+		cmd.blueGreenPush(routeActor, existingApp, appParams, c)
+		return
+	}
+
+	if !found {
+		app := cmd.createApp(appParams)
+		cmd.updateRoutes(routeActor, app, appParams)
+		cmd.uploadAndBindServices(app, appParams, c)
+		cmd.runHooks(models.PostPushHook, appParams, app)
+		cmd.restart(app, appParams, c)
+		cmd.runPostStartHooks(app, appParams, c)
+		return
+	}
+
+	rollback := cmd.prepareRollback(existingApp, c)
+	defer rollback.cleanup()
+
+	app := cmd.updateApp(existingApp, appParams)
+	cmd.updateRoutes(routeActor, app, appParams)
+	cmd.uploadAndBindServices(app, appParams, c)
+	cmd.runHooks(models.PostPushHook, appParams, app)
+	cmd.restartWithRollback(rollback, app, appParams, c)
+	cmd.runPostStartHooks(app, appParams, c)
+}
+
+func (cmd *Push) runPostStartHooks(app models.Application, appParams models.AppParams, c flags.FlagContext) {
+	if !c.Bool("no-start") {
+		cmd.runHooks(models.PostStartHook, appParams, app)
+	}
+}
+
+// runHooks runs the manifest commands of the given stage one after the other,
+// from the directory of the manifest. The app and the target are passed in
+// CF_* environment variables, and the push fails at the first command that
+// fails.
+func (cmd *Push) runHooks(stage string, appParams models.AppParams, app models.Application) {
+	commands := appParams.Hooks.Commands(stage)
+	if len(commands) == 0 {
+		return
+	}
+
+	env := []string{
+		"CF_HOOK=" + stage,
+		"CF_APP_NAME=" + app.Name,
+		"CF_APP_GUID=" + app.Guid,
+		"CF_APP_ROUTES=" + strings.Join(cmd.appRouteURLs(app), ","),
+		"CF_API=" + cmd.config.ApiEndpoint(),
+		"CF_ORG=" + cmd.config.OrganizationFields().Name,
+		"CF_SPACE=" + cmd.config.SpaceFields().Name,
+	}
+
+	for _, hook := range commands {
+		cmd.ui.Say(T("Running {{.Stage}} hook for app {{.AppName}}: {{.Command}}",
+			map[string]interface{}{
+				"Stage":   stage,
+				"AppName": terminal.EntityNameColor(app.Name),
+				"Command": terminal.CommandColor(hook.Command),
+			}))
+
+		output := &hookOutput{ui: cmd.ui}
+		err := cmd.shellRunner.Run(hook.Command, hook.Dir, env, output)
+		output.flush()
+		if err != nil {
+			cmd.ui.Failed(T("The {{.Stage}} hook '{{.Command}}' of app {{.AppName}} failed: {{.Error}}",
+				map[string]interface{}{
+					"Stage":   stage,
+					"Command": hook.Command,
+					"AppName": app.Name,
+					"Error":   err.Error(),
+				}))
+		}
+		cmd.ui.Ok()
+		cmd.ui.Say("")
+	}
+}
+
+// appRouteURLs returns the routes the app is mapped to, once it exists.
+func (cmd *Push) appRouteURLs(app models.Application) []string {
+	if app.Guid == "" {
+		return nil
+	}
+
+	summary, err := cmd.appSummaryRepo.GetSummary(app.Guid)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+	}
+
+	urls := []string{}
+	for _, route := range summary.Routes {
+		urls = append(urls, route.URL())
+	}
+	return urls
+}
+
+// hookOutput prints the output of a hook line by line, so it goes through the
+// UI like the rest of the push output.
+type hookOutput struct {
+	ui      terminal.UI
+	partial []byte
+}
+
+func (output *hookOutput) Write(p []byte) (int, error) {
+	output.partial = append(output.partial, p...)
+	for {
+		newline := bytes.IndexByte(output.partial, '\n')
+		if newline < 0 {
+			break
+		}
+		output.ui.Say("%s", strings.TrimSuffix(string(output.partial[:newline]), "\r"))
+		output.partial = output.partial[newline+1:]
+	}
+	return len(p), nil
+}
+
+func (output *hookOutput) flush() {
+	if len(output.partial) > 0 {
+		output.ui.Say("%s", string(output.partial))
+		output.partial = nil
+	}
+}
+
+type parallelPushResult struct {
+	appName  string
+	failed   bool
+	duration time.Duration
+}
+
+// pushInParallel pushes up to parallel apps at the same time. The output of
+// each app is collected and printed as one block once the app is done, with
+// every line prefixed by the app name.
+func (cmd *Push) pushInParallel(appSet []models.AppParams, parallel int, c flags.FlagContext) {
+	cmd.ui.Say(T("Pushing {{.Count}} apps, {{.Parallel}} at a time.\n",
+		map[string]interface{}{"Count": len(appSet), "Parallel": parallel}))
+
+	results := make([]parallelPushResult, len(appSet))
+	outputMutex := &sync.Mutex{}
+	slots := make(chan struct{}, parallel)
+	var wg sync.WaitGroup
+
+	for i, appParams := range appSet {
+		wg.Add(1)
+		go func(i int, appParams models.AppParams) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			results[i] = cmd.pushAppWithGroupedOutput(appParams, c, outputMutex)
+		}(i, appParams)
+	}
+	wg.Wait()
+
+	cmd.showParallelPushSummary(results)
+}
+
+func (cmd *Push) pushAppWithGroupedOutput(appParams models.AppParams, c flags.FlagContext, outputMutex *sync.Mutex) (result parallelPushResult) {
+	result.appName = *appParams.Name
+
+	printer := terminal.NewBufferedPrinter("[" + result.appName + "] ")
+	ui := terminal.NewUI(os.Stdin, printer)
+
+	appCmd := *cmd
+	appCmd.ui = ui
+	appCmd.appStarter = cmd.appStarter.WithUI(ui)
+	appCmd.appStopper = cmd.appStopper.WithUI(ui)
+	appCmd.actor = cmd.actor.WithUI(ui)
+	appCmd.appBitsRepo = cmd.appBitsRepo.WithUI(ui)
+	appCmd.noRollbackPrompt = true
+
+	startTime := time.Now()
+	defer func() {
+		result.duration = time.Since(startTime)
+
+		// an unexpected panic fails this app only, instead of the whole push
+		if err := recover(); err != nil {
+			result.failed = true
+			if err != terminal.QuietPanic {
+				ui.Say(terminal.FailureColor(T("FAILED")))
+				ui.Say(fmt.Sprint(err))
+			}
+		}
+
+		outputMutex.Lock()
+		for _, line := range printer.TakeLines(true) {
+			cmd.ui.Say("%s", line)
+		}
+		outputMutex.Unlock()
+	}()
+
+	appCmd.pushApp(actors.NewRouteActor(ui, cmd.routeRepo), appParams, c)
+	return
+}
+
+func (cmd *Push) showParallelPushSummary(results []parallelPushResult) {
+	cmd.ui.Say("")
+
+	failed := 0
+	table := cmd.ui.Table([]string{T("app"), T("status"), T("duration")})
+	for _, result := range results {
+		status := terminal.SuccessColor(T("pushed"))
+		if result.failed {
+			status = terminal.FailureColor(T("failed"))
+			failed++
+		}
+		table.Add(result.appName, status, (result.duration - result.duration%time.Second).String())
+	}
+	table.Print()
+
+	if failed > 0 {
+		cmd.ui.Say("")
+		cmd.ui.Failed(T("{{.Failed}} of {{.Total}} apps failed to push",
+			map[string]interface{}{"Failed": failed, "Total": len(results)}))
+	}
+}
+
+// pushPlan is what a push would do to an app: the settings that change and
+// the actions taken to get there.
+type pushPlan struct {
+	changes [][]string
+	actions []string
+}
+
+func (plan *pushPlan) addChange(property, current, planned string) {
+	if current != planned {
+		plan.changes = append(plan.changes, []string{property, current, planned})
+	}
+}
+
+func (plan *pushPlan) addAction(action string) {
+	plan.actions = append(plan.actions, action)
+}
+
+// showPushPlan prints the differences between the existing app and the one
+// described by appParams, along with the actions a push would take. It only
+// reads from the API.
+func (cmd *Push) showPushPlan(appParams models.AppParams, c flags.FlagContext) {
+	app, found := cmd.findExistingApp(appParams)
+	if found {
+		summary, apiErr := cmd.appSummaryRepo.GetSummary(app.Guid)
+		if apiErr != nil {
+			cmd.ui.Failed(apiErr.Error())
+			return
+		}
+		app = summary
+	} else {
+		app.Name = *appParams.Name
+	}
+
+	cmd.ui.Say(T("Planned changes for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.Username}} (dry run)...",
+		map[string]interface{}{
+			"AppName":   terminal.EntityNameColor(app.Name),
+			"OrgName":   terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+			"SpaceName": terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+			"Username":  terminal.EntityNameColor(cmd.config.Username())}))
+	cmd.ui.Say("")
+
+	plan := &pushPlan{}
+	planHooks(plan, models.PrePushHook, appParams)
+	cmd.planAppSettings(plan, app, found, appParams)
+	settingsChanged := len(plan.changes) > 0
+
+	if !found {
+		plan.addAction(T("create app {{.AppName}}", map[string]interface{}{"AppName": app.Name}))
+	} else if settingsChanged {
+		plan.addAction(T("update app {{.AppName}}", map[string]interface{}{"AppName": app.Name}))
+	}
+
+	cmd.planRoutes(plan, app, appParams)
+
+	if appParams.DockerImage == nil && appParams.Path != nil {
+		plan.addAction(T("upload app files from {{.Path}}", map[string]interface{}{"Path": *appParams.Path}))
+	}
+
+	cmd.planServices(plan, app, appParams)
+	planHooks(plan, models.PostPushHook, appParams)
+
+	switch {
+	case c.Bool("no-start"):
+		if found && app.State != "stopped" {
+			plan.addAction(T("stop app {{.AppName}}", map[string]interface{}{"AppName": app.Name}))
+		}
+	case found && app.State != "stopped":
+		plan.addAction(T("restart app {{.AppName}}", map[string]interface{}{"AppName": app.Name}))
+	default:
+		plan.addAction(T("start app {{.AppName}}", map[string]interface{}{"AppName": app.Name}))
+	}
+
+	if !c.Bool("no-start") {
+		planHooks(plan, models.PostStartHook, appParams)
+	}
+
+	if len(plan.changes) == 0 {
+		cmd.ui.Say(T("No changes to the app settings"))
+	} else {
+		table := cmd.ui.Table([]string{T("property"), T("current"), T("after push")})
+		for _, change := range plan.changes {
+			table.Add(change...)
+		}
+		table.Print()
+	}
+
+	cmd.ui.Say("")
+	cmd.ui.Say(T("Actions:"))
+	for i, action := range plan.actions {
+		cmd.ui.Say("  %d. %s", i+1, action)
+	}
+	cmd.ui.Say("")
+}
+
+func planHooks(plan *pushPlan, stage string, appParams models.AppParams) {
+	for _, hook := range appParams.Hooks.Commands(stage) {
+		plan.addAction(T("run {{.Stage}} hook: {{.Command}}", map[string]interface{}{"Stage": stage, "Command": hook.Command}))
+	}
+}
+
+func (cmd *Push) planAppSettings(plan *pushPlan, app models.Application, found bool, appParams models.AppParams) {
+	current := func(value string) string {
+		if !found {
+			return ""
+		}
+		return value
+	}
+
+	if appParams.Memory != nil {
+		plan.addChange(T("memory"),
+			current(formatters.ByteSize(app.Memory*formatters.MEGABYTE)),
+			formatters.ByteSize(*appParams.Memory*formatters.MEGABYTE))
+	}
+
+	if appParams.InstanceCount != nil {
+		plan.addChange(T("instances"), current(strconv.Itoa(app.InstanceCount)), strconv.Itoa(*appParams.InstanceCount))
+	}
+
+	if appParams.DiskQuota != nil {
+		plan.addChange(T("disk"),
+			current(formatters.ByteSize(app.DiskQuota*formatters.MEGABYTE)),
+			formatters.ByteSize(*appParams.DiskQuota*formatters.MEGABYTE))
+	}
+
+	if appParams.Command != nil {
+		plan.addChange(T("command"), current(valueOrDefault(app.Command)), valueOrDefault(*appParams.Command))
+	}
+
+	if appParams.BuildpackUrl != nil {
+		plan.addChange(T("buildpack"), current(valueOrDefault(app.BuildpackUrl)), valueOrDefault(*appParams.BuildpackUrl))
+	}
+
+	if appParams.EnvironmentVars != nil {
+		keys := []string{}
+		for key := range *appParams.EnvironmentVars {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			currentValue := ""
+			if value, ok := app.EnvironmentVars[key]; ok {
+				currentValue = fmt.Sprintf("%v", value)
+			}
+			plan.addChange(T("env {{.Name}}", map[string]interface{}{"Name": key}),
+				currentValue, fmt.Sprintf("%v", (*appParams.EnvironmentVars)[key]))
+		}
+	}
+}
+
+func valueOrDefault(value string) string {
+	if value == "" {
+		return T("default")
+	}
+	return value
+}
+
+// planRoutes follows the same rules as updateRoutes, looking routes up
+// instead of creating and binding them.
+func (cmd *Push) planRoutes(plan *pushPlan, app models.Application, appParams models.AppParams) {
+	currentURLs := []string{}
+	for _, route := range app.Routes {
+		currentURLs = append(currentURLs, route.URL())
+	}
+
+	if appParams.NoRoute {
+		for _, url := range currentURLs {
+			plan.addAction(T("unmap route {{.URL}}", map[string]interface{}{"URL": url}))
+		}
+		plan.addChange(T("routes"), strings.Join(currentURLs, ", "), "")
+		return
+	}
+
+	var routes []manifestRoute
+	routeDefined := appParams.Domains != nil || !appParams.IsHostEmpty() || appParams.NoHostname
+
+	if appParams.Routes != nil {
+		var unresolved []string
+		routes, unresolved = cmd.resolveRoutes(*appParams.Routes)
+		cmd.failOnUnresolvedRoutes(unresolved)
+	} else if routeDefined || len(app.Routes) == 0 {
+		var domains []models.DomainFields
+		if appParams.Domains == nil {
+			domains = append(domains, cmd.findDomain(nil))
+		} else {
+			for _, d := range *appParams.Domains {
+				domains = append(domains, cmd.findDomain(&d))
+			}
+		}
+
+		path := ""
+		if appParams.RoutePath != nil {
+			path = *appParams.RoutePath
+		}
+
+		for _, domain := range domains {
+			if appParams.IsHostEmpty() {
+				routes = append(routes, manifestRoute{host: cmd.plannedHostname(nil, app.Name, appParams), domain: domain, path: path})
+				continue
+			}
+			for _, host := range *appParams.Hosts {
+				routes = append(routes, manifestRoute{host: cmd.plannedHostname(&host, app.Name, appParams), domain: domain, path: path})
+			}
+		}
+	}
+
+	plannedURLs := append([]string{}, currentURLs...)
+	for _, plannedRoute := range routes {
+		url := plannedRoute.domain.UrlForHostAndPath(plannedRoute.host, plannedRoute.path)
+
+		route, apiErr := cmd.routeRepo.Find(plannedRoute.host, plannedRoute.domain, plannedRoute.path)
+		switch apiErr.(type) {
+		case nil:
+			if app.HasRoute(route) {
+				continue
+			}
+		case *errors.ModelNotFoundError:
+			plan.addAction(T("create route {{.URL}}", map[string]interface{}{"URL": url}))
+		default:
+			cmd.ui.Failed(apiErr.Error())
+		}
+
+		plan.addAction(T("map route {{.URL}}", map[string]interface{}{"URL": url}))
+		plannedURLs = append(plannedURLs, url)
+	}
+
+	plan.addChange(T("routes"), strings.Join(currentURLs, ", "), strings.Join(plannedURLs, ", "))
+}
+
+// plannedHostname is hostnameForApp without picking the random words of a
+// random route, which would differ from the ones of the actual push.
+func (cmd *Push) plannedHostname(host *string, appName string, appParams models.AppParams) string {
+	if host == nil && appParams.UseRandomHostname && !appParams.NoHostname {
+		return hostNameForString(appName) + "-" + T("RANDOM")
+	}
+	return cmd.hostnameForApp(host, false, appName, appParams.NoHostname)
+}
+
+func (cmd *Push) planServices(plan *pushPlan, app models.Application, appParams models.AppParams) {
+	if appParams.ServicesToBind == nil {
+		return
+	}
+
+	bound := map[string]bool{}
+	currentServices := []string{}
+	for _, service := range app.Services {
+		bound[service.Name] = true
+		currentServices = append(currentServices, service.Name)
+	}
+
+	plannedServices := append([]string{}, currentServices...)
+	for _, serviceName := range *appParams.ServicesToBind {
+		if bound[serviceName] {
+			continue
+		}
+		bound[serviceName] = true
+		plannedServices = append(plannedServices, serviceName)
+
+		if _, err := cmd.serviceRepo.FindInstanceByName(serviceName); err != nil {
+			plan.addAction(T("bind service {{.ServiceName}} (the service could not be found, the push would fail)",
+				map[string]interface{}{"ServiceName": serviceName}))
+			continue
+		}
+		plan.addAction(T("bind service {{.ServiceName}}", map[string]interface{}{"ServiceName": serviceName}))
+	}
+
+	plan.addChange(T("services"), strings.Join(currentServices, ", "), strings.Join(plannedServices, ", "))
+}
+
+func (cmd *Push) uploadAndBindServices(app models.Application, appParams models.AppParams, c flags.FlagContext) {
+	if appParams.DockerImage == nil {
+		options := uploadOptions{reproducible: c.Bool("reproducible"), retries: DefaultUploadRetries}
+		if c.IsSet("upload-retries") {
+			options.retries = c.Int("upload-retries")
+		}
+
+		err := cmd.actor.ProcessPath(*appParams.Path, cmd.processPathCallback(*appParams.Path, app, options))
+		if err != nil {
+			cmd.ui.Failed(
+				T("Error processing app files: {{.Error}}",
+					map[string]interface{}{
+						"Error": err.Error(),
+					}),
+			)
+			return
+		}
+	}
+
+	if appParams.ServicesToBind != nil {
+		cmd.bindAppToServices(*appParams.ServicesToBind, app)
+	}
+}
+
+func (cmd *Push) findExistingApp(appParams models.AppParams) (models.Application, bool) {
+	if appParams.Name == nil {
+		cmd.ui.Failed(T("Error: No name found for app"))
+	}
+
+	app, apiErr := cmd.appRepo.Read(*appParams.Name)
+
+	switch apiErr.(type) {
+	case nil:
+		return app, true
+	case *errors.ModelNotFoundError:
+		return models.Application{}, false
+	default:
+		cmd.ui.Failed(apiErr.Error())
+	}
+
+	return models.Application{}, false
+}
+
+func (cmd *Push) blueGreenPush(routeActor actors.RouteActor, oldApp models.Application, appParams models.AppParams, c flags.FlagContext) {
+	summary, apiErr := cmd.appSummaryRepo.GetSummary(oldApp.Guid)
+	if apiErr != nil {
+		cmd.ui.Failed(apiErr.Error())
+		return
+	}
+
+	newAppParams := blueGreenAppParams(summary, appParams)
+	asideName := oldApp.Name + BlueGreenOldAppSuffix
+	cmd.checkAppNameIsFree(*newAppParams.Name)
+	cmd.checkAppNameIsFree(asideName)
+
+	cmd.ui.Say(T("Pushing new version of {{.AppName}} as {{.TempAppName}}...",
+		map[string]interface{}{
+			"AppName":     terminal.EntityNameColor(oldApp.Name),
+			"TempAppName": terminal.EntityNameColor(*newAppParams.Name),
+		}))
+	cmd.ui.Say("")
+
+	newApp := cmd.createApp(newAppParams)
+
+	cmd.startWithRollback(newApp, newAppParams, oldApp, c)
+
+	if !appParams.NoRoute {
+		cmd.moveRoutes(routeActor, summary, newApp)
+	}
+
+	// the old app is only deleted once the new one has taken its name, so
+	// that the app is never missing if a step fails on the way
+	oldApp, apiErr = cmd.tryRenameApp(oldApp, asideName)
+	if apiErr != nil {
+		cmd.abortBlueGreen(summary, newApp, !appParams.NoRoute, apiErr)
+		return
+	}
+
+	newApp, apiErr = cmd.tryRenameApp(newApp, summary.Name)
+	if apiErr != nil {
+		_, renameErr := cmd.tryRenameApp(oldApp, summary.Name)
+		if renameErr != nil {
+			cmd.ui.Warn(T("Could not rename app {{.AppName}} back to {{.NewName}}: {{.Err}}",
+				map[string]interface{}{"AppName": oldApp.Name, "NewName": summary.Name, "Err": renameErr.Error()}))
+		}
+		cmd.abortBlueGreen(summary, newApp, !appParams.NoRoute, apiErr)
+		return
+	}
+
+	cmd.ui.Say(T("Deleting old version of app {{.AppName}}...",
+		map[string]interface{}{"AppName": terminal.EntityNameColor(oldApp.Name)}))
+	apiErr = cmd.appRepo.Delete(oldApp.Guid)
+	if apiErr != nil {
+		cmd.ui.Failed(apiErr.Error())
+		return
+	}
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+
+	if !appParams.NoRoute && (appParams.Domains != nil || !appParams.IsHostEmpty() || appParams.NoHostname || appParams.Routes != nil) {
+		newApp.Routes = summary.Routes
+		cmd.updateRoutes(routeActor, newApp, appParams)
+	}
+}
+
+// checkAppNameIsFree fails when an app named name already exists, as a
+// blue-green push needs the name for one of its apps.
+func (cmd *Push) checkAppNameIsFree(name string) {
+	_, apiErr := cmd.appRepo.Read(name)
+	switch apiErr.(type) {
+	case nil:
+		cmd.ui.Failed(T("App {{.AppName}} already exists. Rename or delete it before pushing with the {{.BlueGreen}} strategy.",
+			map[string]interface{}{"AppName": name, "BlueGreen": BlueGreenStrategy}))
+	case *errors.ModelNotFoundError:
+	default:
+		cmd.ui.Failed(apiErr.Error())
+	}
+}
+
+// tryRenameApp renames app, returning the error instead of failing so that
+// the caller can undo the previous steps first.
+func (cmd *Push) tryRenameApp(app models.Application, name string) (models.Application, error) {
+	cmd.ui.Say(T("Renaming app {{.AppName}} to {{.NewName}}...",
+		map[string]interface{}{
+			"AppName": terminal.EntityNameColor(app.Name),
+			"NewName": terminal.EntityNameColor(name),
+		}))
+
+	renamedApp, apiErr := cmd.appRepo.Update(app.Guid, models.AppParams{Name: &name})
+	if apiErr != nil {
+		return app, apiErr
+	}
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+	return renamedApp, nil
+}
+
+// startWithRollback uploads, binds and starts the temporary app of a blue-green
+// push, running the post-push and post-start hooks against it. If any of these
+// steps fail the temporary app is deleted again, leaving the old app and its
+// routes untouched.
+func (cmd *Push) startWithRollback(newApp models.Application, newAppParams models.AppParams, oldApp models.Application, c flags.FlagContext) {
+	defer func() {
+		if failure := recover(); failure != nil {
+			cmd.rollbackBlueGreen(newApp, oldApp)
+			panic(failure)
+		}
+	}()
+
+	cmd.uploadAndBindServices(newApp, newAppParams, c)
+	cmd.runHooks(models.PostPushHook, newAppParams, newApp)
+
+	if newAppParams.HealthCheckTimeout != nil {
+		cmd.appStarter.SetStartTimeoutInSeconds(*newAppParams.HealthCheckTimeout)
+	}
+
+	_, err := cmd.appStarter.ApplicationStart(newApp, cmd.config.OrganizationFields().Name, cmd.config.SpaceFields().Name)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+	}
+
+	cmd.runHooks(models.PostStartHook, newAppParams, newApp)
+}
+
+func (cmd *Push) rollbackBlueGreen(newApp models.Application, oldApp models.Application) {
+	cmd.ui.Say("")
+	cmd.ui.Say(T("Rolling back: deleting {{.TempAppName}}, {{.AppName}} keeps its routes...",
+		map[string]interface{}{
+			"TempAppName": terminal.EntityNameColor(newApp.Name),
+			"AppName":     terminal.EntityNameColor(oldApp.Name),
+		}))
+
+	apiErr := cmd.appRepo.Delete(newApp.Guid)
+	if apiErr != nil {
+		cmd.ui.Warn(T("Could not delete app {{.AppName}}: {{.Err}}",
+			map[string]interface{}{"AppName": newApp.Name, "Err": apiErr.Error()}))
+		return
+	}
+	cmd.ui.Ok()
+}
+
+// abortBlueGreen undoes a blue-green push that failed once the routes were
+// moved: the routes go back to the old app and the temporary app is deleted,
+// before the push fails with err.
+func (cmd *Push) abortBlueGreen(oldApp models.Application, newApp models.Application, routesMoved bool, err error) {
+	if routesMoved {
+		cmd.restoreRoutes(oldApp, newApp)
+	}
+	cmd.rollbackBlueGreen(newApp, oldApp)
+	cmd.ui.Failed(err.Error())
+}
+
+// restoreRoutes binds the routes of oldApp back to it and unbinds them from
+// newApp. It carries on past errors so that as many routes as possible are
+// restored.
+func (cmd *Push) restoreRoutes(oldApp models.Application, newApp models.Application) {
+	for _, route := range oldApp.Routes {
+		cmd.ui.Say(T("Mapping route {{.URL}} to {{.AppName}}...",
+			map[string]interface{}{"URL": terminal.EntityNameColor(route.URL()), "AppName": terminal.EntityNameColor(oldApp.Name)}))
+
+		apiErr := cmd.routeRepo.Bind(route.Guid, oldApp.Guid)
+		if apiErr != nil {
+			cmd.ui.Warn(T("Could not map route {{.URL}} to {{.AppName}}: {{.Err}}",
+				map[string]interface{}{"URL": route.URL(), "AppName": oldApp.Name, "Err": apiErr.Error()}))
+			continue
+		}
+		cmd.ui.Ok()
+
+		apiErr = cmd.routeRepo.Unbind(route.Guid, newApp.Guid)
+		if apiErr != nil {
+			cmd.ui.Warn(T("Could not unmap route {{.URL}} from {{.AppName}}: {{.Err}}",
+				map[string]interface{}{"URL": route.URL(), "AppName": newApp.Name, "Err": apiErr.Error()}))
+		}
+	}
+}
+
+// pushRollback is what is needed to put an existing app back the way it was
+// before a push that failed. summary holds the routes and services of the app,
+// unless hasSummary is false as they could not be read.
+type pushRollback struct {
+	app         models.Application
+	automatic   bool
+	dropletPath string
+	summary     models.Application
+	hasSummary  bool
+}
+
+// prepareRollback remembers the settings, routes and services of the app
+// being updated and, with --rollback-on-failure, saves its current droplet so
+// it can be restored.
+func (cmd *Push) prepareRollback(app models.Application, c flags.FlagContext) *pushRollback {
+	rollback := &pushRollback{app: app, automatic: c.Bool("rollback-on-failure")}
+
+	summary, err := cmd.appSummaryRepo.GetSummary(app.Guid)
+	if err != nil {
+		cmd.ui.Warn(T("Could not read the routes and services of app {{.AppName}}, a rollback will not restore them: {{.Err}}",
+			map[string]interface{}{"AppName": app.Name, "Err": err.Error()}))
+	} else {
+		rollback.summary = summary
+		rollback.hasSummary = true
+	}
+
+	if !rollback.automatic || app.PackageState != "STAGED" || app.DockerImage != "" {
+		return rollback
+	}
+
+	cmd.ui.Say(T("Saving the current droplet of app {{.AppName}} for a rollback...",
+		map[string]interface{}{"AppName": terminal.EntityNameColor(app.Name)}))
+
+	dropletFile, err := ioutil.TempFile("", "droplet")
+	if err == nil {
+		err = cmd.appBitsRepo.DownloadDroplet(app.Guid, dropletFile)
+		dropletFile.Close()
+		if err != nil {
+			os.Remove(dropletFile.Name())
+		}
+	}
+
+	if err != nil {
+		cmd.ui.Warn(T("Could not save the droplet, a rollback will only restore the settings, routes and service bindings of the app: {{.Err}}",
+			map[string]interface{}{"Err": err.Error()}))
+		cmd.ui.Say("")
+		return rollback
+	}
+
+	rollback.dropletPath = dropletFile.Name()
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+	return rollback
+}
+
+func (rollback *pushRollback) cleanup() {
+	if rollback.dropletPath != "" {
+		os.Remove(rollback.dropletPath)
+	}
+}
+
+// restartWithRollback restarts the updated app. If it fails to stage or start
+// the app is restored, either right away with --rollback-on-failure or, for
+// all but its droplet, once the user agrees to it. The push still fails
+// afterwards.
+func (cmd *Push) restartWithRollback(rollback *pushRollback, app models.Application, appParams models.AppParams, c flags.FlagContext) {
+	defer cmd.rollbackOnFailure(rollback)
+	cmd.restart(app, appParams, c)
+}
+
+func (cmd *Push) rollbackOnFailure(rollback *pushRollback) {
+	failure := recover()
+	if failure == nil {
+		return
+	}
+
+	if rollback.automatic || (!cmd.noRollbackPrompt && cmd.ui.Confirm(
+		T("Restore the previous settings, routes and service bindings of app {{.AppName}}? Its droplet was not saved, so the app is left stopped.", map[string]interface{}{"AppName": rollback.app.Name}))) {
+		cmd.rollbackApp(rollback)
+	}
+
+	panic(failure)
+}
+
+func (cmd *Push) rollbackApp(rollback *pushRollback) {
+	app := rollback.app
+
+	cmd.ui.Say("")
+	cmd.ui.Say(T("Rolling back app {{.AppName}} to its previous settings...",
+		map[string]interface{}{"AppName": terminal.EntityNameColor(app.Name)}))
+
+	restoredApp, apiErr := cmd.appRepo.Update(app.Guid, rollbackAppParams(app))
+	if apiErr != nil {
+		cmd.ui.Warn(T("Could not restore app {{.AppName}}: {{.Err}}",
+			map[string]interface{}{"AppName": app.Name, "Err": apiErr.Error()}))
+		return
 	}
+	cmd.ui.Ok()
 
-	var reqs []requirements.Requirement
+	cmd.restoreBindings(rollback)
 
-	if fc.String("route-path") != "" {
-		requiredVersion, err := semver.Make("2.36.0")
-		if err != nil {
-			panic(err.Error())
+	if rollback.dropletPath == "" {
+		if app.State != "stopped" {
+			cmd.ui.Warn(T("The previous droplet was not saved, so app {{.AppName}} is left stopped. Use '--rollback-on-failure' to also restore the droplet.",
+				map[string]interface{}{"AppName": app.Name}))
 		}
-
-		reqs = append(reqs, requirementsFactory.NewMinAPIVersionRequirement("Option '--route-path'", requiredVersion))
+		return
 	}
 
-	reqs = append(reqs, []requirements.Requirement{
-		requirementsFactory.NewLoginRequirement(),
-		requirementsFactory.NewTargetedSpaceRequirement(),
-	}...)
+	cmd.ui.Say(T("Restoring the previous droplet of app {{.AppName}}...",
+		map[string]interface{}{"AppName": terminal.EntityNameColor(app.Name)}))
 
-	return reqs, nil
+	apiErr = cmd.appBitsRepo.UploadDroplet(app.Guid, rollback.dropletPath)
+	if apiErr != nil {
+		cmd.ui.Warn(T("Could not restore the droplet of app {{.AppName}}, it is left stopped: {{.Err}}",
+			map[string]interface{}{"AppName": app.Name, "Err": apiErr.Error()}))
+		return
+	}
+	cmd.ui.Ok()
+
+	if app.State != "stopped" {
+		cmd.startAfterRollback(restoredApp)
+	}
 }
 
-func (cmd *Push) SetDependency(deps command_registry.Dependency, pluginCall bool) command_registry.Command {
-	cmd.ui = deps.Ui
-	cmd.config = deps.Config
-	cmd.manifestRepo = deps.ManifestRepo
+// restoreBindings unmaps the routes and unbinds the services that the push
+// added to the app. A push only ever adds them, so the routes and services
+// the app had before are still there.
+func (cmd *Push) restoreBindings(rollback *pushRollback) {
+	if !rollback.hasSummary {
+		return
+	}
 
-	//set appStarter
-	appCommand := command_registry.Commands.FindCommand("start")
-	appCommand = appCommand.SetDependency(deps, false)
-	cmd.appStarter = appCommand.(ApplicationStarter)
+	app := rollback.app
+	summary, apiErr := cmd.appSummaryRepo.GetSummary(app.Guid)
+	if apiErr != nil {
+		cmd.ui.Warn(T("Could not restore the routes and service bindings of app {{.AppName}}: {{.Err}}",
+			map[string]interface{}{"AppName": app.Name, "Err": apiErr.Error()}))
+		return
+	}
 
-	//set appStopper
-	appCommand = command_registry.Commands.FindCommand("stop")
-	appCommand = appCommand.SetDependency(deps, false)
-	cmd.appStopper = appCommand.(ApplicationStopper)
+	for _, route := range summary.Routes {
+		if hasRoute(rollback.summary.Routes, route) {
+			continue
+		}
 
-	//set serviceBinder
-	appCommand = command_registry.Commands.FindCommand("bind-service")
-	appCommand = appCommand.SetDependency(deps, false)
-	cmd.serviceBinder = appCommand.(service.ServiceBinder)
+		cmd.ui.Say(T("Unmapping route {{.URL}} from {{.AppName}}...",
+			map[string]interface{}{"URL": terminal.EntityNameColor(route.URL()), "AppName": terminal.EntityNameColor(app.Name)}))
+		apiErr = cmd.routeRepo.Unbind(route.Guid, app.Guid)
+		if apiErr != nil {
+			cmd.ui.Warn(T("Could not unmap route {{.URL}} from {{.AppName}}: {{.Err}}",
+				map[string]interface{}{"URL": route.URL(), "AppName": app.Name, "Err": apiErr.Error()}))
+			continue
+		}
+		cmd.ui.Ok()
+	}
 
-	cmd.appRepo = deps.RepoLocator.GetApplicationRepository()
-	cmd.domainRepo = deps.RepoLocator.GetDomainRepository()
-	cmd.routeRepo = deps.RepoLocator.GetRouteRepository()
-	cmd.serviceRepo = deps.RepoLocator.GetServiceRepository()
-	cmd.stackRepo = deps.RepoLocator.GetStackRepository()
-	cmd.authRepo = deps.RepoLocator.GetAuthenticationRepository()
-	cmd.wordGenerator = deps.WordGenerator
-	cmd.actor = deps.PushActor
-	cmd.zipper = deps.AppZipper
-	cmd.appfiles = deps.AppFiles
+	for _, service := range summary.Services {
+		if hasService(rollback.summary.Services, service) {
+			continue
+		}
 
-	return cmd
+		cmd.ui.Say(T("Unbinding service {{.ServiceName}} from {{.AppName}}...",
+			map[string]interface{}{"ServiceName": terminal.EntityNameColor(service.Name), "AppName": terminal.EntityNameColor(app.Name)}))
+		instance, apiErr := cmd.serviceRepo.FindInstanceByName(service.Name)
+		if apiErr == nil {
+			_, apiErr = cmd.bindingRepo.Delete(instance, app.Guid)
+		}
+		if apiErr != nil {
+			cmd.ui.Warn(T("Could not unbind service {{.ServiceName}} from {{.AppName}}: {{.Err}}",
+				map[string]interface{}{"ServiceName": service.Name, "AppName": app.Name, "Err": apiErr.Error()}))
+			continue
+		}
+		cmd.ui.Ok()
+	}
 }
 
-func (cmd *Push) Execute(c flags.FlagContext) {
-	appSet := cmd.findAndValidateAppsToPush(c)
-	_, apiErr := cmd.authRepo.RefreshAuthToken()
-	if apiErr != nil {
-		cmd.ui.Failed(apiErr.Error())
-		return
+func hasRoute(routes []models.RouteSummary, route models.RouteSummary) bool {
+	for _, r := range routes {
+		if r.Guid == route.Guid {
+			return true
+		}
 	}
+	return false
+}
 
-	routeActor := actors.NewRouteActor(cmd.ui, cmd.routeRepo)
+func hasService(services []models.ServicePlanSummary, service models.ServicePlanSummary) bool {
+	for _, s := range services {
+		if s.Name == service.Name {
+			return true
+		}
+	}
+	return false
+}
 
-	for _, appParams := range appSet {
-		cmd.fetchStackGuid(&appParams)
+// startAfterRollback starts the restored app, warning instead of failing as
+// the push has already failed.
+func (cmd *Push) startAfterRollback(app models.Application) {
+	defer func() {
+		if failure := recover(); failure != nil {
+			cmd.ui.Warn(T("The restored app {{.AppName}} could not be started", map[string]interface{}{"AppName": app.Name}))
+		}
+	}()
+
+	cmd.ui.Say("")
+	cmd.appStarter.ApplicationStart(app, cmd.config.OrganizationFields().Name, cmd.config.SpaceFields().Name)
+}
 
-		if c.IsSet("docker-image") {
-			diego := true
-			appParams.Diego = &diego
+// rollbackAppParams are the settings of app as they were before the push.
+// The app is stopped, it is started again once its droplet is restored.
+func rollbackAppParams(app models.Application) models.AppParams {
+	params := app.ToParams()
+	params.Guid = nil
+	params.Name = nil
+	params.SpaceGuid = nil
+
+	state := "STOPPED"
+	params.State = &state
+
+	if app.EnvironmentVars == nil {
+		params.EnvironmentVars = &map[string]interface{}{}
+	}
+	if app.HealthCheckType == "" {
+		params.HealthCheckType = nil
+	}
+	if app.DockerImage == "" {
+		params.DockerImage = nil
+	}
+	return params
+}
+
+// moveRoutes binds every route of the old app to the new app before unbinding
+// them from the old one, so that each route always has a running app behind it.
+// If a route cannot be bound, the new app is deleted again along with the
+// routes already bound to it.
+func (cmd *Push) moveRoutes(routeActor actors.RouteActor, oldApp models.Application, newApp models.Application) {
+	for i, route := range oldApp.Routes {
+		cmd.ui.Say(T("Mapping route {{.URL}} to {{.AppName}}...",
+			map[string]interface{}{"URL": terminal.EntityNameColor(route.URL()), "AppName": terminal.EntityNameColor(newApp.Name)}))
+
+		apiErr := cmd.routeRepo.Bind(route.Guid, newApp.Guid)
+		if apiErr != nil {
+			for _, boundRoute := range oldApp.Routes[:i] {
+				cmd.routeRepo.Unbind(boundRoute.Guid, newApp.Guid)
+			}
+			cmd.rollbackBlueGreen(newApp, oldApp)
+			cmd.ui.Failed(apiErr.Error())
+			return
 		}
+		cmd.ui.Ok()
+	}
 
-		app := cmd.createOrUpdateApp(appParams)
+	routeActor.UnbindAll(oldApp)
+	cmd.ui.Say("")
+}
 
-		cmd.updateRoutes(routeActor, app, appParams)
+func blueGreenAppParams(oldApp models.Application, appParams models.AppParams) models.AppParams {
+	params := oldApp.ToParams()
+	params.Guid = nil
+	params.State = nil
+	params.SpaceGuid = nil
+	if oldApp.HealthCheckType == "" {
+		params.HealthCheckType = nil
+	}
+	if oldApp.DockerImage == "" {
+		params.DockerImage = nil
+	}
+	if oldApp.HealthCheckTimeout != 0 {
+		params.HealthCheckTimeout = &oldApp.HealthCheckTimeout
+	}
+	if oldApp.Diego {
+		params.Diego = &oldApp.Diego
+	}
 
-		if c.String("docker-image") == "" {
-			err := cmd.actor.ProcessPath(*appParams.Path, cmd.processPathCallback(*appParams.Path, app))
-			if err != nil {
-				cmd.ui.Failed(
-					T("Error processing app files: {{.Error}}",
-						map[string]interface{}{
-							"Error": err.Error(),
-						}),
-				)
-				return
+	services := []string{}
+	seen := map[string]bool{}
+	for _, service := range oldApp.Services {
+		services = append(services, service.Name)
+		seen[service.Name] = true
+	}
+	if appParams.ServicesToBind != nil {
+		for _, service := range *appParams.ServicesToBind {
+			if !seen[service] {
+				services = append(services, service)
 			}
 		}
+	}
 
-		if appParams.ServicesToBind != nil {
-			cmd.bindAppToServices(*appParams.ServicesToBind, app)
+	if appParams.EnvironmentVars != nil {
+		envVars := map[string]interface{}{}
+		for key, val := range oldApp.EnvironmentVars {
+			envVars[key] = val
+		}
+		for key, val := range *appParams.EnvironmentVars {
+			envVars[key] = val
 		}
+		appParams.EnvironmentVars = &envVars
+	}
 
-		cmd.restart(app, appParams, c)
+	params.Merge(&appParams)
+	if appParams.Diego != nil {
+		params.Diego = appParams.Diego
 	}
+	params.ServicesToBind = &services
+	params.Domains = nil
+	params.Hosts = nil
+	params.RoutePath = nil
+	params.Routes = nil
+	params.NoRoute = true
+	params.NoHostname = false
+	params.UseRandomHostname = false
+
+	tempName := *appParams.Name + BlueGreenTempAppSuffix
+	params.Name = &tempName
+
+	return params
+}
+
+// uploadOptions are the push flags that change how the app files are uploaded.
+type uploadOptions struct {
+	reproducible bool
+	retries      int
 }
 
-func (cmd *Push) processPathCallback(path string, app models.Application) func(string) {
+func (cmd *Push) processPathCallback(path string, app models.Application, options uploadOptions) func(string) {
+	// archives are extracted to a new temporary directory on every push, so
+	// caching the hashes of their files would never pay off
+	listAppFiles := cmd.appfiles.AppFilesInDir
+	if cmd.zipper.IsZipFile(path) || cmd.zipper.IsTarFile(path) {
+		listAppFiles = cmd.appfiles.AppFilesInArchiveDir
+	}
+
 	return func(appDir string) {
-		localFiles, err := cmd.appfiles.AppFilesInDir(appDir)
+		localFiles, err := listAppFiles(appDir)
 		if err != nil {
 			cmd.ui.Failed(
 				T("Error processing app files in '{{.Path}}': {{.Error}}",
@@ -213,7 +1322,7 @@ func (cmd *Push) processPathCallback(path string, app models.Application) func(s
 		cmd.ui.Say(T("Uploading {{.AppName}}...",
 			map[string]interface{}{"AppName": terminal.EntityNameColor(app.Name)}))
 
-		apiErr := cmd.uploadApp(app.Guid, appDir, path, localFiles)
+		apiErr := cmd.uploadApp(app.Guid, appDir, path, localFiles, options)
 		if apiErr != nil {
 			cmd.ui.Failed(fmt.Sprintf(T("Error uploading application.\n{{.ApiErr}}",
 				map[string]interface{}{"ApiErr": apiErr.Error()})))
@@ -232,6 +1341,11 @@ func (cmd *Push) updateRoutes(routeActor actors.RouteActor, app models.Applicati
 		return
 	}
 
+	if appParams.Routes != nil {
+		cmd.bindManifestRoutes(routeActor, app, *appParams.Routes)
+		return
+	}
+
 	if routeDefined || defaultRouteAcceptable {
 		if appParams.Domains == nil {
 			cmd.processDomainsAndBindRoutes(appParams, routeActor, app, cmd.findDomain(nil))
@@ -264,6 +1378,116 @@ func (cmd *Push) createAndBindRoute(host *string, UseRandomHostname bool, routeA
 	routeActor.BindRoute(app, route)
 }
 
+// manifestRoute is a route URL from the manifest split into its parts.
+type manifestRoute struct {
+	host   string
+	domain models.DomainFields
+	path   string
+}
+
+// checkManifestRoutes lists the domains of the org when the manifest declares
+// routes, and fails if any of these routes does not match one of them.
+func (cmd *Push) checkManifestRoutes(appSet []models.AppParams) {
+	cmd.orgDomains = nil
+
+	var unresolved []string
+	for _, appParams := range appSet {
+		if appParams.Routes == nil || appParams.NoRoute {
+			continue
+		}
+
+		if cmd.orgDomains == nil {
+			cmd.orgDomains = cmd.listOrgDomains()
+		}
+
+		_, appUnresolved := cmd.resolveRoutes(*appParams.Routes)
+		unresolved = append(unresolved, appUnresolved...)
+	}
+
+	cmd.failOnUnresolvedRoutes(unresolved)
+}
+
+func (cmd *Push) bindManifestRoutes(routeActor actors.RouteActor, app models.Application, urls []string) {
+	routes, unresolved := cmd.resolveRoutes(urls)
+	cmd.failOnUnresolvedRoutes(unresolved)
+
+	for _, manifestRoute := range routes {
+		route := routeActor.FindOrCreateRoute(manifestRoute.host, manifestRoute.domain, manifestRoute.path)
+		routeActor.BindRoute(app, route)
+	}
+}
+
+func (cmd *Push) listOrgDomains() []models.DomainFields {
+	domains := []models.DomainFields{}
+	apiErr := cmd.domainRepo.ListDomainsForOrg(cmd.config.OrganizationFields().Guid, func(domain models.DomainFields) bool {
+		domains = append(domains, domain)
+		return true
+	})
+	if apiErr != nil {
+		cmd.ui.Failed(apiErr.Error())
+	}
+	return domains
+}
+
+func (cmd *Push) resolveRoutes(urls []string) (routes []manifestRoute, unresolved []string) {
+	for _, url := range urls {
+		route, found := splitRouteURL(url, cmd.orgDomains)
+		if !found {
+			unresolved = append(unresolved, url)
+			continue
+		}
+		routes = append(routes, route)
+	}
+	return
+}
+
+func (cmd *Push) failOnUnresolvedRoutes(unresolved []string) {
+	if len(unresolved) == 0 {
+		return
+	}
+
+	cmd.ui.Failed(T("The following routes do not match any domain of org {{.OrgName}}:\n{{.Routes}}",
+		map[string]interface{}{
+			"OrgName": cmd.config.OrganizationFields().Name,
+			"Routes":  "  " + strings.Join(unresolved, "\n  "),
+		}))
+}
+
+// splitRouteURL splits a route URL such as 'api.example.com/v1' into host,
+// domain and path, using the longest of the given domains that matches.
+func splitRouteURL(url string, domains []models.DomainFields) (route manifestRoute, found bool) {
+	url = strings.TrimPrefix(strings.TrimPrefix(url, "https://"), "http://")
+
+	hostname, path := url, ""
+	if index := strings.Index(url, "/"); index >= 0 {
+		hostname, path = url[:index], url[index:]
+	}
+	if path == "/" {
+		path = ""
+	}
+	hostname = strings.ToLower(hostname)
+
+	for _, domain := range domains {
+		domainName := strings.ToLower(domain.Name)
+
+		var host string
+		switch {
+		case hostname == domainName:
+			host = ""
+		case strings.HasSuffix(hostname, "."+domainName):
+			host = strings.TrimSuffix(hostname, "."+domainName)
+		default:
+			continue
+		}
+
+		if !found || len(domainName) > len(route.domain.Name) {
+			route = manifestRoute{host: host, domain: domain, path: path}
+			found = true
+		}
+	}
+	return
+}
+
 func (cmd *Push) removeRoutes(app models.Application, routeActor actors.RouteActor) {
 	if len(app.Routes) == 0 {
 		cmd.ui.Say(T("App {{.AppName}} is a worker, skipping route creation",
@@ -380,25 +1604,6 @@ func (cmd *Push) restart(app models.Application, params models.AppParams, c flag
 	cmd.appStarter.ApplicationStart(app, cmd.config.OrganizationFields().Name, cmd.config.SpaceFields().Name)
 }
 
-func (cmd *Push) createOrUpdateApp(appParams models.AppParams) (app models.Application) {
-	if appParams.Name == nil {
-		cmd.ui.Failed(T("Error: No name found for app"))
-	}
-
-	app, apiErr := cmd.appRepo.Read(*appParams.Name)
-
-	switch apiErr.(type) {
-	case nil:
-		app = cmd.updateApp(app, appParams)
-	case *errors.ModelNotFoundError:
-		app = cmd.createApp(appParams)
-	default:
-		cmd.ui.Failed(apiErr.Error())
-	}
-
-	return
-}
-
 func (cmd *Push) createApp(appParams models.AppParams) (app models.Application) {
 	spaceGuid := cmd.config.SpaceFields().Guid
 	appParams.SpaceGuid = &spaceGuid
@@ -452,41 +1657,117 @@ func (cmd *Push) updateApp(app models.Application, appParams models.AppParams) (
 func (cmd *Push) findAndValidateAppsToPush(c flags.FlagContext) []models.AppParams {
 	appsFromManifest := cmd.getAppParamsFromManifest(c)
 	appFromContext := cmd.getAppParamsFromContext(c)
-	return cmd.createAppSetFromContextAndManifest(appFromContext, appsFromManifest)
+	apps := cmd.createAppSetFromContextAndManifest(appFromContext, appsFromManifest)
+
+	for i := range apps {
+		cmd.validateDockerApp(&apps[i])
+	}
+	return apps
+}
+
+// validateDockerApp checks that a docker app has none of the settings of the
+// apps staged from their files, and reads the password of its private
+// registry from the environment.
+func (cmd *Push) validateDockerApp(app *models.AppParams) {
+	if app.DockerImage == nil {
+		if app.DockerCredentials != nil {
+			cmd.ui.Failed(T("Incorrect Usage. A docker username can only be given for a docker image, set with --docker-image or in the docker section of the manifest.\n\n") +
+				command_registry.Commands.CommandUsage("push"))
+		}
+		return
+	}
+
+	var conflicts []string
+	if app.BuildpackUrl != nil && *app.BuildpackUrl != "" {
+		conflicts = append(conflicts, T("buildpack"))
+	}
+	if app.Path != nil {
+		conflicts = append(conflicts, T("path"))
+	}
+	if app.StackName != nil {
+		conflicts = append(conflicts, T("stack"))
+	}
+	if len(conflicts) > 0 {
+		cmd.ui.Failed(T("App {{.AppName}} is pushed from the docker image {{.Image}}, which cannot be combined with a {{.Settings}}",
+			map[string]interface{}{
+				"AppName":  *app.Name,
+				"Image":    *app.DockerImage,
+				"Settings": strings.Join(conflicts, ", "),
+			}))
+	}
+
+	if app.DockerCredentials != nil {
+		password := os.Getenv("CF_DOCKER_PASSWORD")
+		if password == "" {
+			cmd.ui.Failed(T("Environment variable CF_DOCKER_PASSWORD not set. It is required to pull the docker image {{.Image}} as {{.Username}}.",
+				map[string]interface{}{"Image": *app.DockerImage, "Username": app.DockerCredentials.Username}))
+		}
+		app.DockerCredentials = &models.DockerCredentials{Username: app.DockerCredentials.Username, Password: password}
+	}
 }
 
 func (cmd *Push) getAppParamsFromManifest(c flags.FlagContext) []models.AppParams {
+	usesVariables := len(c.StringSlice("var")) > 0 || len(c.StringSlice("vars-file")) > 0
+
 	if c.Bool("no-manifest") {
+		if usesVariables {
+			cmd.ui.Failed(T("Incorrect Usage. --var and --vars-file cannot be used with --no-manifest.\n\n") +
+				command_registry.Commands.CommandUsage("push"))
+		}
 		return []models.AppParams{}
 	}
 
-	var path string
-	if c.String("f") != "" {
-		path = c.String("f")
-	} else {
-		var err error
+	var m *manifest.Manifest
+	var err error
+
+	paths := c.StringSlice("f")
+	switch len(paths) {
+	case 0:
+		var path string
 		path, err = os.Getwd()
 		if err != nil {
 			cmd.ui.Failed(T("Could not determine the current working directory!"), err)
 		}
-	}
-
-	m, err := cmd.manifestRepo.ReadManifest(path)
 
-	if err != nil {
-		if m.Path == "" && c.String("f") == "" {
+		m, err = cmd.manifestRepo.ReadManifest(path)
+		if err != nil && m.Path == "" {
+			if usesVariables {
+				cmd.ui.Failed(T("Incorrect Usage. --var and --vars-file need a manifest, but none was found in {{.Path}}.\n\n",
+					map[string]interface{}{"Path": path}) + command_registry.Commands.CommandUsage("push"))
+			}
 			return []models.AppParams{}
 		}
+	case 1:
+		m, err = cmd.manifestRepo.ReadManifest(paths[0])
+	default:
+		m, err = cmd.manifestRepo.ReadManifests(paths)
+	}
+
+	if err != nil {
 		cmd.ui.Failed(T("Error reading manifest file:\n{{.Err}}", map[string]interface{}{"Err": err.Error()}))
 	}
 
+	m.Variables, err = manifest.CollectVariables(c.StringSlice("vars-file"), c.StringSlice("var"))
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+	}
+
 	apps, err := m.Applications()
 	if err != nil {
 		cmd.ui.Failed("Error reading manifest file:\n%s", err)
 	}
 
-	cmd.ui.Say(T("Using manifest file {{.Path}}\n",
-		map[string]interface{}{"Path": terminal.EntityNameColor(m.Path)}))
+	if len(m.OverlayPaths) == 0 {
+		cmd.ui.Say(T("Using manifest file {{.Path}}\n",
+			map[string]interface{}{"Path": terminal.EntityNameColor(m.Path)}))
+	} else {
+		manifestPaths := []string{terminal.EntityNameColor(m.Path)}
+		for _, path := range m.OverlayPaths {
+			manifestPaths = append(manifestPaths, terminal.EntityNameColor(path))
+		}
+		cmd.ui.Say(T("Using manifest files {{.Paths}}\n",
+			map[string]interface{}{"Paths": strings.Join(manifestPaths, ", ")}))
+	}
 	return apps
 }
 
@@ -505,6 +1786,9 @@ func (cmd *Push) createAppSetFromContextAndManifest(contextApp models.AppParams,
 			err = addApp(&apps, contextApp)
 		}
 	case 1:
+		if manifestApps[0].Routes != nil && contextApp.HasRouteFlags() {
+			cmd.ui.Failed("%s", T("Incorrect Usage. The flags -d, -n, --no-hostname, --random-route and --route-path cannot be used with a manifest that declares routes."))
+		}
 		manifestApps[0].Merge(&contextApp)
 		err = addApp(&apps, manifestApps[0])
 	default:
@@ -539,7 +1823,7 @@ func addApp(apps *[]models.AppParams, app models.AppParams) (err error) {
 	if app.Name == nil {
 		err = errors.New(T("App name is a required field"))
 	}
-	if app.Path == nil {
+	if app.Path == nil && app.DockerImage == nil {
 		cwd, _ := os.Getwd()
 		app.Path = &cwd
 	}
@@ -630,6 +1914,10 @@ func (cmd *Push) getAppParamsFromContext(c flags.FlagContext) (appParams models.
 		appParams.DockerImage = &dockerImage
 	}
 
+	if c.String("docker-username") != "" {
+		appParams.DockerCredentials = &models.DockerCredentials{Username: c.String("docker-username")}
+	}
+
 	if c.String("p") != "" {
 		path := c.String("p")
 		appParams.Path = &path
@@ -662,8 +1950,40 @@ func (cmd *Push) getAppParamsFromContext(c flags.FlagContext) (appParams models.
 	return
 }
 
-func (cmd *Push) uploadApp(appGuid, appDir, appDirOrZipFile string, localFiles []models.AppFileFields) error {
+// uploadApp uploads the app files, retrying with an exponential backoff when
+// the upload fails because of the network or of the server. The resources are
+// matched again before each retry, so the files the server received during
+// the failed attempt are not sent again.
+func (cmd *Push) uploadApp(appGuid, appDir, appDirOrZipFile string, localFiles []models.AppFileFields, options uploadOptions) error {
+	backoff := cmd.UploadRetryBackoff
+
+	for attempt := 1; ; attempt++ {
+		err := cmd.uploadAppFiles(appGuid, appDir, appDirOrZipFile, localFiles, options.reproducible)
+		if err == nil || attempt > options.retries || !application_bits.IsRetryableError(err) {
+			return err
+		}
+
+		cmd.ui.Warn(T("Uploading failed: {{.Error}}\nRetrying in {{.Delay}} ({{.Retry}} of {{.Retries}})...",
+			map[string]interface{}{
+				"Error":   err.Error(),
+				"Delay":   backoff,
+				"Retry":   attempt,
+				"Retries": options.retries,
+			}))
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxUploadRetryBackoff {
+			backoff = maxUploadRetryBackoff
+		}
+	}
+}
+
+func (cmd *Push) uploadAppFiles(appGuid, appDir, appDirOrZipFile string, localFiles []models.AppFileFields, reproducible bool) error {
 	uploadDir, err := ioutil.TempDir("", "apps")
+	if err != nil {
+		return err
+	}
 	defer os.RemoveAll(uploadDir)
 
 	remoteFiles, hasFileToUpload, err := cmd.actor.GatherFiles(localFiles, appDir, uploadDir)
@@ -671,20 +1991,21 @@ func (cmd *Push) uploadApp(appGuid, appDir, appDirOrZipFile string, localFiles [
 		return err
 	}
 
-	zipFile, err := ioutil.TempFile("", "uploads")
-	defer func() {
-		zipFile.Close()
-		os.Remove(zipFile.Name())
-	}()
-
+	var zipSource application_bits.ZipSource
+	var zipSize int64
 	if hasFileToUpload {
-		err = cmd.zipAppFiles(zipFile, appDirOrZipFile, uploadDir)
-		if err != nil {
-			return err
+		zipSize = filesToUploadSize(localFiles, remoteFiles)
+		cmd.describeUpload(appDirOrZipFile, uploadDir, zipSize)
+
+		zipSource = func(zipWriter io.Writer) error {
+			if reproducible {
+				return cmd.zipper.ZipReproducibly(uploadDir, zipWriter)
+			}
+			return cmd.zipper.Zip(uploadDir, zipWriter)
 		}
 	}
 
-	err = cmd.actor.UploadApp(appGuid, zipFile, remoteFiles)
+	err = cmd.actor.UploadApp(appGuid, zipSource, zipSize, remoteFiles)
 	if err != nil {
 		return err
 	}
@@ -692,29 +2013,33 @@ func (cmd *Push) uploadApp(appGuid, appDir, appDirOrZipFile string, localFiles [
 	return nil
 }
 
-func (cmd *Push) zipAppFiles(zipFile *os.File, appDir string, uploadDir string) error {
-	err := cmd.zipper.Zip(uploadDir, zipFile)
-	if err != nil {
-		if emptyDirErr, ok := err.(*errors.EmptyDirError); ok {
-			zipFile = nil
-			return emptyDirErr
-		}
-		return fmt.Errorf("%s: %s", T("Error zipping application"), err.Error())
+// filesToUploadSize is the uncompressed size of the files that are not
+// already present on the server. The zip is streamed while uploading, so its
+// size is not known in advance and this size stands in for it.
+func filesToUploadSize(localFiles []models.AppFileFields, remoteFiles []resources.AppFileResource) int64 {
+	remotePaths := map[string]bool{}
+	for _, remoteFile := range remoteFiles {
+		remotePaths[remoteFile.Path] = true
 	}
 
-	zipFileSize, err := cmd.zipper.GetZipSize(zipFile)
-	if err != nil {
-		return err
+	var uploadSize int64
+	for _, localFile := range localFiles {
+		if !remotePaths[localFile.Path] {
+			uploadSize += localFile.Size
+		}
 	}
+	return uploadSize
+}
 
-	zipFileCount := cmd.appfiles.CountFiles(uploadDir)
-	if zipFileCount > 0 {
+// describeUpload reports the files that are not already present on the
+// server and their uncompressed size.
+func (cmd *Push) describeUpload(appDir string, uploadDir string, uploadSize int64) {
+	fileCount := cmd.appfiles.CountFiles(uploadDir)
+	if fileCount > 0 {
 		cmd.ui.Say(T("Uploading app files from: {{.Path}}", map[string]interface{}{"Path": appDir}))
-		cmd.ui.Say(T("Uploading {{.ZipFileBytes}}, {{.FileCount}} files",
+		cmd.ui.Say(T("Uploading {{.FileBytes}}, {{.FileCount}} files",
 			map[string]interface{}{
-				"ZipFileBytes": formatters.ByteSize(zipFileSize),
-				"FileCount":    zipFileCount}))
+				"FileBytes": formatters.ByteSize(uploadSize),
+				"FileCount": fileCount}))
 	}
-
-	return nil
 }
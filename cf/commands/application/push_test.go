@@ -1,11 +1,19 @@
 package application_test
 
 import (
+	"bytes"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"syscall"
+	"time"
 
 	fakeactors "github.com/cloudfoundry/cli/cf/actors/fakes"
+	"github.com/cloudfoundry/cli/cf/api/application_bits"
+	appBitsFakes "github.com/cloudfoundry/cli/cf/api/application_bits/fakes"
 	testApplication "github.com/cloudfoundry/cli/cf/api/applications/fakes"
 	authenticationfakes "github.com/cloudfoundry/cli/cf/api/authentication/fakes"
 	testapi "github.com/cloudfoundry/cli/cf/api/fakes"
@@ -13,6 +21,7 @@ import (
 	testStacks "github.com/cloudfoundry/cli/cf/api/stacks/fakes"
 	fakeappfiles "github.com/cloudfoundry/cli/cf/app_files/fakes"
 	"github.com/cloudfoundry/cli/cf/command_registry"
+	"github.com/cloudfoundry/cli/cf/commands/application"
 	appCmdFakes "github.com/cloudfoundry/cli/cf/commands/application/fakes"
 	serviceCmdFakes "github.com/cloudfoundry/cli/cf/commands/service/fakes"
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
@@ -26,6 +35,7 @@ import (
 	testmanifest "github.com/cloudfoundry/cli/testhelpers/manifest"
 	testreq "github.com/cloudfoundry/cli/testhelpers/requirements"
 	testterm "github.com/cloudfoundry/cli/testhelpers/terminal"
+	utilsfakes "github.com/cloudfoundry/cli/utils/fakes"
 	testwords "github.com/cloudfoundry/cli/words/generator/fakes"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -42,16 +52,20 @@ var _ = Describe("Push Command", func() {
 		stopper                    *appCmdFakes.FakeApplicationStopper
 		serviceBinder              *serviceCmdFakes.FakeAppBinder
 		appRepo                    *testApplication.FakeApplicationRepository
+		appSummaryRepo             *testapi.FakeAppSummaryRepository
 		domainRepo                 *testapi.FakeDomainRepository
 		routeRepo                  *testapi.FakeRouteRepository
 		stackRepo                  *testStacks.FakeStackRepository
 		serviceRepo                *testapi.FakeServiceRepository
+		bindingRepo                *testapi.FakeServiceBindingRepo
 		wordGenerator              *testwords.FakeWordGenerator
 		requirementsFactory        *testreq.FakeReqFactory
 		authRepo                   *authenticationfakes.FakeAuthenticationRepository
 		actor                      *fakeactors.FakePushActor
 		appfiles                   *fakeappfiles.FakeAppFiles
+		appBitsRepo                *appBitsFakes.FakeApplicationBitsRepository
 		zipper                     *fakeappfiles.FakeZipper
+		shellRunner                *utilsfakes.FakeShellRunner
 		OriginalCommandStart       command_registry.Command
 		OriginalCommandStop        command_registry.Command
 		OriginalCommandServiceBind command_registry.Command
@@ -63,15 +77,19 @@ var _ = Describe("Push Command", func() {
 		deps.Config = configRepo
 		deps.ManifestRepo = manifestRepo
 		deps.RepoLocator = deps.RepoLocator.SetApplicationRepository(appRepo)
+		deps.RepoLocator = deps.RepoLocator.SetAppSummaryRepository(appSummaryRepo)
 		deps.RepoLocator = deps.RepoLocator.SetDomainRepository(domainRepo)
 		deps.RepoLocator = deps.RepoLocator.SetRouteRepository(routeRepo)
 		deps.RepoLocator = deps.RepoLocator.SetServiceRepository(serviceRepo)
+		deps.RepoLocator = deps.RepoLocator.SetServiceBindingRepository(bindingRepo)
 		deps.RepoLocator = deps.RepoLocator.SetStackRepository(stackRepo)
 		deps.RepoLocator = deps.RepoLocator.SetAuthenticationRepository(authRepo)
+		deps.RepoLocator = deps.RepoLocator.SetApplicationBitsRepository(appBitsRepo)
 		deps.WordGenerator = wordGenerator
 		deps.PushActor = actor
 		deps.AppZipper = zipper
 		deps.AppFiles = appfiles
+		deps.ShellRunner = shellRunner
 
 		//inject fake commands dependencies into registry
 		command_registry.Register(starter)
@@ -79,6 +97,7 @@ var _ = Describe("Push Command", func() {
 		command_registry.Register(serviceBinder)
 
 		command_registry.Commands.SetCommand(command_registry.Commands.FindCommand("push").SetDependency(deps, false))
+		command_registry.Commands.FindCommand("push").(*application.Push).UploadRetryBackoff = 0
 	}
 
 	BeforeEach(func() {
@@ -100,6 +119,7 @@ var _ = Describe("Push Command", func() {
 		stopper.MetaDataReturns(command_registry.CommandMetadata{Name: "stop"})
 
 		appRepo = &testApplication.FakeApplicationRepository{}
+		appSummaryRepo = &testapi.FakeAppSummaryRepository{}
 
 		domainRepo = &testapi.FakeDomainRepository{}
 		sharedDomain := maker.NewSharedDomainFields(maker.Overrides{"name": "foo.cf-app.com", "guid": "foo-domain-guid"})
@@ -146,6 +166,7 @@ var _ = Describe("Push Command", func() {
 
 		stackRepo = &testStacks.FakeStackRepository{}
 		serviceRepo = &testapi.FakeServiceRepository{}
+		bindingRepo = &testapi.FakeServiceBindingRepo{}
 		authRepo = &authenticationfakes.FakeAuthenticationRepository{}
 		wordGenerator = new(testwords.FakeWordGenerator)
 		wordGenerator.BabbleReturns("random-host")
@@ -161,6 +182,8 @@ var _ = Describe("Push Command", func() {
 
 		zipper = &fakeappfiles.FakeZipper{}
 		appfiles = &fakeappfiles.FakeAppFiles{}
+		appBitsRepo = &appBitsFakes.FakeApplicationBitsRepository{}
+		shellRunner = &utilsfakes.FakeShellRunner{}
 		appfiles.AppFilesInDirReturns([]models.AppFileFields{
 			{
 				Path: "some-path",
@@ -239,7 +262,6 @@ var _ = Describe("Push Command", func() {
 			}
 
 			zipper.ZipReturns(nil)
-			zipper.GetZipSizeReturns(9001, nil)
 			actor.GatherFilesReturns(nil, true, nil)
 			actor.UploadAppReturns(nil)
 		})
@@ -420,7 +442,7 @@ var _ = Describe("Push Command", func() {
 				Expect(boundAppGUID).To(Equal("app-name-guid"))
 				Expect(boundRouteGUID).To(Equal("app-name-route-guid"))
 
-				appGuid, _, _ := actor.UploadAppArgsForCall(0)
+				appGuid, _, _, _ := actor.UploadAppArgsForCall(0)
 				Expect(appGuid).To(Equal("app-name-guid"))
 
 				Expect(ui.Outputs).To(ContainSubstrings(
@@ -527,7 +549,7 @@ var _ = Describe("Push Command", func() {
 				Expect(boundAppGUID).To(Equal("app-name-guid"))
 				Expect(boundRouteGUID).To(Equal("my-hostname-route-guid"))
 
-				appGuid, _, _ := actor.UploadAppArgsForCall(0)
+				appGuid, _, _, _ := actor.UploadAppArgsForCall(0)
 				Expect(appGuid).To(Equal("app-name-guid"))
 
 				Expect(starter.ApplicationStartCallCount()).To(Equal(0))
@@ -557,6 +579,85 @@ var _ = Describe("Push Command", func() {
 						[]string{"Uploading testApp"},
 					))
 				})
+
+				It("fails when combined with a buildpack, path or stack", func() {
+					callPush("testApp", "-o", "sample/dockerImage", "-b", "ruby_buildpack", "-p", "some/path", "-s", "cflinuxfs2")
+
+					Expect(appRepo.CreateCallCount()).To(BeZero())
+					Expect(ui.Outputs).To(ContainSubstrings(
+						[]string{"FAILED"},
+						[]string{"App testApp is pushed from the docker image sample/dockerImage, which cannot be combined with a buildpack, path, stack"},
+					))
+				})
+
+				Context("from a private registry", func() {
+					var oldPassword string
+
+					BeforeEach(func() {
+						oldPassword = os.Getenv("CF_DOCKER_PASSWORD")
+						os.Setenv("CF_DOCKER_PASSWORD", "some-password")
+					})
+
+					AfterEach(func() {
+						os.Setenv("CF_DOCKER_PASSWORD", oldPassword)
+					})
+
+					It("sends the username and the password from the environment", func() {
+						callPush("testApp", "-o", "registry.example.com/image", "--docker-username", "some-user")
+
+						params := appRepo.CreateArgsForCall(0)
+						Expect(*params.DockerCredentials).To(Equal(models.DockerCredentials{
+							Username: "some-user",
+							Password: "some-password",
+						}))
+					})
+
+					It("fails when the password is not set", func() {
+						os.Setenv("CF_DOCKER_PASSWORD", "")
+
+						callPush("testApp", "-o", "registry.example.com/image", "--docker-username", "some-user")
+
+						Expect(appRepo.CreateCallCount()).To(BeZero())
+						Expect(ui.Outputs).To(ContainSubstrings(
+							[]string{"FAILED"},
+							[]string{"Environment variable CF_DOCKER_PASSWORD not set."},
+						))
+					})
+
+					It("takes the image and the username from the manifest", func() {
+						manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+							Path: "manifest.yml",
+							Data: generic.NewMap(map[interface{}]interface{}{
+								"name": "docker-app",
+								"docker": map[interface{}]interface{}{
+									"image":    "registry.example.com/image",
+									"username": "manifest-user",
+								},
+							}),
+						}
+
+						callPush()
+
+						params := appRepo.CreateArgsForCall(0)
+						Expect(*params.DockerImage).To(Equal("registry.example.com/image"))
+						Expect(*params.Diego).To(BeTrue())
+						Expect(*params.DockerCredentials).To(Equal(models.DockerCredentials{
+							Username: "manifest-user",
+							Password: "some-password",
+						}))
+						Expect(actor.ProcessPathCallCount()).To(BeZero())
+					})
+
+					It("fails when a username is given without a docker image", func() {
+						callPush("testApp", "--docker-username", "some-user")
+
+						Expect(appRepo.CreateCallCount()).To(BeZero())
+						Expect(ui.Outputs).To(ContainSubstrings(
+							[]string{"FAILED"},
+							[]string{"A docker username can only be given for a docker image"},
+						))
+					})
+				})
 			})
 
 			Context("when health-check-type '-u' or '--health-check-type' is supplied", func() {
@@ -711,6 +812,17 @@ var _ = Describe("Push Command", func() {
 				Expect(actualLocalFiles).To(Equal(expectedLocalFiles))
 			})
 
+			It("lists the app files without the hash cache when the path is an archive", func() {
+				zipper.IsZipFileReturns(true)
+				appfiles.AppFilesInArchiveDirReturns([]models.AppFileFields{{Path: "the-path"}}, nil)
+				callPush("-p", "../some/path-to/an-app/file.zip", "app-with-path")
+
+				Expect(appfiles.AppFilesInArchiveDirCallCount()).To(Equal(1))
+				Expect(appfiles.AppFilesInDirCallCount()).To(BeZero())
+				actualLocalFiles, _, _ := actor.GatherFilesArgsForCall(0)
+				Expect(actualLocalFiles).To(Equal([]models.AppFileFields{{Path: "the-path"}}))
+			})
+
 			It("prints a message when there are no app files to process", func() {
 				appfiles.AppFilesInDirReturns([]models.AppFileFields{}, nil)
 				callPush("-p", "../some/path-to/an-app/file.zip", "app-with-path")
@@ -772,6 +884,20 @@ var _ = Describe("Push Command", func() {
 				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"FAILED"}))
 			})
 
+			It("fails when variables are given but the current working directory does not contain a manifest", func() {
+				manifestRepo.ReadManifestReturns.Manifest = manifest.NewEmptyManifest()
+				manifestRepo.ReadManifestReturns.Error = syscall.ENOENT
+
+				callPush("--var", "instances=5", "app-name")
+
+				Expect(appRepo.CreateCallCount()).To(BeZero())
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"FAILED"},
+					[]string{"Incorrect Usage", "--var and --vars-file need a manifest"},
+					[]string{"USAGE:"},
+				))
+			})
+
 			It("uses the manifest in the current directory by default", func() {
 				manifestRepo.ReadManifestReturns.Manifest = singleAppManifest()
 				manifestRepo.ReadManifestReturns.Manifest.Path = "manifest.yml"
@@ -784,6 +910,17 @@ var _ = Describe("Push Command", func() {
 				Expect(manifestRepo.ReadManifestArgs.Path).To(Equal(cwd))
 			})
 
+			It("merges the manifests given with several -f flags", func() {
+				manifestRepo.ReadManifestReturns.Manifest = singleAppManifest()
+				manifestRepo.ReadManifestReturns.Manifest.Path = "manifest.yml"
+				manifestRepo.ReadManifestReturns.Manifest.OverlayPaths = []string{"manifest-prod.yml"}
+
+				callPush("-f", "manifest.yml", "-f", "manifest-prod.yml")
+
+				Expect(manifestRepo.ReadManifestsArgs.Paths).To(Equal([]string{"manifest.yml", "manifest-prod.yml"}))
+				Expect(ui.Outputs).To(ContainSubstrings([]string{"Using manifest files", "manifest.yml, manifest-prod.yml"}))
+			})
+
 			It("does not use a manifest if the 'no-manifest' flag is passed", func() {
 				callPush("--no-route", "--no-manifest", "app-name")
 
@@ -797,6 +934,17 @@ var _ = Describe("Push Command", func() {
 				Expect(*params.Name).To(Equal("app-name"))
 			})
 
+			It("fails when variables are given with the 'no-manifest' flag", func() {
+				callPush("--no-manifest", "--vars-file", "vars.yml", "app-name")
+
+				Expect(appRepo.CreateCallCount()).To(BeZero())
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"FAILED"},
+					[]string{"Incorrect Usage", "--var and --vars-file cannot be used with --no-manifest"},
+					[]string{"USAGE:"},
+				))
+			})
+
 			It("pushes an app when provided a manifest with one app defined", func() {
 				domainRepo.FindByNameInOrgReturns(models.DomainFields{
 					Name: "manifest-example.com",
@@ -919,6 +1067,62 @@ var _ = Describe("Push Command", func() {
 				))
 			})
 
+			Context("when the manifest contains variables", func() {
+				BeforeEach(func() {
+					manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+						Path: "manifest.yml",
+						Data: generic.NewMap(map[interface{}]interface{}{
+							"applications": []interface{}{
+								generic.NewMap(map[interface{}]interface{}{
+									"name":      "((app-name))",
+									"instances": "((instances))",
+									"services":  "((services))",
+								}),
+							},
+						}),
+					}
+				})
+
+				It("substitutes values from vars files and --var flags, with --var taking precedence", func() {
+					varsFile := filepath.Join("..", "..", "..", "fixtures", "manifests", "vars.yml")
+					callPush("--vars-file", varsFile, "--var", "instances=5")
+
+					Expect(appRepo.CreateCallCount()).To(Equal(1))
+					params := appRepo.CreateArgsForCall(0)
+					Expect(*params.Name).To(Equal("app-from-vars"))
+					Expect(*params.InstanceCount).To(Equal(5))
+					Expect(*params.ServicesToBind).To(Equal([]string{"database", "cache"}))
+				})
+
+				It("fails when a variable is not provided", func() {
+					callPush("--var", "app-name=my-app")
+
+					Expect(appRepo.CreateCallCount()).To(BeZero())
+					Expect(ui.Outputs).To(ContainSubstrings(
+						[]string{"FAILED"},
+						[]string{"Unresolved variables in manifest", "((instances))", "((services))"},
+					))
+				})
+
+				It("fails when a --var is malformed", func() {
+					callPush("--var", "app-name")
+
+					Expect(ui.Outputs).To(ContainSubstrings(
+						[]string{"FAILED"},
+						[]string{"Invalid variable 'app-name'"},
+					))
+				})
+
+				It("fails when a vars file cannot be read", func() {
+					callPush("--vars-file", "does-not-exist.yml")
+
+					Expect(ui.Outputs).To(ContainSubstrings(
+						[]string{"FAILED"},
+						[]string{"Error reading vars file"},
+					))
+				})
+			})
+
 			Context("when a manifest has many apps", func() {
 				BeforeEach(func() {
 					manifestRepo.ReadManifestReturns.Manifest = manifestWithServicesAndEnv()
@@ -1031,7 +1235,7 @@ var _ = Describe("Push Command", func() {
 			Expect(orgName).To(Equal(configRepo.OrganizationFields().Name))
 			Expect(spaceName).To(Equal(configRepo.SpaceFields().Name))
 
-			appGuid, _, _ := actor.UploadAppArgsForCall(0)
+			appGuid, _, _, _ := actor.UploadAppArgsForCall(0)
 			Expect(appGuid).To(Equal(existingApp.Guid))
 		})
 
@@ -1125,7 +1329,7 @@ var _ = Describe("Push Command", func() {
 					It("does not add a route to the app", func() {
 						callPush("existing-app")
 
-						appGuid, _, _ := actor.UploadAppArgsForCall(0)
+						appGuid, _, _, _ := actor.UploadAppArgsForCall(0)
 						Expect(appGuid).To(Equal("existing-app-guid"))
 						Expect(domainRepo.FindByNameInOrgCallCount()).To(BeZero())
 						Expect(routeRepo.FindCallCount()).To(BeZero())
@@ -1200,7 +1404,7 @@ var _ = Describe("Push Command", func() {
 			It("removes the route when the --no-route flag is given", func() {
 				callPush("--no-route", "existing-app")
 
-				appGuid, _, _ := actor.UploadAppArgsForCall(0)
+				appGuid, _, _, _ := actor.UploadAppArgsForCall(0)
 				Expect(appGuid).To(Equal("existing-app-guid"))
 
 				Expect(domainRepo.FindByNameInOrgCallCount()).To(BeZero())
@@ -1238,116 +1442,1235 @@ var _ = Describe("Push Command", func() {
 		})
 	})
 
-	Describe("service instances", func() {
+	Describe("routes declared in the manifest", func() {
 		BeforeEach(func() {
-			serviceRepo.FindInstanceByNameStub = func(name string) (models.ServiceInstance, error) {
-				return maker.NewServiceInstance(name), nil
+			domainRepo.ListDomainsForOrgStub = func(orgGuid string, cb func(models.DomainFields) bool) error {
+				cb(models.DomainFields{Name: "example.com", Guid: "example-domain-guid", Shared: true})
+				cb(models.DomainFields{Name: "api.example.com", Guid: "api-domain-guid"})
+				cb(models.DomainFields{Name: "other.org", Guid: "other-domain-guid"})
+				return nil
 			}
+			routeRepo.FindReturns(models.Route{}, errors.NewModelNotFoundError("Org", "couldn't find it"))
+			appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "the-app"))
+		})
 
-			appRepo.CreateStub = func(params models.AppParams) (models.Application, error) {
-				a := models.Application{}
-				a.Name = *params.Name
-
-				return a, nil
+		routesManifest := func(routes ...interface{}) *manifest.Manifest {
+			return &manifest.Manifest{
+				Path: "manifest.yml",
+				Data: generic.NewMap(map[interface{}]interface{}{
+					"applications": []interface{}{
+						generic.NewMap(map[interface{}]interface{}{
+							"name":   "manifest-app",
+							"routes": routes,
+						}),
+					},
+				}),
 			}
+		}
 
-			manifestRepo.ReadManifestReturns.Manifest = manifestWithServicesAndEnv()
+		It("creates and maps exactly the listed routes, splitting them against the org's domains", func() {
+			manifestRepo.ReadManifestReturns.Manifest = routesManifest("www.example.com/v1", "api.example.com", "other.org/", "v2.api.example.com/")
+			callPush()
+
+			Expect(routeRepo.CreateCallCount()).To(Equal(4))
+			host, domain, path := routeRepo.CreateArgsForCall(0)
+			Expect([]string{host, domain.Guid, path}).To(Equal([]string{"www", "example-domain-guid", "/v1"}))
+			host, domain, path = routeRepo.CreateArgsForCall(1)
+			Expect([]string{host, domain.Guid, path}).To(Equal([]string{"", "api-domain-guid", ""}))
+			host, domain, path = routeRepo.CreateArgsForCall(2)
+			Expect([]string{host, domain.Guid, path}).To(Equal([]string{"", "other-domain-guid", ""}))
+			host, domain, path = routeRepo.CreateArgsForCall(3)
+			Expect([]string{host, domain.Guid, path}).To(Equal([]string{"v2", "api-domain-guid", ""}))
+
+			Expect(routeRepo.BindCallCount()).To(Equal(4))
+			Expect(domainRepo.FirstOrDefaultCallCount()).To(BeZero())
 		})
 
-		Context("when the service is not bound", func() {
-			BeforeEach(func() {
-				appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "the-app"))
-			})
+		It("lists the org's domains once for all the apps", func() {
+			manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+				Path: "manifest.yml",
+				Data: generic.NewMap(map[interface{}]interface{}{
+					"applications": []interface{}{
+						generic.NewMap(map[interface{}]interface{}{"name": "app1", "routes": []interface{}{"app1.example.com"}}),
+						generic.NewMap(map[interface{}]interface{}{"name": "app2", "routes": []interface{}{"app2.example.com"}}),
+					},
+				}),
+			}
+			callPush()
 
-			It("binds service instances to the app", func() {
-				callPush()
-				Expect(len(serviceBinder.AppsToBind)).To(Equal(4))
-				Expect(serviceBinder.AppsToBind[0].Name).To(Equal("app1"))
-				Expect(serviceBinder.AppsToBind[1].Name).To(Equal("app1"))
-				Expect(serviceBinder.InstancesToBindTo[0].Name).To(Equal("app1-service"))
-				Expect(serviceBinder.InstancesToBindTo[1].Name).To(Equal("global-service"))
+			Expect(routeRepo.BindCallCount()).To(Equal(2))
+			Expect(domainRepo.ListDomainsForOrgCallCount()).To(Equal(1))
+		})
 
-				Expect(serviceBinder.AppsToBind[2].Name).To(Equal("app2"))
-				Expect(serviceBinder.AppsToBind[3].Name).To(Equal("app2"))
-				Expect(serviceBinder.InstancesToBindTo[2].Name).To(Equal("app2-service"))
-				Expect(serviceBinder.InstancesToBindTo[3].Name).To(Equal("global-service"))
+		It("maps no route when the list is empty", func() {
+			manifestRepo.ReadManifestReturns.Manifest = routesManifest()
+			callPush()
 
-				Expect(ui.Outputs).To(ContainSubstrings(
-					[]string{"Creating", "app1"},
-					[]string{"OK"},
-					[]string{"Binding service", "app1-service", "app1", "my-org", "my-space", "my-user"},
-					[]string{"OK"},
-					[]string{"Binding service", "global-service", "app1", "my-org", "my-space", "my-user"},
-					[]string{"OK"},
-					[]string{"Creating", "app2"},
-					[]string{"OK"},
-					[]string{"Binding service", "app2-service", "app2", "my-org", "my-space", "my-user"},
-					[]string{"OK"},
-					[]string{"Binding service", "global-service", "app2", "my-org", "my-space", "my-user"},
-					[]string{"OK"},
-				))
-			})
+			Expect(appRepo.CreateCallCount()).To(Equal(1))
+			Expect(routeRepo.CreateCallCount()).To(BeZero())
+			Expect(routeRepo.BindCallCount()).To(BeZero())
 		})
 
-		Context("when the app is already bound to the service", func() {
-			BeforeEach(func() {
-				appRepo.ReadReturns(maker.NewApp(maker.Overrides{}), nil)
-				serviceBinder.BindApplicationReturns.Error = errors.NewHttpError(500, "90003", "it don't work")
-			})
+		It("reports every route it cannot resolve before creating the app", func() {
+			manifestRepo.ReadManifestReturns.Manifest = routesManifest("www.example.com", "foo.unknown.com", "example.net/path")
+			callPush()
 
-			It("gracefully continues", func() {
-				callPush()
-				Expect(len(serviceBinder.AppsToBind)).To(Equal(4))
-				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"FAILED"}))
-			})
+			Expect(appRepo.CreateCallCount()).To(BeZero())
+			Expect(routeRepo.CreateCallCount()).To(BeZero())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"routes do not match any domain", "my-org"},
+				[]string{"foo.unknown.com"},
+				[]string{"example.net/path"},
+			))
 		})
 
-		Context("when the service instance can't be found", func() {
-			BeforeEach(func() {
-				serviceRepo.FindInstanceByNameReturns(models.ServiceInstance{}, errors.New("Error finding instance"))
-				manifestRepo.ReadManifestReturns.Manifest = manifestWithServicesAndEnv()
-			})
+		It("fails when route flags are combined with manifest routes", func() {
+			manifestRepo.ReadManifestReturns.Manifest = routesManifest("www.example.com")
+			callPush("-n", "other-host")
 
-			It("fails with an error", func() {
-				callPush()
-				Expect(ui.Outputs).To(ContainSubstrings(
-					[]string{"FAILED"},
-					[]string{"Could not find service", "app1-service", "app1"},
-				))
-			})
+			Expect(appRepo.CreateCallCount()).To(BeZero())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"Incorrect Usage", "cannot be used with a manifest that declares routes"},
+			))
 		})
-
 	})
 
-	Describe("checking for bad flags", func() {
-		It("fails when a non-numeric start timeout is given", func() {
+	Describe("parallel push", func() {
+		BeforeEach(func() {
+			starter.WithUIReturns(starter)
+			stopper.WithUIReturns(stopper)
+			actor.WithUIReturns(actor)
+			appBitsRepo.WithUIReturns(appBitsRepo)
+
 			appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "the-app"))
+			appRepo.CreateStub = func(params models.AppParams) (models.Application, error) {
+				if *params.Name == "broken-app" {
+					return models.Application{}, errors.New("create failed")
+				}
+				return models.Application{Guid: *params.Name + "-guid", Name: *params.Name, State: "stopped"}, nil
+			}
 
-			callPush("-t", "FooeyTimeout", "app-name")
+			apps := []interface{}{}
+			for _, name := range []string{"app1", "app2", "app3"} {
+				apps = append(apps, generic.NewMap(map[interface{}]interface{}{"name": name}))
+			}
+			manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+				Path: "manifest.yml",
+				Data: generic.NewMap(map[interface{}]interface{}{"applications": apps}),
+			}
+		})
 
+		It("fails when --parallel is not a positive number", func() {
+			Expect(callPush("--parallel", "0")).To(BeFalse())
 			Expect(ui.Outputs).To(ContainSubstrings(
 				[]string{"FAILED"},
-				[]string{"Invalid", "timeout", "FooeyTimeout"},
+				[]string{"Invalid value for --parallel: 0"},
+			))
+		})
+
+		It("starts no more than N apps at the same time", func() {
+			var mutex sync.Mutex
+			running, maxRunning := 0, 0
+			starter.ApplicationStartStub = func(app models.Application, orgName, spaceName string) (models.Application, error) {
+				mutex.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mutex.Unlock()
+
+				time.Sleep(50 * time.Millisecond)
+
+				mutex.Lock()
+				running--
+				mutex.Unlock()
+				return app, nil
+			}
+
+			callPush("--parallel", "2")
+
+			Expect(starter.ApplicationStartCallCount()).To(Equal(3))
+			Expect(maxRunning).To(Equal(2))
+			Expect(starter.WithUICallCount()).To(Equal(3))
+		})
+
+		It("reports the progress of each upload to the UI of its app", func() {
+			callPush("--parallel", "3")
+
+			Expect(actor.WithUICallCount()).To(Equal(3))
+			Expect(appBitsRepo.WithUICallCount()).To(Equal(3))
+			for i := 0; i < 3; i++ {
+				Expect(actor.WithUIArgsForCall(i) == ui).To(BeFalse())
+			}
+		})
+
+		It("prints the output of each app as one block prefixed with its name", func() {
+			callPush("--parallel", "3")
+
+			for _, name := range []string{"app1", "app2", "app3"} {
+				prefix := "[" + name + "] "
+				first, last := -1, -1
+				for i, line := range ui.Outputs {
+					if strings.HasPrefix(line, prefix) {
+						if first == -1 {
+							first = i
+						}
+						last = i
+					}
+				}
+
+				Expect(first).NotTo(Equal(-1))
+				for _, line := range ui.Outputs[first : last+1] {
+					Expect(line).To(HavePrefix(prefix))
+				}
+			}
+
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"[app1] Creating app", "app1"},
+				[]string{"[app2] Uploading app2"},
+			))
+		})
+
+		It("shows a summary table and fails when an app could not be pushed", func() {
+			manifestRepo.ReadManifestReturns.Manifest.Data.Get("applications").([]interface{})[1].(generic.Map).Set("name", "broken-app")
+
+			callPush("--parallel", "2")
+
+			Expect(starter.ApplicationStartCallCount()).To(Equal(2))
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"[broken-app] FAILED"},
+				[]string{"[broken-app] create failed"},
+				[]string{"app", "status", "duration"},
+				[]string{"app1", "pushed"},
+				[]string{"broken-app", "failed"},
+				[]string{"app3", "pushed"},
+				[]string{"FAILED"},
+				[]string{"1 of 3 apps failed to push"},
+			))
+		})
+
+		It("fails only the app whose push panics", func() {
+			starter.ApplicationStartStub = func(app models.Application, orgName, spaceName string) (models.Application, error) {
+				if app.Name == "app2" {
+					panic("something unexpected")
+				}
+				return app, nil
+			}
+
+			callPush("--parallel", "2")
+
+			Expect(starter.ApplicationStartCallCount()).To(Equal(3))
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"[app2] FAILED"},
+				[]string{"[app2] something unexpected"},
+				[]string{"app1", "pushed"},
+				[]string{"app2", "failed"},
+				[]string{"app3", "pushed"},
+				[]string{"1 of 3 apps failed to push"},
 			))
 		})
 	})
 
-	Describe("displaying information about files being uploaded", func() {
-		It("displays information about the files being uploaded", func() {
-			appfiles.CountFilesReturns(11)
+	Describe("blue-green push", func() {
+		var existingApp models.Application
+
+		BeforeEach(func() {
+			existingApp = models.Application{}
+			existingApp.Name = "existing-app"
+			existingApp.Guid = "existing-app-guid"
+			existingApp.State = "started"
+			existingApp.Memory = 256
+			existingApp.InstanceCount = 3
+			existingApp.EnvironmentVars = map[string]interface{}{"crazy": "pants"}
+			existingApp.Routes = []models.RouteSummary{
+				{Guid: "route-1-guid", Host: "existing-app", Domain: models.DomainFields{Name: "example.com"}},
+				{Guid: "route-2-guid", Host: "www", Domain: models.DomainFields{Name: "example.com"}},
+			}
+			existingApp.Services = []models.ServicePlanSummary{{Name: "existing-service"}}
+
+			appRepo.ReadStub = func(name string) (models.Application, error) {
+				if name == existingApp.Name {
+					return existingApp, nil
+				}
+				return models.Application{}, errors.NewModelNotFoundError("App", name)
+			}
+			appSummaryRepo.GetSummaryReturns(existingApp, nil)
+			appRepo.CreateStub = func(params models.AppParams) (models.Application, error) {
+				a := models.Application{}
+				a.Guid = *params.Name + "-guid"
+				a.Name = *params.Name
+				a.State = "stopped"
+				return a, nil
+			}
+			appRepo.UpdateStub = func(guid string, params models.AppParams) (models.Application, error) {
+				a := models.Application{}
+				a.Guid = guid
+				a.Name = *params.Name
+				return a, nil
+			}
+			serviceRepo.FindInstanceByNameStub = func(name string) (models.ServiceInstance, error) {
+				return models.ServiceInstance{ServiceInstanceFields: models.ServiceInstanceFields{Name: name}}, nil
+			}
 			zipper.ZipReturns(nil)
-			zipper.GetZipSizeReturns(6100000, nil)
-			actor.GatherFilesReturns([]resources.AppFileResource{resources.AppFileResource{Path: "path/to/app"}, resources.AppFileResource{Path: "bar"}}, true, nil)
+			actor.GatherFilesReturns(nil, true, nil)
+		})
 
-			curDir, err := os.Getwd()
-			Expect(err).NotTo(HaveOccurred())
+		It("fails with an unknown strategy", func() {
+			Expect(callPush("--strategy", "rolling", "existing-app")).To(BeFalse())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"Invalid strategy: rolling"},
+			))
+		})
 
-			callPush("appName")
+		It("fails when combined with --no-start", func() {
+			Expect(callPush("--strategy", "blue-green", "--no-start", "existing-app")).To(BeFalse())
 			Expect(ui.Outputs).To(ContainSubstrings(
-				[]string{"Uploading", curDir},
-				[]string{"5.8M", "11 files"},
+				[]string{"FAILED"},
+				[]string{"cannot be used with --no-start"},
 			))
 		})
+
+		It("creates the new version under a temporary name with the settings of the old app", func() {
+			callPush("--strategy", "blue-green", "-i", "5", "existing-app")
+
+			Expect(appRepo.CreateCallCount()).To(Equal(1))
+			params := appRepo.CreateArgsForCall(0)
+			Expect(*params.Name).To(Equal("existing-app-new"))
+			Expect(*params.Memory).To(Equal(int64(256)))
+			Expect(*params.InstanceCount).To(Equal(5))
+			Expect(params.State).To(BeNil())
+			Expect(params.Guid).To(BeNil())
+
+			Expect(appRepo.UpdateCallCount()).To(Equal(2))
+			Expect(stopper.ApplicationStopCallCount()).To(BeZero())
+
+			appGuid, _, _, _ := actor.UploadAppArgsForCall(0)
+			Expect(appGuid).To(Equal("existing-app-new-guid"))
+
+			Expect(len(serviceBinder.AppsToBind)).To(Equal(1))
+			Expect(serviceBinder.AppsToBind[0].Guid).To(Equal("existing-app-new-guid"))
+			Expect(serviceBinder.InstancesToBindTo[0].Name).To(Equal("existing-service"))
+		})
+
+		It("starts the new app before moving the routes and swapping it for the old app", func() {
+			starter.ApplicationStartStub = func(app models.Application, _, _ string) (models.Application, error) {
+				Expect(routeRepo.BindCallCount()).To(BeZero())
+				Expect(appRepo.DeleteCallCount()).To(BeZero())
+				return app, nil
+			}
+
+			callPush("--strategy", "blue-green", "existing-app")
+
+			Expect(starter.ApplicationStartCallCount()).To(Equal(1))
+			startedApp, _, _ := starter.ApplicationStartArgsForCall(0)
+			Expect(startedApp.Name).To(Equal("existing-app-new"))
+
+			Expect(routeRepo.BindCallCount()).To(Equal(2))
+			routeGuid, appGuid := routeRepo.BindArgsForCall(0)
+			Expect(routeGuid).To(Equal("route-1-guid"))
+			Expect(appGuid).To(Equal("existing-app-new-guid"))
+			routeGuid, appGuid = routeRepo.BindArgsForCall(1)
+			Expect(routeGuid).To(Equal("route-2-guid"))
+			Expect(appGuid).To(Equal("existing-app-new-guid"))
+
+			Expect(routeRepo.UnbindCallCount()).To(Equal(2))
+			routeGuid, appGuid = routeRepo.UnbindArgsForCall(0)
+			Expect(routeGuid).To(Equal("route-1-guid"))
+			Expect(appGuid).To(Equal("existing-app-guid"))
+
+			Expect(appRepo.UpdateCallCount()).To(Equal(2))
+			appGuid, params := appRepo.UpdateArgsForCall(0)
+			Expect(appGuid).To(Equal("existing-app-guid"))
+			Expect(*params.Name).To(Equal("existing-app-old"))
+			appGuid, params = appRepo.UpdateArgsForCall(1)
+			Expect(appGuid).To(Equal("existing-app-new-guid"))
+			Expect(*params.Name).To(Equal("existing-app"))
+
+			Expect(appRepo.DeleteCallCount()).To(Equal(1))
+			Expect(appRepo.DeleteArgsForCall(0)).To(Equal("existing-app-guid"))
+
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Pushing new version of", "existing-app", "existing-app-new"},
+				[]string{"Mapping route", "existing-app.example.com"},
+				[]string{"Mapping route", "www.example.com"},
+				[]string{"Renaming app", "existing-app", "existing-app-old"},
+				[]string{"Renaming app", "existing-app-new", "existing-app"},
+				[]string{"Deleting old version", "existing-app-old"},
+			))
+		})
+
+		It("fails before creating anything when the temporary name is taken", func() {
+			appRepo.ReadStub = func(name string) (models.Application, error) {
+				return models.Application{ApplicationFields: models.ApplicationFields{Name: name, Guid: name + "-guid"}}, nil
+			}
+			appSummaryRepo.GetSummaryReturns(existingApp, nil)
+
+			callPush("--strategy", "blue-green", "existing-app")
+
+			Expect(appRepo.CreateCallCount()).To(BeZero())
+			Expect(appRepo.UpdateCallCount()).To(BeZero())
+			Expect(appRepo.DeleteCallCount()).To(BeZero())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"App existing-app-new already exists"},
+			))
+		})
+
+		expectRoutesMovedBack := func() {
+			Expect(routeRepo.BindCallCount()).To(Equal(4))
+			for i, routeGuid := range []string{"route-1-guid", "route-2-guid"} {
+				boundRoute, appGuid := routeRepo.BindArgsForCall(i + 2)
+				Expect(boundRoute).To(Equal(routeGuid))
+				Expect(appGuid).To(Equal("existing-app-guid"))
+			}
+
+			Expect(routeRepo.UnbindCallCount()).To(Equal(4))
+			for i, routeGuid := range []string{"route-1-guid", "route-2-guid"} {
+				unboundRoute, appGuid := routeRepo.UnbindArgsForCall(i + 2)
+				Expect(unboundRoute).To(Equal(routeGuid))
+				Expect(appGuid).To(Equal("existing-app-new-guid"))
+			}
+		}
+
+		It("moves the routes back and deletes the new app when the old app cannot be renamed", func() {
+			appRepo.UpdateStub = func(guid string, params models.AppParams) (models.Application, error) {
+				if guid == "existing-app-guid" {
+					return models.Application{}, errors.New("rename failed")
+				}
+				return models.Application{ApplicationFields: models.ApplicationFields{Guid: guid, Name: *params.Name}}, nil
+			}
+
+			callPush("--strategy", "blue-green", "existing-app")
+
+			expectRoutesMovedBack()
+			Expect(appRepo.UpdateCallCount()).To(Equal(1))
+			Expect(appRepo.DeleteCallCount()).To(Equal(1))
+			Expect(appRepo.DeleteArgsForCall(0)).To(Equal("existing-app-new-guid"))
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Renaming app", "existing-app", "existing-app-old"},
+				[]string{"Mapping route", "existing-app.example.com", "existing-app"},
+				[]string{"Rolling back", "existing-app-new"},
+				[]string{"FAILED"},
+				[]string{"rename failed"},
+			))
+		})
+
+		It("renames the old app back, moves the routes back and deletes the new app when the new app cannot take its name", func() {
+			appRepo.UpdateStub = func(guid string, params models.AppParams) (models.Application, error) {
+				if guid == "existing-app-new-guid" {
+					return models.Application{}, errors.New("name taken")
+				}
+				return models.Application{ApplicationFields: models.ApplicationFields{Guid: guid, Name: *params.Name}}, nil
+			}
+
+			callPush("--strategy", "blue-green", "existing-app")
+
+			Expect(appRepo.UpdateCallCount()).To(Equal(3))
+			appGuid, params := appRepo.UpdateArgsForCall(2)
+			Expect(appGuid).To(Equal("existing-app-guid"))
+			Expect(*params.Name).To(Equal("existing-app"))
+
+			expectRoutesMovedBack()
+			Expect(appRepo.DeleteCallCount()).To(Equal(1))
+			Expect(appRepo.DeleteArgsForCall(0)).To(Equal("existing-app-new-guid"))
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Renaming app", "existing-app-old", "existing-app"},
+				[]string{"Mapping route", "existing-app.example.com", "existing-app"},
+				[]string{"Rolling back", "existing-app-new"},
+				[]string{"FAILED"},
+				[]string{"name taken"},
+			))
+		})
+
+		It("unbinds the moved routes and deletes the new app when a route cannot be mapped", func() {
+			routeRepo.BindStub = func(routeGuid, appGuid string) error {
+				if routeGuid == "route-2-guid" {
+					return errors.New("bind failed")
+				}
+				return nil
+			}
+
+			callPush("--strategy", "blue-green", "existing-app")
+
+			Expect(routeRepo.UnbindCallCount()).To(Equal(1))
+			routeGuid, appGuid := routeRepo.UnbindArgsForCall(0)
+			Expect(routeGuid).To(Equal("route-1-guid"))
+			Expect(appGuid).To(Equal("existing-app-new-guid"))
+
+			Expect(appRepo.DeleteCallCount()).To(Equal(1))
+			Expect(appRepo.DeleteArgsForCall(0)).To(Equal("existing-app-new-guid"))
+			Expect(appRepo.UpdateCallCount()).To(BeZero())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Rolling back", "existing-app-new"},
+				[]string{"FAILED"},
+				[]string{"bind failed"},
+			))
+		})
+
+		It("does a regular push when the app does not exist yet", func() {
+			appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "existing-app"))
+
+			callPush("--strategy", "blue-green", "existing-app")
+
+			params := appRepo.CreateArgsForCall(0)
+			Expect(*params.Name).To(Equal("existing-app"))
+			Expect(appSummaryRepo.GetSummaryCallCount()).To(BeZero())
+			Expect(appRepo.DeleteCallCount()).To(BeZero())
+		})
+
+		Context("when the new app fails to start", func() {
+			BeforeEach(func() {
+				starter.ApplicationStartStub = func(app models.Application, _, _ string) (models.Application, error) {
+					ui.Failed("Start unsuccessful")
+					return app, nil
+				}
+			})
+
+			It("deletes the new app and leaves the old app and its routes alone", func() {
+				callPush("--strategy", "blue-green", "existing-app")
+
+				Expect(appRepo.DeleteCallCount()).To(Equal(1))
+				Expect(appRepo.DeleteArgsForCall(0)).To(Equal("existing-app-new-guid"))
+				Expect(routeRepo.BindCallCount()).To(BeZero())
+				Expect(routeRepo.UnbindCallCount()).To(BeZero())
+				Expect(appRepo.UpdateCallCount()).To(BeZero())
+
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"FAILED"},
+					[]string{"Start unsuccessful"},
+					[]string{"Rolling back", "existing-app-new"},
+				))
+			})
+		})
+
+		Context("when the upload fails", func() {
+			It("deletes the new app", func() {
+				actor.UploadAppReturns(errors.New("Boom!"))
+
+				callPush("--strategy", "blue-green", "existing-app")
+
+				Expect(starter.ApplicationStartCallCount()).To(BeZero())
+				Expect(appRepo.DeleteCallCount()).To(Equal(1))
+				Expect(appRepo.DeleteArgsForCall(0)).To(Equal("existing-app-new-guid"))
+			})
+		})
+	})
+
+	Describe("rolling back a failed start", func() {
+		var existingApp models.Application
+
+		BeforeEach(func() {
+			existingApp = models.Application{}
+			existingApp.Name = "existing-app"
+			existingApp.Guid = "existing-app-guid"
+			existingApp.State = "started"
+			existingApp.PackageState = "STAGED"
+			existingApp.Memory = 256
+			existingApp.Command = "old-command"
+			existingApp.EnvironmentVars = map[string]interface{}{"crazy": "pants"}
+			existingApp.Routes = []models.RouteSummary{
+				{Guid: "route-1-guid", Host: "existing-app", Domain: models.DomainFields{Name: "example.com"}},
+			}
+
+			appRepo.ReadReturns(existingApp, nil)
+			appRepo.UpdateStub = func(guid string, params models.AppParams) (models.Application, error) {
+				a := existingApp
+				a.State = "stopped"
+				return a, nil
+			}
+			actor.GatherFilesReturns(nil, true, nil)
+
+			appBitsRepo.DownloadDropletStub = func(appGuid string, droplet io.Writer) error {
+				droplet.Write([]byte("old-droplet"))
+				return nil
+			}
+
+			starter.ApplicationStartStub = func(app models.Application, orgName, spaceName string) (models.Application, error) {
+				if starter.ApplicationStartCallCount() == 1 {
+					ui.Failed("Start unsuccessful")
+				}
+				return app, nil
+			}
+		})
+
+		It("restores the settings and droplet with --rollback-on-failure", func() {
+			var uploadedDroplet string
+			appBitsRepo.UploadDropletStub = func(appGuid string, dropletPath string) error {
+				content, err := ioutil.ReadFile(dropletPath)
+				Expect(err).NotTo(HaveOccurred())
+				uploadedDroplet = string(content)
+				return nil
+			}
+
+			callPush("--rollback-on-failure", "-m", "1G", "existing-app")
+
+			Expect(appBitsRepo.DownloadDropletCallCount()).To(Equal(1))
+			appGuid, _ := appBitsRepo.DownloadDropletArgsForCall(0)
+			Expect(appGuid).To(Equal("existing-app-guid"))
+
+			Expect(appRepo.UpdateCallCount()).To(Equal(2))
+			guid, params := appRepo.UpdateArgsForCall(1)
+			Expect(guid).To(Equal("existing-app-guid"))
+			Expect(*params.Memory).To(Equal(int64(256)))
+			Expect(*params.Command).To(Equal("old-command"))
+			Expect(*params.EnvironmentVars).To(Equal(map[string]interface{}{"crazy": "pants"}))
+			Expect(*params.State).To(Equal("STOPPED"))
+			Expect(params.Name).To(BeNil())
+
+			Expect(appBitsRepo.UploadDropletCallCount()).To(Equal(1))
+			Expect(uploadedDroplet).To(Equal("old-droplet"))
+
+			Expect(starter.ApplicationStartCallCount()).To(Equal(2))
+			Expect(ui.Prompts).To(BeEmpty())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Saving the current droplet", "existing-app"},
+				[]string{"FAILED"},
+				[]string{"Start unsuccessful"},
+				[]string{"Rolling back app", "existing-app"},
+				[]string{"Restoring the previous droplet", "existing-app"},
+			))
+
+			_, dropletPath := appBitsRepo.UploadDropletArgsForCall(0)
+			_, err := os.Stat(dropletPath)
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+
+		It("only restores the settings when the droplet cannot be saved", func() {
+			appBitsRepo.DownloadDropletStub = nil
+			appBitsRepo.DownloadDropletReturns(errors.New("no droplet for you"))
+
+			callPush("--rollback-on-failure", "existing-app")
+
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Could not save the droplet", "no droplet for you"},
+				[]string{"Rolling back app", "existing-app"},
+				[]string{"previous droplet was not saved", "left stopped"},
+			))
+			Expect(appRepo.UpdateCallCount()).To(Equal(2))
+			Expect(appBitsRepo.UploadDropletCallCount()).To(BeZero())
+			Expect(starter.ApplicationStartCallCount()).To(Equal(1))
+		})
+
+		It("unmaps the routes and unbinds the services added by the push", func() {
+			existingApp.Services = []models.ServicePlanSummary{{Guid: "service-1-guid", Name: "existing-service"}}
+			pushedApp := existingApp
+			pushedApp.Routes = append(pushedApp.Routes, models.RouteSummary{Guid: "route-2-guid", Host: "new-host", Domain: models.DomainFields{Name: "example.com"}})
+			pushedApp.Services = append(pushedApp.Services, models.ServicePlanSummary{Guid: "service-2-guid", Name: "new-service"})
+			appSummaryRepo.GetSummaryStub = func(string) (models.Application, error) {
+				if appSummaryRepo.GetSummaryCallCount() == 1 {
+					return existingApp, nil
+				}
+				return pushedApp, nil
+			}
+			serviceRepo.FindInstanceByNameStub = func(name string) (models.ServiceInstance, error) {
+				return maker.NewServiceInstance(name), nil
+			}
+
+			callPush("--rollback-on-failure", "existing-app")
+
+			Expect(routeRepo.UnbindCallCount()).To(Equal(1))
+			routeGuid, appGuid := routeRepo.UnbindArgsForCall(0)
+			Expect(routeGuid).To(Equal("route-2-guid"))
+			Expect(appGuid).To(Equal("existing-app-guid"))
+
+			Expect(bindingRepo.DeleteServiceInstance.Name).To(Equal("new-service"))
+			Expect(bindingRepo.DeleteApplicationGuid).To(Equal("existing-app-guid"))
+
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Rolling back app", "existing-app"},
+				[]string{"Unmapping route", "new-host.example.com", "existing-app"},
+				[]string{"Unbinding service", "new-service", "existing-app"},
+				[]string{"Restoring the previous droplet", "existing-app"},
+			))
+		})
+
+		It("warns that the routes and services are not restored when they cannot be read", func() {
+			appSummaryRepo.GetSummaryReturns(models.Application{}, errors.New("no summary"))
+
+			callPush("--rollback-on-failure", "existing-app")
+
+			Expect(appSummaryRepo.GetSummaryCallCount()).To(Equal(1))
+			Expect(routeRepo.UnbindCallCount()).To(BeZero())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Could not read the routes and services of app existing-app", "will not restore them", "no summary"},
+				[]string{"Rolling back app", "existing-app"},
+			))
+		})
+
+		It("does not save the droplet of an app that is not staged", func() {
+			existingApp.PackageState = "FAILED"
+			appRepo.ReadReturns(existingApp, nil)
+
+			callPush("--rollback-on-failure", "existing-app")
+
+			Expect(appBitsRepo.DownloadDropletCallCount()).To(BeZero())
+			Expect(appRepo.UpdateCallCount()).To(Equal(2))
+		})
+
+		Context("without --rollback-on-failure", func() {
+			It("restores the settings when the user agrees", func() {
+				ui.Inputs = []string{"y"}
+
+				callPush("existing-app")
+
+				Expect(ui.Prompts).To(ContainSubstrings([]string{"Restore the previous settings, routes and service bindings of app existing-app?", "droplet was not saved", "left stopped"}))
+				Expect(appBitsRepo.DownloadDropletCallCount()).To(BeZero())
+				Expect(appRepo.UpdateCallCount()).To(Equal(2))
+				_, params := appRepo.UpdateArgsForCall(1)
+				Expect(*params.Memory).To(Equal(int64(256)))
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"previous droplet was not saved", "--rollback-on-failure"},
+				))
+			})
+
+			It("leaves the app alone when the user declines", func() {
+				ui.Inputs = []string{"n"}
+
+				callPush("existing-app")
+
+				Expect(appRepo.UpdateCallCount()).To(Equal(1))
+				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"Rolling back"}))
+			})
+		})
+
+		It("does not offer a rollback when the push succeeds", func() {
+			starter.ApplicationStartStub = nil
+
+			callPush("existing-app")
+
+			Expect(ui.Prompts).To(BeEmpty())
+			Expect(appRepo.UpdateCallCount()).To(Equal(1))
+		})
+	})
+
+	Describe("dry run", func() {
+		var existingApp models.Application
+
+		BeforeEach(func() {
+			existingApp = models.Application{}
+			existingApp.Name = "existing-app"
+			existingApp.Guid = "existing-app-guid"
+			existingApp.State = "started"
+			existingApp.Memory = 256
+			existingApp.InstanceCount = 3
+			existingApp.EnvironmentVars = map[string]interface{}{"crazy": "pants"}
+			existingApp.Routes = []models.RouteSummary{
+				{Guid: "route-1-guid", Host: "existing-app", Domain: models.DomainFields{Name: "example.com"}},
+			}
+			existingApp.Services = []models.ServicePlanSummary{{Name: "existing-service"}}
+
+			serviceRepo.FindInstanceByNameStub = func(name string) (models.ServiceInstance, error) {
+				return maker.NewServiceInstance(name), nil
+			}
+			routeRepo.FindReturns(models.Route{}, errors.NewModelNotFoundError("Route", "not found"))
+		})
+
+		expectNothingChanged := func() {
+			Expect(appRepo.CreateCallCount()).To(BeZero())
+			Expect(appRepo.UpdateCallCount()).To(BeZero())
+			Expect(appRepo.DeleteCallCount()).To(BeZero())
+			Expect(routeRepo.CreateCallCount()).To(BeZero())
+			Expect(routeRepo.BindCallCount()).To(BeZero())
+			Expect(routeRepo.UnbindCallCount()).To(BeZero())
+			Expect(len(serviceBinder.AppsToBind)).To(BeZero())
+			Expect(actor.ProcessPathCallCount()).To(BeZero())
+			Expect(actor.UploadAppCallCount()).To(BeZero())
+			Expect(starter.ApplicationStartCallCount()).To(BeZero())
+			Expect(stopper.ApplicationStopCallCount()).To(BeZero())
+		}
+
+		Context("when the app exists", func() {
+			BeforeEach(func() {
+				appRepo.ReadReturns(existingApp, nil)
+				appSummaryRepo.GetSummaryReturns(existingApp, nil)
+			})
+
+			It("shows the changed settings and the actions without changing anything", func() {
+				Expect(callPush("--dry-run", "-m", "512M", "-i", "3", "-c", "null", "existing-app")).To(BeTrue())
+
+				expectNothingChanged()
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Planned changes for app", "existing-app", "my-org", "my-space", "my-user", "dry run"},
+					[]string{"property", "current", "after push"},
+					[]string{"memory", "256M", "512M"},
+					[]string{"Actions:"},
+					[]string{"1. update app existing-app"},
+					[]string{"2. upload app files from"},
+					[]string{"3. restart app existing-app"},
+					[]string{"Dry run complete, nothing was changed."},
+				))
+				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"instances"}))
+				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"command", "default"}))
+				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"route"}))
+			})
+
+			It("shows the routes, services and env that would be added", func() {
+				manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+					Data: generic.NewMap(map[interface{}]interface{}{
+						"applications": []interface{}{
+							generic.NewMap(map[interface{}]interface{}{
+								"name":     "existing-app",
+								"services": []interface{}{"existing-service", "new-service"},
+								"env":      generic.NewMap(map[interface{}]interface{}{"crazy": "pants", "FOO": "bar"}),
+								"host":     "new-host",
+							}),
+						},
+					}),
+				}
+
+				callPush("--dry-run")
+
+				expectNothingChanged()
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"env FOO", "bar"},
+					[]string{"routes", "existing-app.example.com", "existing-app.example.com, new-host.foo.cf-app.com"},
+					[]string{"services", "existing-service", "existing-service, new-service"},
+					[]string{"1. update app existing-app"},
+					[]string{"2. create route new-host.foo.cf-app.com"},
+					[]string{"3. map route new-host.foo.cf-app.com"},
+					[]string{"4. upload app files from"},
+					[]string{"5. bind service new-service"},
+					[]string{"6. restart app existing-app"},
+				))
+				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"env crazy"}))
+			})
+
+			It("shows the routes that would be unmapped with --no-route", func() {
+				callPush("--dry-run", "--no-route", "--no-start", "existing-app")
+
+				expectNothingChanged()
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"routes", "existing-app.example.com"},
+					[]string{"1. unmap route existing-app.example.com"},
+					[]string{"2. upload app files from"},
+					[]string{"3. stop app existing-app"},
+				))
+			})
+		})
+
+		Context("when the app does not exist", func() {
+			BeforeEach(func() {
+				appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "new-app"))
+			})
+
+			It("shows the app would be created with a route and started", func() {
+				callPush("--dry-run", "-m", "1G", "--random-route", "new-app")
+
+				expectNothingChanged()
+				Expect(appSummaryRepo.GetSummaryCallCount()).To(BeZero())
+				Expect(wordGenerator.BabbleCallCount()).To(BeZero())
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"memory", "1G"},
+					[]string{"routes", "new-app-RANDOM.foo.cf-app.com"},
+					[]string{"1. create app new-app"},
+					[]string{"2. create route new-app-RANDOM.foo.cf-app.com"},
+					[]string{"3. map route new-app-RANDOM.foo.cf-app.com"},
+					[]string{"4. upload app files from"},
+					[]string{"5. start app new-app"},
+				))
+			})
+
+			It("warns about services that cannot be found", func() {
+				serviceRepo.FindInstanceByNameStub = nil
+				serviceRepo.FindInstanceByNameReturns(models.ServiceInstance{}, errors.New("not found"))
+				manifestRepo.ReadManifestReturns.Manifest = manifestWithServicesAndEnv()
+
+				callPush("--dry-run")
+
+				expectNothingChanged()
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"bind service app1-service", "could not be found"},
+					[]string{"bind service app2-service", "could not be found"},
+				))
+				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"FAILED"}))
+			})
+		})
+
+		It("fails when combined with a strategy", func() {
+			Expect(callPush("--dry-run", "--strategy", "blue-green", "existing-app")).To(BeFalse())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"cannot be used with --dry-run"},
+			))
+		})
+	})
+
+	Describe("manifest hooks", func() {
+		var steps []string
+
+		BeforeEach(func() {
+			steps = []string{}
+
+			manifestRepo.ReadManifestReturns.Manifest = &manifest.Manifest{
+				Path: filepath.Join("some", "dir", "manifest.yml"),
+				Data: generic.NewMap(map[interface{}]interface{}{
+					"applications": []interface{}{
+						generic.NewMap(map[interface{}]interface{}{
+							"name": "hooked-app",
+							"hooks": map[interface{}]interface{}{
+								"pre-push":   "npm run build",
+								"post-push":  "./check.sh",
+								"post-start": []interface{}{"./smoke-test.sh", "./notify.sh"},
+							},
+						}),
+					},
+				}),
+			}
+
+			appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "hooked-app"))
+			appRepo.CreateStub = func(params models.AppParams) (models.Application, error) {
+				steps = append(steps, "create")
+				return models.Application{ApplicationFields: models.ApplicationFields{
+					Name:  *params.Name,
+					Guid:  "hooked-app-guid",
+					State: "stopped",
+				}}, nil
+			}
+			appSummaryRepo.GetSummaryReturns(models.Application{
+				Routes: []models.RouteSummary{
+					{Host: "hooked-app", Domain: models.DomainFields{Name: "example.com"}},
+					{Host: "www", Domain: models.DomainFields{Name: "example.com"}},
+				},
+			}, nil)
+			actor.GatherFilesReturns(nil, true, nil)
+			actor.UploadAppStub = func(string, application_bits.ZipSource, int64, []resources.AppFileResource) error {
+				steps = append(steps, "upload")
+				return nil
+			}
+			starter.ApplicationStartStub = func(app models.Application, orgName string, spaceName string) (models.Application, error) {
+				steps = append(steps, "start")
+				return app, nil
+			}
+			shellRunner.RunStub = func(command string, dir string, env []string, output io.Writer) error {
+				steps = append(steps, command)
+				output.Write([]byte("output of " + command + "\n"))
+				return nil
+			}
+		})
+
+		It("runs the hooks of each stage from the manifest directory", func() {
+			Expect(callPush()).To(BeTrue())
+
+			Expect(steps).To(Equal([]string{
+				"npm run build",
+				"create",
+				"upload",
+				"./check.sh",
+				"start",
+				"./smoke-test.sh",
+				"./notify.sh",
+			}))
+
+			_, dir, _, _ := shellRunner.RunArgsForCall(0)
+			Expect(dir).To(Equal(filepath.Join("some", "dir")))
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Running pre-push hook for app", "hooked-app", "npm run build"},
+				[]string{"output of npm run build"},
+				[]string{"OK"},
+			))
+		})
+
+		It("passes the app and the target in environment variables", func() {
+			callPush()
+
+			_, _, prePushEnv, _ := shellRunner.RunArgsForCall(0)
+			Expect(prePushEnv).To(ContainElement("CF_HOOK=pre-push"))
+			Expect(prePushEnv).To(ContainElement("CF_APP_NAME=hooked-app"))
+			Expect(prePushEnv).To(ContainElement("CF_APP_GUID="))
+
+			_, _, postStartEnv, _ := shellRunner.RunArgsForCall(2)
+			Expect(postStartEnv).To(ContainElement("CF_HOOK=post-start"))
+			Expect(postStartEnv).To(ContainElement("CF_APP_GUID=hooked-app-guid"))
+			Expect(postStartEnv).To(ContainElement("CF_APP_ROUTES=hooked-app.example.com,www.example.com"))
+			Expect(postStartEnv).To(ContainElement("CF_ORG=my-org"))
+			Expect(postStartEnv).To(ContainElement("CF_SPACE=my-space"))
+		})
+
+		It("aborts the push when a hook fails", func() {
+			shellRunner.RunStub = func(command string, dir string, env []string, output io.Writer) error {
+				steps = append(steps, command)
+				return errors.New("exit status 1")
+			}
+
+			callPush()
+
+			Expect(steps).To(Equal([]string{"npm run build"}))
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"The pre-push hook 'npm run build' of app hooked-app failed: exit status 1"},
+			))
+		})
+
+		It("does not run the post-start hooks with --no-start", func() {
+			callPush("--no-start")
+
+			Expect(steps).To(Equal([]string{"npm run build", "create", "upload", "./check.sh"}))
+		})
+
+		It("lists the hooks in the dry run actions", func() {
+			callPush("--dry-run")
+
+			Expect(shellRunner.RunCallCount()).To(BeZero())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"1. run pre-push hook: npm run build"},
+				[]string{"2. create app hooked-app"},
+				[]string{"run post-push hook: ./check.sh"},
+				[]string{"start app hooked-app"},
+				[]string{"run post-start hook: ./smoke-test.sh"},
+				[]string{"run post-start hook: ./notify.sh"},
+			))
+		})
+	})
+
+	Describe("service instances", func() {
+		BeforeEach(func() {
+			serviceRepo.FindInstanceByNameStub = func(name string) (models.ServiceInstance, error) {
+				return maker.NewServiceInstance(name), nil
+			}
+
+			appRepo.CreateStub = func(params models.AppParams) (models.Application, error) {
+				a := models.Application{}
+				a.Name = *params.Name
+
+				return a, nil
+			}
+
+			manifestRepo.ReadManifestReturns.Manifest = manifestWithServicesAndEnv()
+		})
+
+		Context("when the service is not bound", func() {
+			BeforeEach(func() {
+				appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "the-app"))
+			})
+
+			It("binds service instances to the app", func() {
+				callPush()
+				Expect(len(serviceBinder.AppsToBind)).To(Equal(4))
+				Expect(serviceBinder.AppsToBind[0].Name).To(Equal("app1"))
+				Expect(serviceBinder.AppsToBind[1].Name).To(Equal("app1"))
+				Expect(serviceBinder.InstancesToBindTo[0].Name).To(Equal("app1-service"))
+				Expect(serviceBinder.InstancesToBindTo[1].Name).To(Equal("global-service"))
+
+				Expect(serviceBinder.AppsToBind[2].Name).To(Equal("app2"))
+				Expect(serviceBinder.AppsToBind[3].Name).To(Equal("app2"))
+				Expect(serviceBinder.InstancesToBindTo[2].Name).To(Equal("app2-service"))
+				Expect(serviceBinder.InstancesToBindTo[3].Name).To(Equal("global-service"))
+
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Creating", "app1"},
+					[]string{"OK"},
+					[]string{"Binding service", "app1-service", "app1", "my-org", "my-space", "my-user"},
+					[]string{"OK"},
+					[]string{"Binding service", "global-service", "app1", "my-org", "my-space", "my-user"},
+					[]string{"OK"},
+					[]string{"Creating", "app2"},
+					[]string{"OK"},
+					[]string{"Binding service", "app2-service", "app2", "my-org", "my-space", "my-user"},
+					[]string{"OK"},
+					[]string{"Binding service", "global-service", "app2", "my-org", "my-space", "my-user"},
+					[]string{"OK"},
+				))
+			})
+		})
+
+		Context("when the app is already bound to the service", func() {
+			BeforeEach(func() {
+				appRepo.ReadReturns(maker.NewApp(maker.Overrides{}), nil)
+				serviceBinder.BindApplicationReturns.Error = errors.NewHttpError(500, "90003", "it don't work")
+			})
+
+			It("gracefully continues", func() {
+				callPush()
+				Expect(len(serviceBinder.AppsToBind)).To(Equal(4))
+				Expect(ui.Outputs).ToNot(ContainSubstrings([]string{"FAILED"}))
+			})
+		})
+
+		Context("when the service instance can't be found", func() {
+			BeforeEach(func() {
+				serviceRepo.FindInstanceByNameReturns(models.ServiceInstance{}, errors.New("Error finding instance"))
+				manifestRepo.ReadManifestReturns.Manifest = manifestWithServicesAndEnv()
+			})
+
+			It("fails with an error", func() {
+				callPush()
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"FAILED"},
+					[]string{"Could not find service", "app1-service", "app1"},
+				))
+			})
+		})
+
+	})
+
+	Describe("checking for bad flags", func() {
+		It("fails when a non-numeric start timeout is given", func() {
+			appRepo.ReadReturns(models.Application{}, errors.NewModelNotFoundError("App", "the-app"))
+
+			callPush("-t", "FooeyTimeout", "app-name")
+
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"Invalid", "timeout", "FooeyTimeout"},
+			))
+		})
+	})
+
+	Describe("displaying information about files being uploaded", func() {
+		It("displays information about the files being uploaded", func() {
+			appfiles.CountFilesReturns(11)
+			appfiles.AppFilesInDirReturns([]models.AppFileFields{
+				{Path: "path/to/app", Size: 1000},
+				{Path: "bar", Size: 6100000},
+				{Path: "already/uploaded", Size: 9001},
+			}, nil)
+			zipper.ZipReturns(nil)
+			actor.GatherFilesReturns([]resources.AppFileResource{resources.AppFileResource{Path: "already/uploaded"}}, true, nil)
+
+			curDir, err := os.Getwd()
+			Expect(err).NotTo(HaveOccurred())
+
+			callPush("appName")
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Uploading", curDir},
+				[]string{"5.8M", "11 files"},
+			))
+		})
+	})
+
+	Describe("streaming the application bits", func() {
+		BeforeEach(func() {
+			appfiles.AppFilesInDirReturns([]models.AppFileFields{{Path: "app.rb", Size: 12}}, nil)
+		})
+
+		It("zips the files to upload straight into the upload", func() {
+			zipper.ZipStub = func(dirToZip string, target io.Writer) error {
+				_, err := target.Write([]byte("zip contents"))
+				return err
+			}
+			actor.GatherFilesReturns([]resources.AppFileResource{}, true, nil)
+
+			callPush("appName")
+
+			Expect(actor.UploadAppCallCount()).To(Equal(1))
+			_, zipSource, _, _ := actor.UploadAppArgsForCall(0)
+			Expect(zipSource).NotTo(BeNil())
+
+			zipContents := &bytes.Buffer{}
+			Expect(zipSource(zipContents)).To(Succeed())
+			Expect(zipContents.String()).To(Equal("zip contents"))
+
+			uploadDir, _ := zipper.ZipArgsForCall(0)
+			_, _, gatheredUploadDir := actor.GatherFilesArgsForCall(0)
+			Expect(uploadDir).To(Equal(gatheredUploadDir))
+		})
+
+		It("gives the size of the files that are not already uploaded as the estimated zip size", func() {
+			appfiles.AppFilesInDirReturns([]models.AppFileFields{
+				{Path: "app.rb", Size: 12},
+				{Path: "already/uploaded", Size: 1000},
+				{Path: "Gemfile", Size: 30},
+			}, nil)
+			actor.GatherFilesReturns([]resources.AppFileResource{{Path: "already/uploaded"}}, true, nil)
+
+			callPush("appName")
+
+			_, _, zipSize, _ := actor.UploadAppArgsForCall(0)
+			Expect(zipSize).To(Equal(int64(42)))
+		})
+
+		It("zips the files reproducibly with --reproducible", func() {
+			actor.GatherFilesReturns([]resources.AppFileResource{}, true, nil)
+
+			callPush("appName", "--reproducible")
+
+			_, zipSource, _, _ := actor.UploadAppArgsForCall(0)
+			Expect(zipSource(&bytes.Buffer{})).To(Succeed())
+
+			Expect(zipper.ZipReproduciblyCallCount()).To(Equal(1))
+			Expect(zipper.ZipCallCount()).To(Equal(0))
+		})
+
+		Context("when the upload fails because of the network", func() {
+			BeforeEach(func() {
+				actor.GatherFilesReturns([]resources.AppFileResource{}, true, nil)
+				actor.UploadAppStub = func(string, application_bits.ZipSource, int64, []resources.AppFileResource) error {
+					if actor.UploadAppCallCount() == 1 {
+						return errors.NewNetworkError("connection reset by peer")
+					}
+					return nil
+				}
+			})
+
+			It("matches the resources again and retries the upload", func() {
+				callPush("appName")
+
+				Expect(actor.GatherFilesCallCount()).To(Equal(2))
+				Expect(actor.UploadAppCallCount()).To(Equal(2))
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Uploading failed: connection reset by peer"},
+					[]string{"Retrying", "(1 of 2)"},
+					[]string{"OK"},
+				))
+				Expect(ui.Outputs).NotTo(ContainSubstrings([]string{"FAILED"}))
+			})
+
+			It("fails without retrying with --upload-retries 0", func() {
+				callPush("appName", "--upload-retries", "0")
+
+				Expect(actor.UploadAppCallCount()).To(Equal(1))
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"FAILED"},
+					[]string{"connection reset by peer"},
+				))
+			})
+		})
+
+		It("fails once the upload retries are used up", func() {
+			actor.GatherFilesReturns([]resources.AppFileResource{}, true, nil)
+			actor.UploadAppReturns(errors.NewHttpError(503, "", "Service Unavailable"))
+
+			callPush("appName", "--upload-retries", "3")
+
+			Expect(actor.UploadAppCallCount()).To(Equal(4))
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Retrying", "(3 of 3)"},
+				[]string{"FAILED"},
+				[]string{"Service Unavailable"},
+			))
+		})
+
+		It("fails when --upload-retries is negative", func() {
+			Expect(callPush("appName", "--upload-retries", "-1")).To(BeFalse())
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"FAILED"},
+				[]string{"Invalid value for --upload-retries: -1"},
+			))
+		})
+
+		It("does not retry uploads rejected by the server", func() {
+			actor.GatherFilesReturns([]resources.AppFileResource{}, true, nil)
+			actor.UploadAppReturns(errors.NewHttpError(400, "170001", "Staging error"))
+
+			callPush("appName")
+
+			Expect(actor.UploadAppCallCount()).To(Equal(1))
+			Expect(ui.Outputs).NotTo(ContainSubstrings([]string{"Retrying"}))
+		})
+
+		It("does not send a zip when every file is already on the server", func() {
+			actor.GatherFilesReturns([]resources.AppFileResource{{Path: "app.rb"}}, false, nil)
+
+			callPush("appName")
+
+			Expect(actor.UploadAppCallCount()).To(Equal(1))
+			_, zipSource, _, _ := actor.UploadAppArgsForCall(0)
+			Expect(zipSource).To(BeNil())
+			Expect(zipper.ZipCallCount()).To(Equal(0))
+		})
 	})
 
 	It("fails when the app can't be uploaded", func() {
@@ -1,15 +1,20 @@
 package application
 
 import (
+	"sort"
+	"strings"
+
 	"github.com/cloudfoundry/cli/cf"
 	"github.com/cloudfoundry/cli/cf/api/applications"
 	"github.com/cloudfoundry/cli/cf/command_registry"
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
 	. "github.com/cloudfoundry/cli/cf/i18n"
+	"github.com/cloudfoundry/cli/cf/manifest"
 	"github.com/cloudfoundry/cli/cf/models"
 	"github.com/cloudfoundry/cli/cf/requirements"
 	"github.com/cloudfoundry/cli/cf/terminal"
 	"github.com/cloudfoundry/cli/flags"
+	"github.com/cloudfoundry/cli/flags/flag"
 )
 
 type SetEnv struct {
@@ -24,17 +29,35 @@ func init() {
 }
 
 func (cmd *SetEnv) MetaData() command_registry.CommandMetadata {
+	fs := make(map[string]flags.FlagSet)
+	// listed for the help only: flag parsing is skipped so that values can
+	// begin with a hyphen, and envFileArg reads the option by hand
+	fs["env-file"] = &cliFlags.StringFlag{Name: "env-file", Usage: T("Path to a file of KEY=VALUE lines to set as env variables, given as the only option after APP_NAME")}
+
 	return command_registry.CommandMetadata{
 		Name:            "set-env",
 		ShortName:       "se",
 		Description:     T("Set an env variable for an app"),
-		Usage:           T("CF_NAME set-env APP_NAME ENV_VAR_NAME ENV_VAR_VALUE"),
+		Usage:           T("CF_NAME set-env APP_NAME ENV_VAR_NAME ENV_VAR_VALUE") + "\n   " + T("CF_NAME set-env APP_NAME --env-file ENV_FILE_PATH"),
+		Flags:           fs,
 		SkipFlagParsing: true,
 	}
 }
 
+// envFileArg finds the --env-file option by hand, since flag parsing is
+// skipped so that values can begin with a hyphen.
+func envFileArg(args []string) (string, bool) {
+	switch {
+	case len(args) == 3 && args[1] == "--env-file":
+		return args[2], true
+	case len(args) == 2 && strings.HasPrefix(args[1], "--env-file="):
+		return strings.TrimPrefix(args[1], "--env-file="), true
+	}
+	return "", false
+}
+
 func (cmd *SetEnv) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) (reqs []requirements.Requirement, err error) {
-	if len(fc.Args()) != 3 {
+	if _, ok := envFileArg(fc.Args()); !ok && len(fc.Args()) != 3 {
 		cmd.ui.Failed(T("Incorrect Usage. Requires 'app-name env-name env-value' as arguments\n\n") + command_registry.Commands.CommandUsage("set-env"))
 	}
 
@@ -56,9 +79,41 @@ func (cmd *SetEnv) SetDependency(deps command_registry.Dependency, pluginCall bo
 }
 
 func (cmd *SetEnv) Execute(c flags.FlagContext) {
+	app := cmd.appReq.GetApplication()
+
+	if envFile, ok := envFileArg(c.Args()); ok {
+		vars, err := manifest.ReadEnvFile(envFile)
+		if err != nil {
+			cmd.ui.Failed(err.Error())
+			return
+		}
+
+		if len(vars) == 0 {
+			cmd.ui.Failed(T("No env variables found in {{.EnvFile}}", map[string]interface{}{"EnvFile": envFile}))
+			return
+		}
+
+		varNames := []string{}
+		for varName := range vars {
+			varNames = append(varNames, varName)
+		}
+		sort.Strings(varNames)
+
+		cmd.ui.Say(T("Setting env variables {{.VarNames}} from {{.EnvFile}} for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.CurrentUser}}...",
+			map[string]interface{}{
+				"VarNames":    terminal.EntityNameColor(strings.Join(varNames, ", ")),
+				"EnvFile":     terminal.EntityNameColor(envFile),
+				"AppName":     terminal.EntityNameColor(app.Name),
+				"OrgName":     terminal.EntityNameColor(cmd.config.OrganizationFields().Name),
+				"SpaceName":   terminal.EntityNameColor(cmd.config.SpaceFields().Name),
+				"CurrentUser": terminal.EntityNameColor(cmd.config.Username())}))
+
+		cmd.setEnvVars(app, vars)
+		return
+	}
+
 	varName := c.Args()[1]
 	varValue := c.Args()[2]
-	app := cmd.appReq.GetApplication()
 
 	cmd.ui.Say(T("Setting env variable '{{.VarName}}' to '{{.VarValue}}' for app {{.AppName}} in org {{.OrgName}} / space {{.SpaceName}} as {{.CurrentUser}}...",
 		map[string]interface{}{
@@ -69,14 +124,19 @@ func (cmd *SetEnv) Execute(c flags.FlagContext) {
 			"SpaceName":   terminal.EntityNameColor(cmd.config.SpaceFields().Name),
 			"CurrentUser": terminal.EntityNameColor(cmd.config.Username())}))
 
+	cmd.setEnvVars(app, map[string]string{varName: varValue})
+}
+
+func (cmd *SetEnv) setEnvVars(app models.Application, vars map[string]string) {
 	if len(app.EnvironmentVars) == 0 {
 		app.EnvironmentVars = map[string]interface{}{}
 	}
 	envParams := app.EnvironmentVars
-	envParams[varName] = varValue
+	for varName, varValue := range vars {
+		envParams[varName] = varValue
+	}
 
 	_, apiErr := cmd.appRepo.Update(app.Guid, models.AppParams{EnvironmentVars: &envParams})
-
 	if apiErr != nil {
 		cmd.ui.Failed(apiErr.Error())
 		return
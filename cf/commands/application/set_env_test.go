@@ -2,6 +2,7 @@ package application_test
 
 import (
 	"errors"
+	"path/filepath"
 
 	testApplication "github.com/cloudfoundry/cli/cf/api/applications/fakes"
 	"github.com/cloudfoundry/cli/cf/command_registry"
@@ -154,6 +155,45 @@ var _ = Describe("set-env command", func() {
 			}))
 		})
 
+		Context("when given an env file", func() {
+			var envFile string
+
+			BeforeEach(func() {
+				envFile = filepath.Join("..", "..", "..", "fixtures", "manifests", "env_file", "app.env")
+			})
+
+			It("sets every variable of the file", func() {
+				runCommand("my-app", "--env-file", envFile)
+
+				Expect(ui.Outputs).To(ContainSubstrings(
+					[]string{"Setting env variables", "DATABASE_URL", "LOG_LEVEL", "app.env", "my-app"},
+					[]string{"OK"},
+					[]string{"TIP"},
+				))
+
+				Expect(appRepo.UpdateCallCount()).To(Equal(1))
+				_, params := appRepo.UpdateArgsForCall(0)
+				Expect((*params.EnvironmentVars)["foo"]).To(Equal("bar"))
+				Expect((*params.EnvironmentVars)["LOG_LEVEL"]).To(Equal("debug"))
+				Expect((*params.EnvironmentVars)["CERTIFICATE"]).To(ContainSubstring("\n-----END CERTIFICATE-----"))
+			})
+
+			It("accepts the path after an equals sign", func() {
+				runCommand("my-app", "--env-file="+envFile)
+
+				Expect(appRepo.UpdateCallCount()).To(Equal(1))
+				_, params := appRepo.UpdateArgsForCall(0)
+				Expect((*params.EnvironmentVars)["DATABASE_URL"]).To(Equal("postgres://db.example.com/app?sslmode=require"))
+			})
+
+			It("fails without updating the app when the file cannot be read", func() {
+				runCommand("my-app", "--env-file", "does-not-exist.env")
+
+				Expect(ui.Outputs).To(ContainSubstrings([]string{"FAILED"}))
+				Expect(appRepo.UpdateCallCount()).To(Equal(0))
+			})
+		})
+
 		Context("when setting fails", func() {
 			BeforeEach(func() {
 				appRepo.UpdateReturns(models.Application{}, errors.New("Error updating app."))
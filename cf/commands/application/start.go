@@ -40,6 +40,7 @@ type ApplicationStarter interface {
 	command_registry.Command
 	SetStartTimeoutInSeconds(timeout int)
 	ApplicationStart(app models.Application, orgName string, spaceName string) (updatedApp models.Application, err error)
+	WithUI(ui terminal.UI) ApplicationStarter
 }
 
 type Start struct {
@@ -50,6 +51,7 @@ type Start struct {
 	appRepo          applications.ApplicationRepository
 	appInstancesRepo app_instances.AppInstancesRepository
 	logRepo          api.LogsRepository
+	newLogRepo       func() api.LogsRepository
 
 	LogServerConnectionTimeout time.Duration
 	StartupTimeout             time.Duration
@@ -87,6 +89,7 @@ func (cmd *Start) SetDependency(deps command_registry.Dependency, pluginCall boo
 	cmd.appRepo = deps.RepoLocator.GetApplicationRepository()
 	cmd.appInstancesRepo = deps.RepoLocator.GetAppInstancesRepository()
 	cmd.logRepo = deps.RepoLocator.GetLogsRepository()
+	cmd.newLogRepo = deps.RepoLocator.NewLogsRepository
 	cmd.LogServerConnectionTimeout = 20 * time.Second
 	cmd.PingerThrottle = DefaultPingerThrottle
 
@@ -142,25 +145,44 @@ func (cmd *Start) ApplicationStart(app models.Application, orgName, spaceName st
 	})
 }
 
+// WithUI returns a copy of the starter that writes to ui, so that several apps
+// can be started at once. The copy tails staging logs with its own logs
+// repository, and does not show the app once it is started, as the app
+// displayer shares the UI of the command.
+func (cmd *Start) WithUI(ui terminal.UI) ApplicationStarter {
+	starter := *cmd
+	starter.ui = ui
+	starter.logRepo = nil
+	if cmd.newLogRepo != nil {
+		starter.logRepo = cmd.newLogRepo()
+	}
+	starter.appDisplayer = nil
+	return &starter
+}
+
 func (cmd *Start) ApplicationWatchStaging(app models.Application, orgName, spaceName string, start func(app models.Application) (models.Application, error)) (updatedApp models.Application, err error) {
 	var isConnected bool
 	loggingStartedChan := make(chan bool)
 	doneLoggingChan := make(chan bool)
 
-	go cmd.tailStagingLogs(app, loggingStartedChan, doneLoggingChan)
-	timeout := make(chan struct{})
-	go func() {
-		time.Sleep(cmd.LogServerConnectionTimeout)
-		close(timeout)
-	}()
-
-	select {
-	case <-timeout:
-		cmd.ui.Warn("timeout connecting to log server, no log will be shown")
-		break
-	case <-loggingStartedChan: // block until we have established connection to Loggregator
-		isConnected = true
-		break
+	if cmd.logRepo != nil {
+		go cmd.tailStagingLogs(app, loggingStartedChan, doneLoggingChan)
+		timeout := make(chan struct{})
+		go func() {
+			time.Sleep(cmd.LogServerConnectionTimeout)
+			close(timeout)
+		}()
+
+		select {
+		case <-timeout:
+			cmd.ui.Warn("timeout connecting to log server, no log will be shown")
+			break
+		case <-loggingStartedChan: // block until we have established connection to Loggregator
+			isConnected = true
+			break
+		}
+	} else {
+		close(doneLoggingChan)
 	}
 
 	updatedApp, apiErr := start(app)
@@ -208,7 +230,9 @@ func (cmd *Start) ApplicationWatchStaging(app models.Application, orgName, space
 			"Command": appStartCommand,
 		}))
 
-	cmd.appDisplayer.ShowApp(startedApp, orgName, spaceName)
+	if cmd.appDisplayer != nil {
+		cmd.appDisplayer.ShowApp(startedApp, orgName, spaceName)
+	}
 	return
 }
 
@@ -231,7 +255,7 @@ func (cmd *Start) tailStagingLogs(app models.Application, startChan, doneChan ch
 		startChan <- true
 	}
 
-	err := cmd.logRepo.TailLogsFor(app.Guid, onConnect, func(msg *logmessage.LogMessage) {
+	err := cmd.logRepo.TailLogsFor(app.Guid, api.LogFilter{}, onConnect, func(msg *logmessage.LogMessage) {
 		if msg.GetSourceName() == LogMessageTypeStaging {
 			cmd.ui.Say(simpleLogMessageOutput(msg))
 		}
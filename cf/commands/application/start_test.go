@@ -139,7 +139,7 @@ var _ = Describe("start command", func() {
 		mutex.Lock()
 		logMessages = []*logmessage.LogMessage{}
 		mutex.Unlock()
-		logRepo.TailLogsForStub = func(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+		logRepo.TailLogsForStub = func(appGuid string, filter api.LogFilter, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
 			onConnect()
 			mutex.Lock()
 			for _, log := range logMessages {
@@ -380,10 +380,32 @@ var _ = Describe("start command", func() {
 			))
 		})
 
+		It("displays staging logs when started with another UI", func() {
+			appRepo.UpdateReturns(defaultAppForStart, nil)
+			appRepo.ReadReturns(defaultAppForStart, nil)
+			appRepo.GetAppReturns(defaultAppForStart, nil)
+			appInstancesRepo.GetInstancesStub = getInstance
+			logMessages = []*logmessage.LogMessage{
+				testlogs.NewLogMessage("Staging Line", defaultAppForStart.Guid, "STG", "1", logmessage.LogMessage_OUT, time.Now()),
+			}
+
+			updateCommandDependency(logRepo)
+			cmd := command_registry.Commands.FindCommand("start").(*Start)
+			cmd.StartupTimeout = 200 * time.Millisecond
+			cmd.PingerThrottle = 50 * time.Millisecond
+
+			otherUI := new(testterm.FakeUI)
+			cmd.WithUI(otherUI).ApplicationStart(defaultAppForStart, "some-org", "some-space")
+
+			Expect(otherUI.Outputs).To(ContainSubstrings([]string{"Staging Line"}))
+			Expect(ui.Outputs).To(BeEmpty())
+			Expect(logRepo.TailLogsForCallCount()).To(Equal(1))
+		})
+
 		It("gracefully handles starting an app that is still staging", func() {
 			logRepoClosed := make(chan struct{})
 
-			logRepo.TailLogsForStub = func(appGuid string, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
+			logRepo.TailLogsForStub = func(appGuid string, filter api.LogFilter, onConnect func(), onMessage func(*logmessage.LogMessage)) error {
 				onConnect()
 				onMessage(testlogs.NewLogMessage("Before close", appGuid, LogMessageTypeStaging, "1", logmessage.LogMessage_ERR, time.Now()))
 
@@ -18,6 +18,7 @@ import (
 type ApplicationStopper interface {
 	command_registry.Command
 	ApplicationStop(app models.Application, orgName string, spaceName string) (updatedApp models.Application, err error)
+	WithUI(ui terminal.UI) ApplicationStopper
 }
 
 type Stop struct {
@@ -85,6 +86,13 @@ func (cmd *Stop) ApplicationStop(app models.Application, orgName, spaceName stri
 	return
 }
 
+// WithUI returns a copy of the stopper that writes to ui.
+func (cmd *Stop) WithUI(ui terminal.UI) ApplicationStopper {
+	stopper := *cmd
+	stopper.ui = ui
+	return &stopper
+}
+
 func (cmd *Stop) Execute(c flags.FlagContext) {
 	app := cmd.appReq.GetApplication()
 	if app.State == "stopped" {
@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"github.com/cloudfoundry/cli/cf/app_files"
+	"github.com/cloudfoundry/cli/cf/command_registry"
+	. "github.com/cloudfoundry/cli/cf/i18n"
+	"github.com/cloudfoundry/cli/cf/requirements"
+	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/flags"
+)
+
+type ClearFileHashCache struct {
+	ui            terminal.UI
+	fileHashCache app_files.FileHashCache
+}
+
+func init() {
+	command_registry.Register(&ClearFileHashCache{})
+}
+
+func (cmd *ClearFileHashCache) MetaData() command_registry.CommandMetadata {
+	return command_registry.CommandMetadata{
+		Name:        "clear-file-hash-cache",
+		Description: T("Remove the locally cached hashes of application files used by push"),
+		Usage:       T("CF_NAME clear-file-hash-cache"),
+	}
+}
+
+func (cmd *ClearFileHashCache) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) (reqs []requirements.Requirement, err error) {
+	if len(fc.Args()) != 0 {
+		cmd.ui.Failed(T("Incorrect Usage. No argument required\n\n") + command_registry.Commands.CommandUsage("clear-file-hash-cache"))
+	}
+
+	reqs = []requirements.Requirement{}
+	return
+}
+
+func (cmd *ClearFileHashCache) SetDependency(deps command_registry.Dependency, pluginCall bool) command_registry.Command {
+	cmd.ui = deps.Ui
+	cmd.fileHashCache = deps.FileHashCache
+	return cmd
+}
+
+func (cmd *ClearFileHashCache) Execute(c flags.FlagContext) {
+	cmd.ui.Say(T("Clearing file hash cache..."))
+
+	err := cmd.fileHashCache.Clear()
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+	}
+
+	cmd.ui.Ok()
+}
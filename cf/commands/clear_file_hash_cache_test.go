@@ -0,0 +1,68 @@
+package commands_test
+
+import (
+	"errors"
+
+	"github.com/cloudfoundry/cli/cf/app_files/fakes"
+	"github.com/cloudfoundry/cli/cf/command_registry"
+	testcmd "github.com/cloudfoundry/cli/testhelpers/commands"
+	testreq "github.com/cloudfoundry/cli/testhelpers/requirements"
+	testterm "github.com/cloudfoundry/cli/testhelpers/terminal"
+
+	. "github.com/cloudfoundry/cli/testhelpers/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("clear-file-hash-cache command", func() {
+	var (
+		ui                  *testterm.FakeUI
+		fileHashCache       *fakes.FakeFileHashCache
+		requirementsFactory *testreq.FakeReqFactory
+		deps                command_registry.Dependency
+	)
+
+	updateCommandDependency := func(pluginCall bool) {
+		deps.Ui = ui
+		deps.FileHashCache = fileHashCache
+		command_registry.Commands.SetCommand(command_registry.Commands.FindCommand("clear-file-hash-cache").SetDependency(deps, pluginCall))
+	}
+
+	BeforeEach(func() {
+		ui = &testterm.FakeUI{}
+		fileHashCache = &fakes.FakeFileHashCache{}
+		requirementsFactory = &testreq.FakeReqFactory{}
+	})
+
+	runCommand := func(args ...string) bool {
+		return testcmd.RunCliCommand("clear-file-hash-cache", args, requirementsFactory, updateCommandDependency, false)
+	}
+
+	It("fails with usage when given arguments", func() {
+		runCommand("extra")
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"Incorrect Usage", "No argument required"},
+		))
+	})
+
+	It("clears the cache", func() {
+		runCommand()
+
+		Expect(fileHashCache.ClearCallCount()).To(Equal(1))
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"Clearing file hash cache..."},
+			[]string{"OK"},
+		))
+	})
+
+	It("fails when the cache cannot be removed", func() {
+		fileHashCache.ClearReturns(errors.New("permission denied"))
+
+		runCommand()
+
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"FAILED"},
+			[]string{"permission denied"},
+		))
+	})
+})
@@ -1,8 +1,11 @@
 package commands
 
 import (
+	"bytes"
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
 
 	. "github.com/cloudfoundry/cli/cf/i18n"
 	"github.com/cloudfoundry/cli/flags"
@@ -10,6 +13,7 @@ import (
 
 	"github.com/cloudfoundry/cli/cf/api"
 	"github.com/cloudfoundry/cli/cf/api/app_instances"
+	"github.com/cloudfoundry/cli/cf/api/applications"
 	"github.com/cloudfoundry/cli/cf/command_registry"
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
 	"github.com/cloudfoundry/cli/cf/manifest"
@@ -22,6 +26,7 @@ type CreateAppManifest struct {
 	ui               terminal.UI
 	config           core_config.Reader
 	appSummaryRepo   api.AppSummaryRepository
+	appRepo          applications.ApplicationRepository
 	appInstancesRepo app_instances.AppInstancesRepository
 	appReq           requirements.ApplicationRequirement
 	manifest         manifest.AppManifest
@@ -33,17 +38,30 @@ func init() {
 
 func (cmd *CreateAppManifest) MetaData() command_registry.CommandMetadata {
 	fs := make(map[string]flags.FlagSet)
-	fs["p"] = &cliFlags.StringFlag{ShortName: "p", Usage: T("Specify a path for file creation. If path not specified, manifest file is created in current working directory.")}
+	fs["p"] = &cliFlags.StringFlag{ShortName: "p", Usage: T("Specify a path for file creation. If path not specified, manifest file is created in current working directory. Use '-' to write the manifest to standard output.")}
+	fs["multiple"] = &cliFlags.StringFlag{Name: "multiple", Usage: T("Create a single manifest for a comma separated list of apps (e.g. APP1,APP2)")}
 
 	return command_registry.CommandMetadata{
 		Name:        "create-app-manifest",
 		Description: T("Create an app manifest for an app that has been pushed successfully."),
-		Usage:       T("CF_NAME create-app-manifest APP_NAME [-p /path/to/<app-name>-manifest.yml ]"),
+		Usage:       T("CF_NAME create-app-manifest APP_NAME [-p /path/to/<app-name>-manifest.yml ]\n   CF_NAME create-app-manifest --multiple APP1,APP2 [-p /path/to/manifest.yml ]"),
 		Flags:       fs,
 	}
 }
 
 func (cmd *CreateAppManifest) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) (reqs []requirements.Requirement, err error) {
+	if fc.IsSet("multiple") {
+		if len(fc.Args()) != 0 || len(appNamesFromList(fc.String("multiple"))) == 0 {
+			cmd.ui.Failed(T("Incorrect Usage. Requires either APP_NAME as argument or a list of apps with --multiple\n\n") + command_registry.Commands.CommandUsage("create-app-manifest"))
+		}
+
+		reqs = []requirements.Requirement{
+			requirementsFactory.NewLoginRequirement(),
+			requirementsFactory.NewTargetedSpaceRequirement(),
+		}
+		return
+	}
+
 	if len(fc.Args()) != 1 {
 		cmd.ui.Failed(T("Incorrect Usage. Requires APP_NAME as argument\n\n") + command_registry.Commands.CommandUsage("create-app-manifest"))
 	}
@@ -62,41 +80,121 @@ func (cmd *CreateAppManifest) SetDependency(deps command_registry.Dependency, pl
 	cmd.ui = deps.Ui
 	cmd.config = deps.Config
 	cmd.appSummaryRepo = deps.RepoLocator.GetAppSummaryRepository()
+	cmd.appRepo = deps.RepoLocator.GetApplicationRepository()
 	cmd.manifest = deps.AppManifest
 	return cmd
 }
 
 func (cmd *CreateAppManifest) Execute(c flags.FlagContext) {
-	app := cmd.appReq.GetApplication()
+	var apps []models.Application
+	if c.IsSet("multiple") {
+		for _, appName := range appNamesFromList(c.String("multiple")) {
+			app, apiErr := cmd.appRepo.Read(appName)
+			if apiErr != nil {
+				cmd.ui.Failed(apiErr.Error())
+			}
+			apps = append(apps, app)
+		}
+	} else {
+		apps = append(apps, cmd.appReq.GetApplication())
+	}
+
+	toStdout := c.String("p") == "-"
+
+	var summaries []models.Application
+	for _, app := range apps {
+		application, apiErr := cmd.appSummaryRepo.GetSummary(app.Guid)
+		if apiErr != nil {
+			cmd.ui.Failed(T("Error getting application summary: ") + apiErr.Error())
+		}
 
-	application, apiErr := cmd.appSummaryRepo.GetSummary(app.Guid)
-	if apiErr != nil {
-		cmd.ui.Failed(T("Error getting application summary: ") + apiErr.Error())
+		// The summary leaves out the stack and some settings of the app
+		application.Stack = app.Stack
+		application.HealthCheckType = app.HealthCheckType
+		application.DockerImage = app.DockerImage
+		summaries = append(summaries, application)
+
+		if !toStdout {
+			cmd.ui.Say(T("Creating an app manifest from current settings of app ") + application.Name + " ...")
+		}
 	}
 
-	cmd.ui.Say(T("Creating an app manifest from current settings of app ") + application.Name + " ...")
+	if toStdout {
+		cmd.writeManifest(summaries)
+		return
+	}
 	cmd.ui.Say("")
 
-	savePath := "./" + application.Name + "_manifest.yml"
+	savePath := "./" + apps[0].Name + "_manifest.yml"
+	if len(apps) > 1 {
+		savePath = "./manifest.yml"
+	}
 
 	if c.String("p") != "" {
 		savePath = c.String("p")
 	}
 
-	cmd.createManifest(application, savePath)
+	cmd.createManifest(summaries, savePath)
 }
 
-func (cmd *CreateAppManifest) createManifest(app models.Application, savePath string) error {
+func (cmd *CreateAppManifest) createManifest(apps []models.Application, savePath string) error {
 	cmd.manifest.FileSavePath(savePath)
+	for _, app := range apps {
+		cmd.addApp(app)
+	}
+
+	err := cmd.manifest.Save()
+	if err != nil {
+		cmd.ui.Failed(T("Error creating manifest file: ") + err.Error())
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say(T("Manifest file created successfully at ") + savePath)
+	cmd.ui.Say("")
+
+	return nil
+}
+
+// writeManifest prints the manifest alone, so that the output can be
+// redirected to a file or piped to another command.
+func (cmd *CreateAppManifest) writeManifest(apps []models.Application) {
+	for _, app := range apps {
+		cmd.addApp(app)
+	}
+
+	buffer := &bytes.Buffer{}
+	err := cmd.manifest.Write(buffer)
+	if err != nil {
+		cmd.ui.Failed(T("Error creating manifest file: ") + err.Error())
+	}
+
+	cmd.ui.Say("%s", strings.TrimSuffix(buffer.String(), "\n"))
+}
+
+func (cmd *CreateAppManifest) addApp(app models.Application) {
 	cmd.manifest.Memory(app.Name, app.Memory)
 	cmd.manifest.Instances(app.Name, app.InstanceCount)
 
+	if app.DiskQuota > 0 {
+		cmd.manifest.DiskQuota(app.Name, app.DiskQuota)
+	}
+
 	if app.Command != "" {
 		cmd.manifest.StartCommand(app.Name, app.Command)
 	}
 
-	if app.BuildpackUrl != "" {
-		cmd.manifest.BuildpackUrl(app.Name, app.BuildpackUrl)
+	// Docker apps are not staged, so they have neither a buildpack nor a
+	// stack to push with.
+	if app.DockerImage != "" {
+		cmd.manifest.DockerImage(app.Name, app.DockerImage)
+	} else {
+		if app.BuildpackUrl != "" {
+			cmd.manifest.BuildpackUrl(app.Name, app.BuildpackUrl)
+		}
+
+		if app.Stack != nil && app.Stack.Name != "" {
+			cmd.manifest.Stack(app.Name, app.Stack.Name)
+		}
 	}
 
 	if len(app.Services) > 0 {
@@ -105,6 +203,10 @@ func (cmd *CreateAppManifest) createManifest(app models.Application, savePath st
 		}
 	}
 
+	if app.HealthCheckType != "" {
+		cmd.manifest.HealthCheckType(app.Name, app.HealthCheckType)
+	}
+
 	if app.HealthCheckTimeout > 0 {
 		cmd.manifest.HealthCheckTimeout(app.Name, app.HealthCheckTimeout)
 	}
@@ -122,27 +224,26 @@ func (cmd *CreateAppManifest) createManifest(app models.Application, savePath st
 			case bool:
 				cmd.manifest.EnvironmentVars(app.Name, envVarKey, fmt.Sprintf("%t", app.EnvironmentVars[envVarKey].(bool)))
 			case string:
-				cmd.manifest.EnvironmentVars(app.Name, envVarKey, "\""+app.EnvironmentVars[envVarKey].(string)+"\"")
+				cmd.manifest.EnvironmentVars(app.Name, envVarKey, strconv.Quote(app.EnvironmentVars[envVarKey].(string)))
 			}
 		}
 	}
 
 	if len(app.Routes) > 0 {
 		for i := 0; i < len(app.Routes); i++ {
-			cmd.manifest.Domain(app.Name, app.Routes[i].Host, app.Routes[i].Domain.Name)
+			cmd.manifest.Route(app.Name, app.Routes[i].Host, app.Routes[i].Domain.Name, app.Routes[i].Path)
 		}
 	}
+}
 
-	err := cmd.manifest.Save()
-	if err != nil {
-		cmd.ui.Failed(T("Error creating manifest file: ") + err.Error())
+func appNamesFromList(list string) []string {
+	var appNames []string
+	for _, appName := range strings.Split(list, ",") {
+		if appName = strings.TrimSpace(appName); appName != "" {
+			appNames = append(appNames, appName)
+		}
 	}
-
-	cmd.ui.Ok()
-	cmd.ui.Say(T("Manifest file created successfully at ") + savePath)
-	cmd.ui.Say("")
-
-	return nil
+	return appNames
 }
 
 func sortEnvVar(vars map[string]interface{}) []string {
@@ -1,9 +1,12 @@
 package commands_test
 
 import (
+	"errors"
+	"io"
 	"time"
 
 	testAppInstanaces "github.com/cloudfoundry/cli/cf/api/app_instances/fakes"
+	testApplication "github.com/cloudfoundry/cli/cf/api/applications/fakes"
 	testapi "github.com/cloudfoundry/cli/cf/api/fakes"
 	"github.com/cloudfoundry/cli/cf/command_registry"
 	"github.com/cloudfoundry/cli/cf/configuration/core_config"
@@ -26,6 +29,7 @@ var _ = Describe("create-app-manifest Command", func() {
 		ui                  *testterm.FakeUI
 		configRepo          core_config.Repository
 		appSummaryRepo      *testapi.FakeAppSummaryRepo
+		appRepo             *testApplication.FakeApplicationRepository
 		appInstancesRepo    *testAppInstanaces.FakeAppInstancesRepository
 		requirementsFactory *testreq.FakeReqFactory
 		fakeManifest        *testManifest.FakeAppManifest
@@ -36,6 +40,7 @@ var _ = Describe("create-app-manifest Command", func() {
 		deps.Ui = ui
 		deps.RepoLocator = deps.RepoLocator.SetAppSummaryRepository(appSummaryRepo)
 		deps.RepoLocator = deps.RepoLocator.SetAppInstancesRepository(appInstancesRepo)
+		deps.RepoLocator = deps.RepoLocator.SetApplicationRepository(appRepo)
 		deps.Config = configRepo
 		deps.AppManifest = fakeManifest
 		command_registry.Commands.SetCommand(command_registry.Commands.FindCommand("create-app-manifest").SetDependency(deps, pluginCall))
@@ -45,6 +50,7 @@ var _ = Describe("create-app-manifest Command", func() {
 		fakeManifest = &testManifest.FakeAppManifest{}
 		ui = &testterm.FakeUI{}
 		appSummaryRepo = &testapi.FakeAppSummaryRepo{}
+		appRepo = &testApplication.FakeApplicationRepository{}
 		appInstancesRepo = &testAppInstanaces.FakeAppInstancesRepository{}
 		configRepo = testconfig.NewRepositoryWithDefaults()
 		requirementsFactory = &testreq.FakeReqFactory{
@@ -77,6 +83,17 @@ var _ = Describe("create-app-manifest Command", func() {
 			Expect(passed).To(BeFalse())
 		})
 
+		It("fails with usage when given both APP_NAME and --multiple", func() {
+			runCommand("--multiple", "app1,app2", "my-app")
+			Expect(ui.Outputs).To(ContainSubstrings(
+				[]string{"Incorrect Usage", "--multiple"},
+			))
+		})
+
+		It("does not require an app when given --multiple", func() {
+			runCommand("--multiple", "app1,app2")
+			Expect(requirementsFactory.ApplicationName).To(BeEmpty())
+		})
 	})
 
 	Describe("creating app manifest", func() {
@@ -119,10 +136,54 @@ var _ = Describe("create-app-manifest Command", func() {
 				Ω(fakeManifest.EnvironmentVarsCallCount()).To(Equal(1))
 				Ω(fakeManifest.HealthCheckTimeoutCallCount()).To(Equal(1))
 				Ω(fakeManifest.InstancesCallCount()).To(Equal(1))
-				Ω(fakeManifest.DomainCallCount()).To(Equal(2))
+				Ω(fakeManifest.RouteCallCount()).To(Equal(2))
 				Ω(fakeManifest.ServiceCallCount()).To(Equal(1))
 				Ω(fakeManifest.StartCommandCallCount()).To(Equal(1))
 			})
+
+			It("pairs each route with its own host, domain and path", func() {
+				runCommand("my-app")
+				Ω(fakeManifest.RouteCallCount()).To(Equal(2))
+				appName, host, domain, path := fakeManifest.RouteArgsForCall(0)
+				Ω([]string{appName, host, domain, path}).To(Equal([]string{"my-app", "foo", "example.com", "/api"}))
+				appName, host, domain, path = fakeManifest.RouteArgsForCall(1)
+				Ω([]string{appName, host, domain, path}).To(Equal([]string{"my-app", "my-app", "example.com", ""}))
+			})
+
+			It("adds the disk quota, stack and health check type of the app", func() {
+				runCommand("my-app")
+				Ω(fakeManifest.DiskQuotaCallCount()).To(Equal(1))
+				_, diskQuota := fakeManifest.DiskQuotaArgsForCall(0)
+				Ω(diskQuota).To(Equal(int64(1024)))
+
+				Ω(fakeManifest.StackCallCount()).To(Equal(1))
+				_, stack := fakeManifest.StackArgsForCall(0)
+				Ω(stack).To(Equal("cflinuxfs2"))
+
+				Ω(fakeManifest.HealthCheckTypeCallCount()).To(Equal(1))
+				_, healthCheckType := fakeManifest.HealthCheckTypeArgsForCall(0)
+				Ω(healthCheckType).To(Equal("none"))
+
+				Ω(fakeManifest.DockerImageCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("docker app", func() {
+			BeforeEach(func() {
+				app := makeAppWithOptions("my-app")
+				app.DockerImage = "cloudfoundry/lattice-app"
+				appSummaryRepo.GetSummarySummary = app
+				requirementsFactory.Application = app
+			})
+
+			It("adds the docker image without a buildpack or stack", func() {
+				runCommand("my-app")
+				Ω(fakeManifest.DockerImageCallCount()).To(Equal(1))
+				_, image := fakeManifest.DockerImageArgsForCall(0)
+				Ω(image).To(Equal("cloudfoundry/lattice-app"))
+				Ω(fakeManifest.BuildpackUrlCallCount()).To(Equal(0))
+				Ω(fakeManifest.StackCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("app with buildpack", func() {
@@ -193,7 +254,7 @@ var _ = Describe("create-app-manifest Command", func() {
 				Ω(fakeManifest.EnvironmentVarsCallCount()).To(Equal(0))
 				Ω(fakeManifest.HealthCheckTimeoutCallCount()).To(Equal(0))
 				Ω(fakeManifest.InstancesCallCount()).To(Equal(1))
-				Ω(fakeManifest.DomainCallCount()).To(Equal(0))
+				Ω(fakeManifest.RouteCallCount()).To(Equal(0))
 				Ω(fakeManifest.ServiceCallCount()).To(Equal(0))
 			})
 		})
@@ -225,6 +286,60 @@ var _ = Describe("create-app-manifest Command", func() {
 			})
 		})
 
+		Context("when -p is -", func() {
+			BeforeEach(func() {
+				app := makeAppWithoutOptions("my-app")
+				appSummaryRepo.GetSummarySummary = app
+				requirementsFactory.Application = app
+				fakeManifest.WriteStub = func(w io.Writer) error {
+					_, err := io.WriteString(w, "---\napplications:\n- name: my-app\n")
+					return err
+				}
+			})
+
+			It("prints only the manifest", func() {
+				runCommand("-p", "-", "my-app")
+				Ω(fakeManifest.SaveCallCount()).To(Equal(0))
+				Ω(fakeManifest.FileSavePathCallCount()).To(Equal(0))
+				Ω(ui.Outputs).To(Equal([]string{"---", "applications:", "- name: my-app"}))
+			})
+		})
+
+		Context("when --multiple is supplied", func() {
+			BeforeEach(func() {
+				appRepo.ReadStub = func(name string) (models.Application, error) {
+					app := makeAppWithoutOptions(name)
+					app.Guid = name + "-guid"
+					return app, nil
+				}
+				appSummaryRepo.GetSummarySummary = makeAppWithoutOptions("app2")
+			})
+
+			It("adds every app to a manifest named manifest.yml", func() {
+				runCommand("--multiple", "app1, app2")
+				Ω(appRepo.ReadCallCount()).To(Equal(2))
+				Ω(appRepo.ReadArgsForCall(0)).To(Equal("app1"))
+				Ω(appRepo.ReadArgsForCall(1)).To(Equal("app2"))
+				Ω(appSummaryRepo.GetSummaryAppGuid).To(Equal("app2-guid"))
+				Ω(fakeManifest.MemoryCallCount()).To(Equal(2))
+				Ω(fakeManifest.FileSavePathArgsForCall(0)).To(Equal("./manifest.yml"))
+				Ω(fakeManifest.SaveCallCount()).To(Equal(1))
+			})
+
+			It("fails when one of the apps cannot be found", func() {
+				appRepo.ReadStub = func(name string) (models.Application, error) {
+					return models.Application{}, errors.New("App app2 not found")
+				}
+
+				runCommand("--multiple", "app1,app2")
+				Ω(ui.Outputs).To(ContainSubstrings(
+					[]string{"FAILED"},
+					[]string{"App app2 not found"},
+				))
+				Ω(fakeManifest.SaveCallCount()).To(Equal(0))
+			})
+		})
+
 	})
 })
 
@@ -238,7 +353,7 @@ func makeAppWithOptions(appName string) models.Application {
 	domain := models.DomainFields{}
 	domain.Name = "example.com"
 
-	route := models.RouteSummary{Host: "foo", Domain: domain}
+	route := models.RouteSummary{Host: "foo", Domain: domain, Path: "/api"}
 	secondRoute := models.RouteSummary{Host: appName, Domain: domain}
 	packgeUpdatedAt, _ := time.Parse("2006-01-02T15:04:05Z07:00", "2012-10-24T19:54:00Z")
 
@@ -247,6 +362,9 @@ func makeAppWithOptions(appName string) models.Application {
 	application.RunningInstances = 2
 	application.Memory = 256
 	application.HealthCheckTimeout = 100
+	application.HealthCheckType = "none"
+	application.DiskQuota = 1024
+	application.Stack = &models.Stack{Name: "cflinuxfs2"}
 	application.Routes = []models.RouteSummary{route, secondRoute}
 	application.PackageUpdatedAt = &packgeUpdatedAt
 
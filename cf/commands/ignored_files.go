@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/cloudfoundry/cli/cf/app_files"
+	"github.com/cloudfoundry/cli/cf/command_registry"
+	. "github.com/cloudfoundry/cli/cf/i18n"
+	"github.com/cloudfoundry/cli/cf/requirements"
+	"github.com/cloudfoundry/cli/cf/terminal"
+	"github.com/cloudfoundry/cli/flags"
+)
+
+type IgnoredFiles struct {
+	ui       terminal.UI
+	appFiles app_files.AppFiles
+}
+
+func init() {
+	command_registry.Register(&IgnoredFiles{})
+}
+
+func (cmd *IgnoredFiles) MetaData() command_registry.CommandMetadata {
+	return command_registry.CommandMetadata{
+		Name:        "ignored-files",
+		Description: T("List the files of an app directory excluded from push by its .cfignore files"),
+		Usage:       T("CF_NAME ignored-files [PATH]"),
+	}
+}
+
+func (cmd *IgnoredFiles) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) (reqs []requirements.Requirement, err error) {
+	if len(fc.Args()) > 1 {
+		cmd.ui.Failed(T("Incorrect Usage. Requires an optional path as argument\n\n") + command_registry.Commands.CommandUsage("ignored-files"))
+	}
+
+	reqs = []requirements.Requirement{}
+	return
+}
+
+func (cmd *IgnoredFiles) SetDependency(deps command_registry.Dependency, pluginCall bool) command_registry.Command {
+	cmd.ui = deps.Ui
+	cmd.appFiles = deps.AppFiles
+	return cmd
+}
+
+func (cmd *IgnoredFiles) Execute(c flags.FlagContext) {
+	dir := "."
+	if len(c.Args()) == 1 {
+		dir = c.Args()[0]
+	}
+
+	fileInfo, err := os.Stat(dir)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	if !fileInfo.IsDir() {
+		cmd.ui.Failed(T("{{.Path}} is not a directory", map[string]interface{}{"Path": dir}))
+		return
+	}
+
+	cmd.ui.Say(T("Getting files ignored in {{.Path}}...", map[string]interface{}{"Path": terminal.EntityNameColor(dir)}))
+
+	ignoredFiles, err := cmd.appFiles.IgnoredFilesInDir(dir)
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+		return
+	}
+
+	cmd.ui.Ok()
+	cmd.ui.Say("")
+
+	if len(ignoredFiles) == 0 {
+		cmd.ui.Say(T("No files are ignored"))
+		return
+	}
+
+	for _, ignoredFile := range ignoredFiles {
+		cmd.ui.Say(ignoredFile)
+	}
+}
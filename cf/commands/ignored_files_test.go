@@ -0,0 +1,86 @@
+package commands_test
+
+import (
+	"errors"
+	"path/filepath"
+
+	"github.com/cloudfoundry/cli/cf/app_files/fakes"
+	"github.com/cloudfoundry/cli/cf/command_registry"
+	testcmd "github.com/cloudfoundry/cli/testhelpers/commands"
+	testreq "github.com/cloudfoundry/cli/testhelpers/requirements"
+	testterm "github.com/cloudfoundry/cli/testhelpers/terminal"
+
+	. "github.com/cloudfoundry/cli/testhelpers/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ignored-files command", func() {
+	var (
+		ui                  *testterm.FakeUI
+		appFiles            *fakes.FakeAppFiles
+		requirementsFactory *testreq.FakeReqFactory
+		deps                command_registry.Dependency
+		fixturePath         string
+	)
+
+	updateCommandDependency := func(pluginCall bool) {
+		deps.Ui = ui
+		deps.AppFiles = appFiles
+		command_registry.Commands.SetCommand(command_registry.Commands.FindCommand("ignored-files").SetDependency(deps, pluginCall))
+	}
+
+	BeforeEach(func() {
+		ui = &testterm.FakeUI{}
+		appFiles = &fakes.FakeAppFiles{}
+		requirementsFactory = &testreq.FakeReqFactory{}
+		fixturePath = filepath.Join("..", "..", "fixtures", "applications", "app-with-nested-cfignore")
+	})
+
+	runCommand := func(args ...string) bool {
+		return testcmd.RunCliCommand("ignored-files", args, requirementsFactory, updateCommandDependency, false)
+	}
+
+	It("fails with usage when given more than one argument", func() {
+		runCommand("one", "two")
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"Incorrect Usage", "optional path"},
+		))
+	})
+
+	It("lists the ignored files of the given directory", func() {
+		appFiles.IgnoredFilesInDirReturns([]string{".cfignore", "src/tmp/"}, nil)
+
+		runCommand(fixturePath)
+
+		Expect(appFiles.IgnoredFilesInDirArgsForCall(0)).To(Equal(fixturePath))
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"Getting files ignored in", "app-with-nested-cfignore"},
+			[]string{"OK"},
+			[]string{".cfignore"},
+			[]string{"src/tmp/"},
+		))
+	})
+
+	It("uses the current directory by default", func() {
+		runCommand()
+
+		Expect(appFiles.IgnoredFilesInDirArgsForCall(0)).To(Equal("."))
+		Expect(ui.Outputs).To(ContainSubstrings([]string{"No files are ignored"}))
+	})
+
+	It("fails when the path is not a directory", func() {
+		runCommand(filepath.Join(fixturePath, "app.rb"))
+
+		Expect(appFiles.IgnoredFilesInDirCallCount()).To(Equal(0))
+		Expect(ui.Outputs).To(ContainSubstrings([]string{"FAILED"}, []string{"is not a directory"}))
+	})
+
+	It("fails when the files cannot be listed", func() {
+		appFiles.IgnoredFilesInDirReturns(nil, errors.New("permission denied"))
+
+		runCommand(fixturePath)
+
+		Expect(ui.Outputs).To(ContainSubstrings([]string{"FAILED"}, []string{"permission denied"}))
+	})
+})
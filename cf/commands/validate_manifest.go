@@ -0,0 +1,91 @@
+package commands
+
+import (
+	"os"
+
+	. "github.com/cloudfoundry/cli/cf/i18n"
+	"github.com/cloudfoundry/cli/flags"
+	"github.com/cloudfoundry/cli/flags/flag"
+
+	"github.com/cloudfoundry/cli/cf/command_registry"
+	"github.com/cloudfoundry/cli/cf/manifest"
+	"github.com/cloudfoundry/cli/cf/requirements"
+	"github.com/cloudfoundry/cli/cf/terminal"
+)
+
+type ValidateManifest struct {
+	ui           terminal.UI
+	manifestRepo manifest.ManifestRepository
+}
+
+func init() {
+	command_registry.Register(&ValidateManifest{})
+}
+
+func (cmd *ValidateManifest) MetaData() command_registry.CommandMetadata {
+	fs := make(map[string]flags.FlagSet)
+	fs["var"] = &cliFlags.StringSliceFlag{Name: "var", Usage: T("Variable key value pair for variable substitution in the manifest, (e.g. name=app1); can specify multiple times")}
+	fs["vars-file"] = &cliFlags.StringSliceFlag{Name: "vars-file", Usage: T("Path to a variable substitution file for the manifest; can specify multiple times")}
+
+	return command_registry.CommandMetadata{
+		Name:        "validate-manifest",
+		Description: T("Check a manifest for errors without contacting the API"),
+		Usage: T("CF_NAME validate-manifest [PATH] [--var KEY=VALUE] [--vars-file VARS_FILE_PATH]\n\n") +
+			T("   PATH is a manifest file or a directory containing manifest.yml, defaults to the current directory"),
+		Flags: fs,
+	}
+}
+
+func (cmd *ValidateManifest) Requirements(requirementsFactory requirements.Factory, fc flags.FlagContext) (reqs []requirements.Requirement, err error) {
+	if len(fc.Args()) > 1 {
+		cmd.ui.Failed(T("Incorrect Usage. Too many arguments\n\n") + command_registry.Commands.CommandUsage("validate-manifest"))
+	}
+
+	reqs = []requirements.Requirement{}
+	return
+}
+
+func (cmd *ValidateManifest) SetDependency(deps command_registry.Dependency, pluginCall bool) command_registry.Command {
+	cmd.ui = deps.Ui
+	cmd.manifestRepo = deps.ManifestRepo
+	return cmd
+}
+
+func (cmd *ValidateManifest) Execute(c flags.FlagContext) {
+	var path string
+	if len(c.Args()) > 0 {
+		path = c.Args()[0]
+	} else {
+		var err error
+		path, err = os.Getwd()
+		if err != nil {
+			cmd.ui.Failed(T("Could not determine the current working directory!"), err)
+		}
+	}
+
+	m, err := cmd.manifestRepo.ReadManifest(path)
+	if err != nil {
+		cmd.ui.Failed(T("Error reading manifest file:\n{{.Err}}", map[string]interface{}{"Err": err.Error()}))
+	}
+	m.Variables, err = manifest.CollectVariables(c.StringSlice("vars-file"), c.StringSlice("var"))
+	if err != nil {
+		cmd.ui.Failed(err.Error())
+	}
+
+	cmd.ui.Say(T("Validating manifest file {{.Path}}...",
+		map[string]interface{}{"Path": terminal.EntityNameColor(m.Path)}))
+
+	validationErrs := m.Validate()
+	if len(validationErrs) == 0 {
+		cmd.ui.Ok()
+		return
+	}
+
+	for _, validationErr := range validationErrs {
+		cmd.ui.Say(validationErr.Error())
+	}
+	cmd.ui.Say("")
+
+	cmd.ui.Failed(T("Found {{.Count}} problem(s) in manifest",
+		map[string]interface{}{"Count": len(validationErrs)}))
+}
@@ -0,0 +1,84 @@
+package commands_test
+
+import (
+	"path/filepath"
+
+	"github.com/cloudfoundry/cli/cf/command_registry"
+	"github.com/cloudfoundry/cli/cf/manifest"
+	testcmd "github.com/cloudfoundry/cli/testhelpers/commands"
+	testreq "github.com/cloudfoundry/cli/testhelpers/requirements"
+	testterm "github.com/cloudfoundry/cli/testhelpers/terminal"
+
+	. "github.com/cloudfoundry/cli/testhelpers/matchers"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("validate-manifest command", func() {
+	var (
+		ui                  *testterm.FakeUI
+		requirementsFactory *testreq.FakeReqFactory
+		deps                command_registry.Dependency
+	)
+
+	updateCommandDependency := func(pluginCall bool) {
+		deps.Ui = ui
+		deps.ManifestRepo = manifest.NewManifestDiskRepository()
+		command_registry.Commands.SetCommand(command_registry.Commands.FindCommand("validate-manifest").SetDependency(deps, pluginCall))
+	}
+
+	BeforeEach(func() {
+		ui = &testterm.FakeUI{}
+		requirementsFactory = &testreq.FakeReqFactory{}
+	})
+
+	runCommand := func(args ...string) bool {
+		return testcmd.RunCliCommand("validate-manifest", args, requirementsFactory, updateCommandDependency, false)
+	}
+
+	fixture := func(name string) string {
+		return filepath.Join("..", "..", "fixtures", "manifests", name)
+	}
+
+	It("fails with usage when given too many arguments", func() {
+		Expect(runCommand("a", "b")).To(BeFalse())
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"Incorrect Usage", "Too many arguments"},
+		))
+	})
+
+	It("does not require the user to be logged in or targeted", func() {
+		Expect(runCommand(fixture("manifest.yml"))).To(BeTrue())
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"Validating manifest file", "manifest.yml"},
+			[]string{"OK"},
+		))
+	})
+
+	It("lists every problem with its location", func() {
+		runCommand(fixture("invalid.yml"))
+
+		path := filepath.Clean(fixture("invalid.yml"))
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{path + ":3:1: Unknown property 'colour'"},
+			[]string{path + ":6:3:", "three"},
+			[]string{path + ":13:3: Unknown property 'servces'"},
+			[]string{path + ":15:3: Duplicate application name 'app1'"},
+			[]string{"FAILED"},
+			[]string{"Found 7 problem(s) in manifest"},
+		))
+	})
+
+	It("substitutes variables before validating", func() {
+		Expect(runCommand(fixture("validate/vars-manifest.yml"), "--var", "instances=2")).To(BeTrue())
+		Expect(ui.Outputs).To(ContainSubstrings([]string{"OK"}))
+	})
+
+	It("fails when the manifest cannot be read", func() {
+		runCommand(fixture("does-not-exist.yml"))
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"FAILED"},
+			[]string{"Error reading manifest file"},
+		))
+	})
+})
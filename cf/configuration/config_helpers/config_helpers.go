@@ -19,6 +19,10 @@ func DefaultFilePath() string {
 	return filepath.Join(configDir, "config.json")
 }
 
+func FileHashCachePath() string {
+	return filepath.Join(filepath.Dir(DefaultFilePath()), "file_hashes.json")
+}
+
 // See: http://stackoverflow.com/questions/7922270/obtain-users-home-directory
 // we can't cross compile using cgo and use user.Current()
 var userHomeDir = func() string {
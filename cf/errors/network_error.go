@@ -0,0 +1,15 @@
+package errors
+
+// NetworkError is returned when a request could not be sent or its response
+// could not be received, as opposed to a response with an error status.
+type NetworkError struct {
+	message string
+}
+
+func NewNetworkError(message string) error {
+	return &NetworkError{message: message}
+}
+
+func (err *NetworkError) Error() string {
+	return err.message
+}
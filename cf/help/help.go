@@ -138,6 +138,7 @@ func newAppPresenter() (presenter appPresenter) {
 					presentNonCodegangstaCommand("copy-source"),
 				}, {
 					presentNonCodegangstaCommand("create-app-manifest"),
+					presentNonCodegangstaCommand("validate-manifest"),
 				}, {
 					presentNonCodegangstaCommand("get-health-check"),
 					presentNonCodegangstaCommand("set-health-check"),
@@ -365,6 +366,8 @@ func newAppPresenter() (presenter appPresenter) {
 					presentNonCodegangstaCommand("config"),
 					presentNonCodegangstaCommand("oauth-token"),
 					presentNonCodegangstaCommand("ssh-code"),
+					presentNonCodegangstaCommand("clear-file-hash-cache"),
+					presentNonCodegangstaCommand("ignored-files"),
 				},
 			},
 		}, {
@@ -0,0 +1,119 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cloudfoundry/cli/cf/errors"
+	. "github.com/cloudfoundry/cli/cf/i18n"
+)
+
+var envFileKeyRegex = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// ReadEnvFile reads a dotenv file of KEY=VALUE lines. Blank lines and lines
+// starting with # are skipped, keys may be prefixed with "export", and values
+// may be quoted. Quoted values can span several lines; double quoted values
+// also understand the \n, \r, \t, \" and \\ escapes.
+func ReadEnvFile(path string) (map[string]string, error) {
+	contents, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	vars, lineNumber, err := parseEnvFile(string(contents))
+	if err != nil {
+		return nil, errors.New(T("Invalid env file {{.Path}}, line {{.Line}}: {{.Error}}",
+			map[string]interface{}{"Path": path, "Line": lineNumber, "Error": err.Error()}))
+	}
+
+	return vars, nil
+}
+
+func parseEnvFile(contents string) (map[string]string, int, error) {
+	vars := map[string]string{}
+	lines := strings.Split(strings.Replace(contents, "\r\n", "\n", -1), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimLeft(lines[i], " \t")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "export ") || strings.HasPrefix(line, "export\t") {
+			line = strings.TrimLeft(line[len("export"):], " \t")
+		}
+
+		equals := strings.Index(line, "=")
+		if equals < 0 {
+			return nil, lineNumber, errors.New(T("expected KEY=VALUE"))
+		}
+
+		key := strings.TrimSpace(line[:equals])
+		if !envFileKeyRegex.MatchString(key) {
+			return nil, lineNumber, errors.New(T("invalid variable name '{{.Name}}'", map[string]interface{}{"Name": key}))
+		}
+
+		value := strings.TrimLeft(line[equals+1:], " \t")
+		if value == "" || (value[0] != '"' && value[0] != '\'') {
+			vars[key] = stripEnvFileComment(value)
+			continue
+		}
+
+		quote := value[0]
+		value = value[1:]
+		for {
+			end := closingQuoteIndex(value, quote)
+			if end >= 0 {
+				trailing := strings.TrimSpace(value[end+1:])
+				if trailing != "" && !strings.HasPrefix(trailing, "#") {
+					return nil, i + 1, errors.New(T("unexpected characters after the closing quote"))
+				}
+				value = value[:end]
+				break
+			}
+
+			i++
+			if i >= len(lines) {
+				return nil, lineNumber, errors.New(T("missing closing quote"))
+			}
+			value = value + "\n" + lines[i]
+		}
+
+		if quote == '"' {
+			value = unescapeEnvFileValue(value)
+		}
+		vars[key] = value
+	}
+
+	return vars, 0, nil
+}
+
+func stripEnvFileComment(value string) string {
+	for i := 1; i < len(value); i++ {
+		if value[i] == '#' && (value[i-1] == ' ' || value[i-1] == '\t') {
+			value = value[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(value)
+}
+
+func closingQuoteIndex(value string, quote byte) int {
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\' && quote == '"':
+			i++
+		case value[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func unescapeEnvFileValue(value string) string {
+	replacer := strings.NewReplacer(`\n`, "\n", `\r`, "\r", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+	return replacer.Replace(value)
+}
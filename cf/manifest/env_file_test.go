@@ -0,0 +1,70 @@
+package manifest_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/cloudfoundry/cli/cf/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReadEnvFile", func() {
+	It("reads comments, exports, quoted and multi-line values", func() {
+		vars, err := ReadEnvFile(filepath.Join("..", "..", "fixtures", "manifests", "env_file", "app.env"))
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(vars).To(Equal(map[string]string{
+			"DATABASE_URL": "postgres://db.example.com/app?sslmode=require",
+			"LOG_LEVEL":    "debug",
+			"GREETING":     "hello \"world\"\tand\\nothing",
+			"LITERAL":      `no $escapes\n here`,
+			"EMPTY":        "",
+			"CERTIFICATE":  "-----BEGIN CERTIFICATE-----\nMIIBszCCAVmgAwIBAgIJAK\n-----END CERTIFICATE-----",
+		}))
+	})
+
+	It("returns an error when the file does not exist", func() {
+		_, err := ReadEnvFile("some/path/that/doesnt/exist/app.env")
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("invalid files", func() {
+		var envFile string
+
+		writeEnvFile := func(contents string) {
+			file, err := ioutil.TempFile("", "env-file")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = file.WriteString(contents)
+			Expect(err).NotTo(HaveOccurred())
+			file.Close()
+			envFile = file.Name()
+		}
+
+		AfterEach(func() {
+			os.Remove(envFile)
+		})
+
+		It("reports lines that are not KEY=VALUE", func() {
+			writeEnvFile("GOOD=1\nnot a variable\n")
+
+			_, err := ReadEnvFile(envFile)
+			Expect(err).To(MatchError(ContainSubstring("line 2: expected KEY=VALUE")))
+		})
+
+		It("reports invalid variable names", func() {
+			writeEnvFile("1BAD=value\n")
+
+			_, err := ReadEnvFile(envFile)
+			Expect(err).To(MatchError(ContainSubstring("line 1: invalid variable name '1BAD'")))
+		})
+
+		It("reports quoted values that are never closed", func() {
+			writeEnvFile("FIRST=1\nOPEN=\"never closed\nmore\n")
+
+			_, err := ReadEnvFile(envFile)
+			Expect(err).To(MatchError(ContainSubstring("line 2: missing closing quote")))
+		})
+	})
+})
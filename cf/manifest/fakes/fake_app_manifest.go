@@ -2,6 +2,7 @@
 package fakes
 
 import (
+	"io"
 	"sync"
 
 	"github.com/cloudfoundry/cli/cf/manifest"
@@ -52,12 +53,37 @@ type FakeAppManifest struct {
 		arg1 string
 		arg2 int
 	}
-	DomainStub        func(string, string, string)
-	domainMutex       sync.RWMutex
-	domainArgsForCall []struct {
+	DiskQuotaStub        func(string, int64)
+	diskQuotaMutex       sync.RWMutex
+	diskQuotaArgsForCall []struct {
+		arg1 string
+		arg2 int64
+	}
+	HealthCheckTypeStub        func(string, string)
+	healthCheckTypeMutex       sync.RWMutex
+	healthCheckTypeArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	DockerImageStub        func(string, string)
+	dockerImageMutex       sync.RWMutex
+	dockerImageArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	StackStub        func(string, string)
+	stackMutex       sync.RWMutex
+	stackArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	RouteStub        func(string, string, string, string)
+	routeMutex       sync.RWMutex
+	routeArgsForCall []struct {
 		arg1 string
 		arg2 string
 		arg3 string
+		arg4 string
 	}
 	GetContentsStub        func() []models.Application
 	getContentsMutex       sync.RWMutex
@@ -76,6 +102,14 @@ type FakeAppManifest struct {
 	saveReturns     struct {
 		result1 error
 	}
+	WriteStub        func(io.Writer) error
+	writeMutex       sync.RWMutex
+	writeArgsForCall []struct {
+		arg1 io.Writer
+	}
+	writeReturns struct {
+		result1 error
+	}
 }
 
 func (fake *FakeAppManifest) BuildpackUrl(arg1 string, arg2 string) {
@@ -247,29 +281,126 @@ func (fake *FakeAppManifest) InstancesArgsForCall(i int) (string, int) {
 	return fake.instancesArgsForCall[i].arg1, fake.instancesArgsForCall[i].arg2
 }
 
-func (fake *FakeAppManifest) Domain(arg1 string, arg2 string, arg3 string) {
-	fake.domainMutex.Lock()
-	fake.domainArgsForCall = append(fake.domainArgsForCall, struct {
+func (fake *FakeAppManifest) DiskQuota(arg1 string, arg2 int64) {
+	fake.diskQuotaMutex.Lock()
+	fake.diskQuotaArgsForCall = append(fake.diskQuotaArgsForCall, struct {
+		arg1 string
+		arg2 int64
+	}{arg1, arg2})
+	fake.diskQuotaMutex.Unlock()
+	if fake.DiskQuotaStub != nil {
+		fake.DiskQuotaStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAppManifest) DiskQuotaCallCount() int {
+	fake.diskQuotaMutex.RLock()
+	defer fake.diskQuotaMutex.RUnlock()
+	return len(fake.diskQuotaArgsForCall)
+}
+
+func (fake *FakeAppManifest) DiskQuotaArgsForCall(i int) (string, int64) {
+	fake.diskQuotaMutex.RLock()
+	defer fake.diskQuotaMutex.RUnlock()
+	return fake.diskQuotaArgsForCall[i].arg1, fake.diskQuotaArgsForCall[i].arg2
+}
+
+func (fake *FakeAppManifest) HealthCheckType(arg1 string, arg2 string) {
+	fake.healthCheckTypeMutex.Lock()
+	fake.healthCheckTypeArgsForCall = append(fake.healthCheckTypeArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.healthCheckTypeMutex.Unlock()
+	if fake.HealthCheckTypeStub != nil {
+		fake.HealthCheckTypeStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAppManifest) HealthCheckTypeCallCount() int {
+	fake.healthCheckTypeMutex.RLock()
+	defer fake.healthCheckTypeMutex.RUnlock()
+	return len(fake.healthCheckTypeArgsForCall)
+}
+
+func (fake *FakeAppManifest) HealthCheckTypeArgsForCall(i int) (string, string) {
+	fake.healthCheckTypeMutex.RLock()
+	defer fake.healthCheckTypeMutex.RUnlock()
+	return fake.healthCheckTypeArgsForCall[i].arg1, fake.healthCheckTypeArgsForCall[i].arg2
+}
+
+func (fake *FakeAppManifest) DockerImage(arg1 string, arg2 string) {
+	fake.dockerImageMutex.Lock()
+	fake.dockerImageArgsForCall = append(fake.dockerImageArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.dockerImageMutex.Unlock()
+	if fake.DockerImageStub != nil {
+		fake.DockerImageStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAppManifest) DockerImageCallCount() int {
+	fake.dockerImageMutex.RLock()
+	defer fake.dockerImageMutex.RUnlock()
+	return len(fake.dockerImageArgsForCall)
+}
+
+func (fake *FakeAppManifest) DockerImageArgsForCall(i int) (string, string) {
+	fake.dockerImageMutex.RLock()
+	defer fake.dockerImageMutex.RUnlock()
+	return fake.dockerImageArgsForCall[i].arg1, fake.dockerImageArgsForCall[i].arg2
+}
+
+func (fake *FakeAppManifest) Stack(arg1 string, arg2 string) {
+	fake.stackMutex.Lock()
+	fake.stackArgsForCall = append(fake.stackArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	fake.stackMutex.Unlock()
+	if fake.StackStub != nil {
+		fake.StackStub(arg1, arg2)
+	}
+}
+
+func (fake *FakeAppManifest) StackCallCount() int {
+	fake.stackMutex.RLock()
+	defer fake.stackMutex.RUnlock()
+	return len(fake.stackArgsForCall)
+}
+
+func (fake *FakeAppManifest) StackArgsForCall(i int) (string, string) {
+	fake.stackMutex.RLock()
+	defer fake.stackMutex.RUnlock()
+	return fake.stackArgsForCall[i].arg1, fake.stackArgsForCall[i].arg2
+}
+
+func (fake *FakeAppManifest) Route(arg1 string, arg2 string, arg3 string, arg4 string) {
+	fake.routeMutex.Lock()
+	fake.routeArgsForCall = append(fake.routeArgsForCall, struct {
 		arg1 string
 		arg2 string
 		arg3 string
-	}{arg1, arg2, arg3})
-	fake.domainMutex.Unlock()
-	if fake.DomainStub != nil {
-		fake.DomainStub(arg1, arg2, arg3)
+		arg4 string
+	}{arg1, arg2, arg3, arg4})
+	fake.routeMutex.Unlock()
+	if fake.RouteStub != nil {
+		fake.RouteStub(arg1, arg2, arg3, arg4)
 	}
 }
 
-func (fake *FakeAppManifest) DomainCallCount() int {
-	fake.domainMutex.RLock()
-	defer fake.domainMutex.RUnlock()
-	return len(fake.domainArgsForCall)
+func (fake *FakeAppManifest) RouteCallCount() int {
+	fake.routeMutex.RLock()
+	defer fake.routeMutex.RUnlock()
+	return len(fake.routeArgsForCall)
 }
 
-func (fake *FakeAppManifest) DomainArgsForCall(i int) (string, string, string) {
-	fake.domainMutex.RLock()
-	defer fake.domainMutex.RUnlock()
-	return fake.domainArgsForCall[i].arg1, fake.domainArgsForCall[i].arg2, fake.domainArgsForCall[i].arg3
+func (fake *FakeAppManifest) RouteArgsForCall(i int) (string, string, string, string) {
+	fake.routeMutex.RLock()
+	defer fake.routeMutex.RUnlock()
+	return fake.routeArgsForCall[i].arg1, fake.routeArgsForCall[i].arg2, fake.routeArgsForCall[i].arg3, fake.routeArgsForCall[i].arg4
 }
 
 func (fake *FakeAppManifest) GetContents() []models.Application {
@@ -343,4 +474,36 @@ func (fake *FakeAppManifest) SaveReturns(result1 error) {
 	}{result1}
 }
 
+func (fake *FakeAppManifest) Write(arg1 io.Writer) error {
+	fake.writeMutex.Lock()
+	fake.writeArgsForCall = append(fake.writeArgsForCall, struct {
+		arg1 io.Writer
+	}{arg1})
+	fake.writeMutex.Unlock()
+	if fake.WriteStub != nil {
+		return fake.WriteStub(arg1)
+	} else {
+		return fake.writeReturns.result1
+	}
+}
+
+func (fake *FakeAppManifest) WriteCallCount() int {
+	fake.writeMutex.RLock()
+	defer fake.writeMutex.RUnlock()
+	return len(fake.writeArgsForCall)
+}
+
+func (fake *FakeAppManifest) WriteArgsForCall(i int) io.Writer {
+	fake.writeMutex.RLock()
+	defer fake.writeMutex.RUnlock()
+	return fake.writeArgsForCall[i].arg1
+}
+
+func (fake *FakeAppManifest) WriteReturns(result1 error) {
+	fake.WriteStub = nil
+	fake.writeReturns = struct {
+		result1 error
+	}{result1}
+}
+
 var _ manifest.AppManifest = new(FakeAppManifest)
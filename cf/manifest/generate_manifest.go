@@ -2,9 +2,13 @@ package manifest
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strconv"
 
 	"github.com/cloudfoundry/cli/cf/models"
+	"gopkg.in/yaml.v2"
 )
 
 type AppManifest interface {
@@ -15,10 +19,15 @@ type AppManifest interface {
 	EnvironmentVars(string, string, string)
 	HealthCheckTimeout(string, int)
 	Instances(string, int)
-	Domain(string, string, string)
+	DiskQuota(string, int64)
+	HealthCheckType(string, string)
+	DockerImage(string, string)
+	Stack(string, string)
+	Route(string, string, string, string)
 	GetContents() []models.Application
 	FileSavePath(string)
 	Save() error
+	Write(io.Writer) error
 }
 
 type appManifest struct {
@@ -67,13 +76,34 @@ func (m *appManifest) Service(appName string, name string) {
 	})
 }
 
-func (m *appManifest) Domain(appName string, host string, domain string) {
+func (m *appManifest) DiskQuota(appName string, diskQuota int64) {
+	i := m.findOrCreateApplication(appName)
+	m.contents[i].DiskQuota = diskQuota
+}
+
+func (m *appManifest) HealthCheckType(appName string, healthCheckType string) {
+	i := m.findOrCreateApplication(appName)
+	m.contents[i].HealthCheckType = healthCheckType
+}
+
+func (m *appManifest) DockerImage(appName string, image string) {
+	i := m.findOrCreateApplication(appName)
+	m.contents[i].DockerImage = image
+}
+
+func (m *appManifest) Stack(appName string, stackName string) {
+	i := m.findOrCreateApplication(appName)
+	m.contents[i].Stack = &models.Stack{Name: stackName}
+}
+
+func (m *appManifest) Route(appName string, host string, domain string, path string) {
 	i := m.findOrCreateApplication(appName)
 	m.contents[i].Routes = append(m.contents[i].Routes, models.RouteSummary{
 		Host: host,
 		Domain: models.DomainFields{
 			Name: domain,
 		},
+		Path: path,
 	})
 }
 
@@ -93,69 +123,88 @@ func (m *appManifest) Save() error {
 	}
 	defer f.Close()
 
-	_, err = fmt.Fprintln(f, "---\napplications:")
+	return m.Write(f)
+}
+
+func (m *appManifest) Write(w io.Writer) error {
+	_, err := fmt.Fprintln(w, "---\napplications:")
 	if err != nil {
 		return err
 	}
 
 	for _, app := range m.contents {
-		if _, err := fmt.Fprintf(f, "- name: %s\n", app.Name); err != nil {
+		if _, err := fmt.Fprintf(w, "- name: %s\n", yamlString(app.Name)); err != nil {
 			return err
 		}
 
-		if _, err := fmt.Fprintf(f, "  memory: %dM\n", app.Memory); err != nil {
+		if _, err := fmt.Fprintf(w, "  memory: %dM\n", app.Memory); err != nil {
 			return err
 		}
 
-		if _, err := fmt.Fprintf(f, "  instances: %d\n", app.InstanceCount); err != nil {
+		if app.DiskQuota > 0 {
+			if _, err := fmt.Fprintf(w, "  disk_quota: %dM\n", app.DiskQuota); err != nil {
+				return err
+			}
+		}
+
+		if _, err := fmt.Fprintf(w, "  instances: %d\n", app.InstanceCount); err != nil {
 			return err
 		}
 
+		if app.DockerImage != "" {
+			if _, err := fmt.Fprintf(w, "  docker:\n    image: %s\n", yamlString(app.DockerImage)); err != nil {
+				return err
+			}
+		}
+
 		if app.BuildpackUrl != "" {
-			if _, err := fmt.Fprintf(f, "  buildpack: %s\n", app.BuildpackUrl); err != nil {
+			if _, err := fmt.Fprintf(w, "  buildpack: %s\n", yamlString(app.BuildpackUrl)); err != nil {
+				return err
+			}
+		}
+
+		if app.Stack != nil && app.Stack.Name != "" {
+			if _, err := fmt.Fprintf(w, "  stack: %s\n", yamlString(app.Stack.Name)); err != nil {
+				return err
+			}
+		}
+
+		if app.HealthCheckType != "" {
+			if _, err := fmt.Fprintf(w, "  health-check-type: %s\n", yamlString(app.HealthCheckType)); err != nil {
 				return err
 			}
 		}
 
 		if app.HealthCheckTimeout > 0 {
-			if _, err := fmt.Fprintf(f, "  timeout: %d\n", app.HealthCheckTimeout); err != nil {
+			if _, err := fmt.Fprintf(w, "  timeout: %d\n", app.HealthCheckTimeout); err != nil {
 				return err
 			}
 		}
 
 		if app.Command != "" {
-			if _, err := fmt.Fprintf(f, "  command: %s\n", app.Command); err != nil {
+			if _, err := fmt.Fprintf(w, "  command: %s\n", yamlString(app.Command)); err != nil {
 				return err
 			}
 		}
 
 		if len(app.Routes) > 0 {
-			if len(app.Routes) == 1 {
-				if _, err := fmt.Fprintf(f, "  host: %s\n", app.Routes[0].Host); err != nil {
-					return err
-				}
-				if _, err := fmt.Fprintf(f, "  domain: %s\n", app.Routes[0].Domain.Name); err != nil {
-					return err
-				}
-			} else {
-				if err := writeRoutesToFile(f, app.Routes); err != nil {
-					return err
-				}
+			if err := writeRoutesToFile(w, app.Routes); err != nil {
+				return err
 			}
 		} else {
-			if _, err := fmt.Fprintf(f, "  no-route: true\n"); err != nil {
+			if _, err := fmt.Fprintf(w, "  no-route: true\n"); err != nil {
 				return err
 			}
 		}
 
 		if len(app.Services) > 0 {
-			if err := writeServicesToFile(f, app.Services); err != nil {
+			if err := writeServicesToFile(w, app.Services); err != nil {
 				return err
 			}
 		}
 
 		if len(app.EnvironmentVars) > 0 {
-			if err := writeEnvironmentVarToFile(f, app.EnvironmentVars); err != nil {
+			if err := writeEnvironmentVarToFile(w, app.EnvironmentVars); err != nil {
 				return err
 			}
 		}
@@ -182,70 +231,29 @@ func (m *appManifest) addApplication(name string) {
 		},
 	})
 }
-func writeRoutesToFile(f *os.File, routes []models.RouteSummary) error {
-	var (
-		hostSlice    []string
-		domainSlice  []string
-		hostPSlice   *[]string
-		domainPSlice *[]string
-		hosts        []string
-		domains      []string
-	)
-
-	for i := 0; i < len(routes); i++ {
-		hostSlice = append(hostSlice, routes[i].Host)
-		domainSlice = append(domainSlice, routes[i].Domain.Name)
-	}
-
-	hostPSlice = removeDuplicatedValue(hostSlice)
-	domainPSlice = removeDuplicatedValue(domainSlice)
-
-	if hostPSlice != nil {
-		hosts = *hostPSlice
-	}
-	if domainPSlice != nil {
-		domains = *domainPSlice
-	}
 
-	if len(hosts) == 1 {
-		if _, err := fmt.Fprintf(f, "  host: %s\n", hosts[0]); err != nil {
-			return err
-		}
-	} else {
-		if _, err := fmt.Fprintln(f, "  hosts:"); err != nil {
-			return err
-		}
-		for i := 0; i < len(hosts); i++ {
-			if _, err := fmt.Fprintf(f, "  - %s\n", hosts[i]); err != nil {
-				return err
-			}
-		}
+// writeRoutesToFile lists every route as a URL, so that each host stays
+// paired with its own domain and path when the manifest is pushed again.
+func writeRoutesToFile(w io.Writer, routes []models.RouteSummary) error {
+	if _, err := fmt.Fprintln(w, "  routes:"); err != nil {
+		return err
 	}
-
-	if len(domains) == 1 {
-		if _, err := fmt.Fprintf(f, "  domain: %s\n", domains[0]); err != nil {
+	for _, route := range routes {
+		if _, err := fmt.Fprintf(w, "  - %s\n", yamlString(route.URL())); err != nil {
 			return err
 		}
-	} else {
-		if _, err := fmt.Fprintln(f, "  domains:"); err != nil {
-			return err
-		}
-		for i := 0; i < len(domains); i++ {
-			if _, err := fmt.Fprintf(f, "  - %s\n", domains[i]); err != nil {
-				return err
-			}
-		}
 	}
 
 	return nil
 }
-func writeServicesToFile(f *os.File, entries []models.ServicePlanSummary) error {
-	_, err := fmt.Fprintln(f, "  services:")
+
+func writeServicesToFile(w io.Writer, entries []models.ServicePlanSummary) error {
+	_, err := fmt.Fprintln(w, "  services:")
 	if err != nil {
 		return err
 	}
 	for _, service := range entries {
-		_, err = fmt.Fprintf(f, "  - %s\n", service.Name)
+		_, err = fmt.Fprintf(w, "  - %s\n", yamlString(service.Name))
 		if err != nil {
 			return err
 		}
@@ -254,13 +262,19 @@ func writeServicesToFile(f *os.File, entries []models.ServicePlanSummary) error
 	return nil
 }
 
-func writeEnvironmentVarToFile(f *os.File, envVars map[string]interface{}) error {
-	_, err := fmt.Fprintln(f, "  env:")
+func writeEnvironmentVarToFile(w io.Writer, envVars map[string]interface{}) error {
+	_, err := fmt.Fprintln(w, "  env:")
 	if err != nil {
 		return err
 	}
-	for k, v := range envVars {
-		_, err = fmt.Fprintf(f, "    %s: %s\n", k, v)
+	keys := make([]string, 0, len(envVars))
+	for k := range envVars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		_, err = fmt.Fprintf(w, "    %s: %s\n", yamlString(k), envVars[k])
 		if err != nil {
 			return err
 		}
@@ -268,3 +282,16 @@ func writeEnvironmentVarToFile(f *os.File, envVars map[string]interface{}) error
 
 	return nil
 }
+
+// yamlString writes value as a YAML scalar. It is written as is when YAML
+// reads it back as the same string, and quoted otherwise, as for values with
+// a ": " or a " #", or ones that would be read as a number or a boolean. The
+// quoting of strconv.Quote is also valid YAML.
+func yamlString(value string) string {
+	parsed := map[string]interface{}{}
+	err := yaml.Unmarshal([]byte("value: "+value), &parsed)
+	if err == nil && parsed["value"] == value {
+		return value
+	}
+	return strconv.Quote(value)
+}
@@ -1,8 +1,10 @@
 package manifest_test
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 
 	. "github.com/cloudfoundry/cli/cf/manifest"
@@ -96,7 +98,7 @@ var _ = Describe("generate_manifest", func() {
 		m.EnvironmentVars("app1", "foo", "boo")
 		m.HealthCheckTimeout("app1", 100)
 		m.Instances("app1", 3)
-		m.Domain("app1", "foo", "blahblahblah.com")
+		m.Route("app1", "foo", "blahblahblah.com", "")
 		m.BuildpackUrl("app1", "ruby-buildpack")
 		err := m.Save()
 		Ω(err).NotTo(HaveOccurred())
@@ -111,119 +113,133 @@ var _ = Describe("generate_manifest", func() {
 			[]string{"    foo: boo"},
 			[]string{"  timeout: 100"},
 			[]string{"  instances: 3"},
-			[]string{"  host: foo"},
-			[]string{"  domain: blahblahblah.com"},
+			[]string{"  routes:"},
+			[]string{"  - foo.blahblahblah.com"},
 			[]string{"  buildpack: ruby-buildpack"},
 		))
 	})
-	Context("When there are multiple hosts and domains", func() {
 
-		It("generates a manifest containing two hosts two domains", func() {
-			m.Memory("app1", 128)
-			m.StartCommand("app1", "run main.go")
-			m.Service("app1", "service1")
-			m.EnvironmentVars("app1", "foo", "boo")
-			m.HealthCheckTimeout("app1", 100)
-			m.Instances("app1", 3)
-			m.Domain("app1", "foo1", "test1.com")
-			m.Domain("app1", "foo1", "test2.com")
-			m.Domain("app1", "foo2", "test1.com")
-			m.Domain("app1", "foo2", "test2.com")
-			m.BuildpackUrl("app1", "ruby-buildpack")
-			err := m.Save()
-			Ω(err).NotTo(HaveOccurred())
+	It("writes the disk quota, stack and health check of the app", func() {
+		m.DiskQuota("app1", 1024)
+		m.Stack("app1", "cflinuxfs2")
+		m.HealthCheckType("app1", "none")
+		err := m.Save()
+		Ω(err).NotTo(HaveOccurred())
 
-			Ω(getYamlContent(uniqueFilename)).To(ContainSubstrings(
-				[]string{"- name: app1"},
-				[]string{"  memory: 128M"},
-				[]string{"  command: run main.go"},
-				[]string{"  services:"},
-				[]string{"  - service1"},
-				[]string{"  env:"},
-				[]string{"    foo: boo"},
-				[]string{"  timeout: 100"},
-				[]string{"  instances: 3"},
-				[]string{"  hosts:"},
-				[]string{"  - foo1"},
-				[]string{"  - foo2"},
-				[]string{"  domains:"},
-				[]string{"  - test1.com"},
-				[]string{"  - test2.com"},
-				[]string{"  buildpack: ruby-buildpack"},
-			))
-		})
+		Ω(getYamlContent(uniqueFilename)).To(ContainSubstrings(
+			[]string{"- name: app1"},
+			[]string{"  disk_quota: 1024M"},
+			[]string{"  stack: cflinuxfs2"},
+			[]string{"  health-check-type: none"},
+		))
 	})
 
-	Context("When there are multiple hosts and single domain", func() {
+	It("writes the image of docker apps", func() {
+		m.DockerImage("app1", "cloudfoundry/lattice-app")
+		err := m.Save()
+		Ω(err).NotTo(HaveOccurred())
 
-		It("generates a manifest containing two hosts one domain", func() {
-			m.Memory("app1", 128)
-			m.StartCommand("app1", "run main.go")
-			m.Service("app1", "service1")
-			m.EnvironmentVars("app1", "foo", "boo")
-			m.HealthCheckTimeout("app1", 100)
-			m.Instances("app1", 3)
-			m.Domain("app1", "foo1", "test.com")
-			m.Domain("app1", "foo2", "test.com")
-			m.BuildpackUrl("app1", "ruby-buildpack")
-			err := m.Save()
-			Ω(err).NotTo(HaveOccurred())
+		Ω(getYamlContent(uniqueFilename)).To(ContainSubstrings(
+			[]string{"  docker:"},
+			[]string{"    image: cloudfoundry/lattice-app"},
+		))
+	})
 
-			Ω(getYamlContent(uniqueFilename)).To(ContainSubstrings(
-				[]string{"- name: app1"},
-				[]string{"  memory: 128M"},
-				[]string{"  command: run main.go"},
-				[]string{"  services:"},
-				[]string{"  - service1"},
-				[]string{"  env:"},
-				[]string{"    foo: boo"},
-				[]string{"  timeout: 100"},
-				[]string{"  instances: 3"},
-				[]string{"  hosts:"},
-				[]string{"  - foo1"},
-				[]string{"  - foo2"},
-				[]string{"  domain: test.com"},
-				[]string{"  buildpack: ruby-buildpack"},
-			))
-		})
+	It("writes no-route for apps without routes", func() {
+		m.Memory("app1", 128)
+		err := m.Save()
+		Ω(err).NotTo(HaveOccurred())
+
+		Ω(getYamlContent(uniqueFilename)).To(ContainSubstrings(
+			[]string{"  no-route: true"},
+		))
 	})
 
-	Context("When there is single host and multiple domains", func() {
+	It("writes the environment variables in alphabetical order", func() {
+		m.EnvironmentVars("app1", "foo", "1")
+		m.EnvironmentVars("app1", "bar", "2")
+		m.EnvironmentVars("app1", "baz", "3")
+		err := m.Save()
+		Ω(err).NotTo(HaveOccurred())
+
+		cmdOutput := &outputs{
+			contents: getYamlContent(uniqueFilename),
+			cursor:   0,
+		}
 
-		It("generates a manifest containing one host two domains", func() {
+		Ω(cmdOutput.ContainsSubstring("    bar: 2")).To(BeTrue())
+		Ω(cmdOutput.ContainsSubstring("    baz: 3")).To(BeTrue())
+		Ω(cmdOutput.ContainsSubstring("    foo: 1")).To(BeTrue())
+	})
+
+	It("writes values that are not plain YAML so that the manifest reads them back", func() {
+		m.Memory("app1", 128)
+		m.StartCommand("app1", "bundle exec rackup -p $PORT # web: on")
+		m.BuildpackUrl("app1", "https://github.com/org/buildpack.git#v1.0")
+		m.DockerImage("app2", "registry.example.com:5000/image:1.0")
+		m.EnvironmentVars("app1", "KEY: with colon", strconv.Quote("value"))
+		m.EnvironmentVars("app1", "true", strconv.Quote("yes"))
+		err := m.Save()
+		Ω(err).NotTo(HaveOccurred())
+
+		manifest, err := NewManifestDiskRepository().ReadManifest(uniqueFilename)
+		Ω(err).NotTo(HaveOccurred())
+		apps, err := manifest.Applications()
+		Ω(err).NotTo(HaveOccurred())
+		Ω(apps).To(HaveLen(2))
+
+		Ω(*apps[0].Name).To(Equal("app1"))
+		Ω(*apps[0].Command).To(Equal("bundle exec rackup -p $PORT # web: on"))
+		Ω(*apps[0].BuildpackUrl).To(Equal("https://github.com/org/buildpack.git#v1.0"))
+		Ω(*apps[0].EnvironmentVars).To(Equal(map[string]interface{}{
+			"KEY: with colon": "value",
+			"true":            "yes",
+		}))
+		Ω(*apps[1].DockerImage).To(Equal("registry.example.com:5000/image:1.0"))
+	})
+
+	Context("When there are several routes", func() {
+		It("lists every route with its own host, domain and path", func() {
 			m.Memory("app1", 128)
-			m.StartCommand("app1", "run main.go")
-			m.Service("app1", "service1")
-			m.EnvironmentVars("app1", "foo", "boo")
-			m.HealthCheckTimeout("app1", 100)
-			m.Instances("app1", 3)
-			m.Domain("app1", "foo", "test1.com")
-			m.Domain("app1", "foo", "test2.com")
-			m.BuildpackUrl("app1", "ruby-buildpack")
+			m.Route("app1", "foo1", "test1.com", "")
+			m.Route("app1", "foo2", "test2.com", "/api")
+			m.Route("app1", "", "test3.com", "")
 			err := m.Save()
 			Ω(err).NotTo(HaveOccurred())
 
-			Ω(getYamlContent(uniqueFilename)).To(ContainSubstrings(
+			contents := getYamlContent(uniqueFilename)
+			Ω(contents).To(ContainSubstrings(
 				[]string{"- name: app1"},
-				[]string{"  memory: 128M"},
-				[]string{"  command: run main.go"},
-				[]string{"  services:"},
-				[]string{"  - service1"},
-				[]string{"  env:"},
-				[]string{"    foo: boo"},
-				[]string{"  timeout: 100"},
-				[]string{"  instances: 3"},
-				[]string{"  host: foo"},
-				[]string{"  domains:"},
-				[]string{"  - test1.com"},
-				[]string{"  - test2.com"},
-				[]string{"  buildpack: ruby-buildpack"},
+				[]string{"  routes:"},
+				[]string{"  - foo1.test1.com"},
+				[]string{"  - foo2.test2.com/api"},
+				[]string{"  - test3.com"},
 			))
+			Ω(contents).NotTo(ContainSubstrings([]string{"host"}))
+			Ω(contents).NotTo(ContainSubstrings([]string{"domain"}))
 		})
 	})
 
 })
 
+var _ = Describe("Write", func() {
+	It("writes the manifest to the given writer", func() {
+		m := NewGenerator()
+		m.Memory("app1", 128)
+
+		buffer := &bytes.Buffer{}
+		err := m.Write(buffer)
+		Ω(err).NotTo(HaveOccurred())
+
+		Ω(strings.Split(buffer.String(), "\n")).To(ContainSubstrings(
+			[]string{"---"},
+			[]string{"applications:"},
+			[]string{"- name: app1"},
+			[]string{"  memory: 128M"},
+		))
+	})
+})
+
 func getYamlContent(path string) []string {
 	b, err := ioutil.ReadFile(path)
 	Ω(err).ToNot(HaveOccurred())
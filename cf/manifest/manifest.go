@@ -17,8 +17,14 @@ import (
 )
 
 type Manifest struct {
-	Path string
-	Data generic.Map
+	Path      string
+	Data      generic.Map
+	Variables map[string]interface{}
+
+	// OverlayPaths are the manifests merged over the one at Path, in order.
+	OverlayPaths []string
+
+	sources []manifestSource
 }
 
 func NewEmptyManifest() (m *Manifest) {
@@ -26,7 +32,12 @@ func NewEmptyManifest() (m *Manifest) {
 }
 
 func (m Manifest) Applications() ([]models.AppParams, error) {
-	rawData, err := expandProperties(m.Data, generator.NewWordGenerator())
+	interpolatedData, err := interpolateVariables(m.Data, m.Variables)
+	if err != nil {
+		return []models.AppParams{}, err
+	}
+
+	rawData, err := expandProperties(interpolatedData, generator.NewWordGenerator())
 	if err != nil {
 		return []models.AppParams{}, err
 	}
@@ -165,9 +176,21 @@ func expandProperties(input interface{}, babbler generator.WordGenerator) (inter
 }
 
 func mapToAppParams(basePath string, yamlMap generic.Map) (models.AppParams, error) {
-	err := checkForNulls(yamlMap)
-	if err != nil {
-		return models.AppParams{}, err
+	appParams, errs := parseAppParams(basePath, yamlMap)
+	if len(errs) > 0 {
+		message := ""
+		for _, err := range errs {
+			message = message + fmt.Sprintf("%s\n", err.Error())
+		}
+		return models.AppParams{}, errors.New(message)
+	}
+
+	return appParams, nil
+}
+
+func parseAppParams(basePath string, yamlMap generic.Map) (models.AppParams, []error) {
+	if errs := checkForNulls(yamlMap); len(errs) > 0 {
+		return models.AppParams{}, errs
 	}
 
 	var appParams models.AppParams
@@ -199,7 +222,21 @@ func mapToAppParams(basePath string, yamlMap generic.Map) (models.AppParams, err
 	appParams.UseRandomHostname = boolVal(yamlMap, "random-route", &errs)
 	appParams.ServicesToBind = sliceOrEmptyVal(yamlMap, "services", &errs)
 	appParams.EnvironmentVars = envVarOrEmptyMap(yamlMap, &errs)
+	if envFile := stringVal(yamlMap, "env_file", &errs); envFile != nil && appParams.EnvironmentVars != nil {
+		mergeEnvFile(basePath, *envFile, *appParams.EnvironmentVars, &errs)
+	}
 	appParams.HealthCheckType = stringVal(yamlMap, "health-check-type", &errs)
+	appParams.Hooks = hooksVal(basePath, yamlMap, &errs)
+
+	if yamlMap.Has("docker") {
+		appParams.DockerImage, appParams.DockerCredentials = dockerVal(yamlMap, &errs)
+		checkDockerConflicts(yamlMap, &errs)
+	}
+
+	if yamlMap.Has("routes") {
+		appParams.Routes = sliceOrEmptyVal(yamlMap, "routes", &errs)
+		checkRoutesConflicts(yamlMap, &errs)
+	}
 
 	if appParams.Path != nil {
 		path := *appParams.Path
@@ -212,16 +249,80 @@ func mapToAppParams(basePath string, yamlMap generic.Map) (models.AppParams, err
 	}
 
 	if len(errs) > 0 {
-		message := ""
-		for _, err := range errs {
-			message = message + fmt.Sprintf("%s\n", err.Error())
-		}
-		return models.AppParams{}, errors.New(message)
+		return models.AppParams{}, errs
 	}
 
 	return appParams, nil
 }
 
+var routeProperties = []string{"domain", "domains", "host", "hosts", "no-hostname", "random-route"}
+
+func checkRoutesConflicts(yamlMap generic.Map, errs *[]error) {
+	var conflicts []string
+	for _, key := range routeProperties {
+		if yamlMap.Has(key) {
+			conflicts = append(conflicts, key)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("routes cannot be used together with {{.Properties}}",
+			map[string]interface{}{"Properties": strings.Join(conflicts, ", ")})), "routes"))
+	}
+}
+
+var dockerConflictingProperties = []string{"buildpack", "path", "stack"}
+
+func checkDockerConflicts(yamlMap generic.Map, errs *[]error) {
+	var conflicts []string
+	for _, key := range dockerConflictingProperties {
+		if yamlMap.Has(key) {
+			conflicts = append(conflicts, key)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("docker cannot be used together with {{.Properties}}",
+			map[string]interface{}{"Properties": strings.Join(conflicts, ", ")})), "docker"))
+	}
+}
+
+// dockerVal reads the image of a docker app and the username of its private
+// registry. The password is never part of the manifest.
+func dockerVal(yamlMap generic.Map, errs *[]error) (*string, *models.DockerCredentials) {
+	key := "docker"
+	if !generic.IsMappable(yamlMap.Get(key)) {
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("Expected {{.Name}} to be a set of key => value, but it was a {{.Type}}.",
+			map[string]interface{}{"Name": key, "Type": yamlMap.Get(key)})), key))
+		return nil, nil
+	}
+
+	dockerMap := generic.NewMap(yamlMap.Get(key))
+	generic.Each(dockerMap, func(property, _ interface{}) {
+		if property != "image" && property != "username" {
+			*errs = append(*errs, newPropertyError(fmt.Errorf(T("Unknown docker property '{{.Property}}', expected image or username",
+				map[string]interface{}{"Property": property})), key, property))
+		}
+	})
+
+	image, ok := dockerMap.Get("image").(string)
+	if !ok || image == "" {
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("docker requires an image")), key))
+		return nil, nil
+	}
+
+	if !dockerMap.Has("username") {
+		return &image, nil
+	}
+
+	username, ok := dockerMap.Get("username").(string)
+	if !ok {
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("Expected docker username to be a string.")), key, "username"))
+		return nil, nil
+	}
+	return &image, &models.DockerCredentials{Username: username}
+}
+
 func removeDuplicatedValue(ary []string) *[]string {
 	if ary == nil {
 		return nil
@@ -239,26 +340,16 @@ func removeDuplicatedValue(ary []string) *[]string {
 	return &newAry
 }
 
-func checkForNulls(yamlMap generic.Map) error {
-	var errs []error
+func checkForNulls(yamlMap generic.Map) (errs []error) {
 	generic.Each(yamlMap, func(key interface{}, value interface{}) {
 		if key == "command" || key == "buildpack" {
 			return
 		}
 		if value == nil {
-			errs = append(errs, fmt.Errorf(T("{{.PropertyName}} should not be null", map[string]interface{}{"PropertyName": key})))
+			errs = append(errs, newPropertyError(fmt.Errorf(T("{{.PropertyName}} should not be null", map[string]interface{}{"PropertyName": key})), key))
 		}
 	})
-
-	if len(errs) > 0 {
-		message := ""
-		for i := range errs {
-			message = message + fmt.Sprintf("%s\n", errs[i].Error())
-		}
-		return errors.New(message)
-	}
-
-	return nil
+	return
 }
 
 func stringVal(yamlMap generic.Map, key string, errs *[]error) *string {
@@ -268,7 +359,7 @@ func stringVal(yamlMap generic.Map, key string, errs *[]error) *string {
 	}
 	result, ok := val.(string)
 	if !ok {
-		*errs = append(*errs, fmt.Errorf(T("{{.PropertyName}} must be a string value", map[string]interface{}{"PropertyName": key})))
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("{{.PropertyName}} must be a string value", map[string]interface{}{"PropertyName": key})), key))
 		return nil
 	}
 	return &result
@@ -288,7 +379,7 @@ func stringValOrDefault(yamlMap generic.Map, key string, errs *[]error) *string
 	case nil:
 		return &empty
 	default:
-		*errs = append(*errs, fmt.Errorf(T("{{.PropertyName}} must be a string or null value", map[string]interface{}{"PropertyName": key})))
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("{{.PropertyName}} must be a string or null value", map[string]interface{}{"PropertyName": key})), key))
 		return nil
 	}
 }
@@ -302,12 +393,12 @@ func bytesVal(yamlMap generic.Map, key string, errs *[]error) *int64 {
 	stringVal := coerceToString(yamlVal)
 	value, err := formatters.ToMegabytes(stringVal)
 	if err != nil {
-		*errs = append(*errs, fmt.Errorf(T("Invalid value for '{{.PropertyName}}': {{.StringVal}}\n{{.Error}}",
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("Invalid value for '{{.PropertyName}}': {{.StringVal}}\n{{.Error}}",
 			map[string]interface{}{
 				"PropertyName": key,
 				"Error":        err.Error(),
 				"StringVal":    stringVal,
-			})))
+			})), key))
 		return nil
 	}
 	return &value
@@ -334,7 +425,7 @@ func intVal(yamlMap generic.Map, key string, errs *[]error) *int {
 	}
 
 	if err != nil {
-		*errs = append(*errs, err)
+		*errs = append(*errs, newPropertyError(err, key))
 		return nil
 	}
 
@@ -354,7 +445,7 @@ func boolVal(yamlMap generic.Map, key string, errs *[]error) bool {
 	case string:
 		return val == "true"
 	default:
-		*errs = append(*errs, fmt.Errorf(T("Expected {{.PropertyName}} to be a boolean.", map[string]interface{}{"PropertyName": key})))
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("Expected {{.PropertyName}} to be a boolean.", map[string]interface{}{"PropertyName": key})), key))
 		return false
 	}
 }
@@ -369,7 +460,7 @@ func sliceOrEmptyVal(yamlMap generic.Map, key string, errs *[]error) *[]string {
 		err         error
 	)
 
-	sliceErr := fmt.Errorf(T("Expected {{.PropertyName}} to be a list of strings.", map[string]interface{}{"PropertyName": key}))
+	sliceErr := newPropertyError(fmt.Errorf(T("Expected {{.PropertyName}} to be a list of strings.", map[string]interface{}{"PropertyName": key})), key)
 
 	switch input := yamlMap.Get(key).(type) {
 	case []interface{}:
@@ -419,17 +510,127 @@ func envVarOrEmptyMap(yamlMap generic.Map, errs *[]error) *map[string]interface{
 
 		return &result
 	default:
-		*errs = append(*errs, fmt.Errorf(T("Expected {{.Name}} to be a set of key => value, but it was a {{.Type}}.",
-			map[string]interface{}{"Name": key, "Type": envVars})))
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("Expected {{.Name}} to be a set of key => value, but it was a {{.Type}}.",
+			map[string]interface{}{"Name": key, "Type": envVars})), key))
 		return nil
 	}
 }
 
+// hooksVal reads the commands to run at each stage of a push. A stage takes a
+// single command or a list of commands. A command runs in basePath unless it
+// is given as a map with its own dir, as the commands of inherited and overlay
+// manifests are.
+func hooksVal(basePath string, yamlMap generic.Map, errs *[]error) *models.AppHooks {
+	key := "hooks"
+	if !yamlMap.Has(key) {
+		return nil
+	}
+
+	if !generic.IsMappable(yamlMap.Get(key)) {
+		*errs = append(*errs, newPropertyError(fmt.Errorf(T("Expected {{.PropertyName}} to be a set of stage => commands.",
+			map[string]interface{}{"PropertyName": key})), key))
+		return nil
+	}
+
+	hookVal := func(command interface{}) (models.AppHook, bool) {
+		if commandString, ok := command.(string); ok {
+			return models.AppHook{Command: commandString, Dir: basePath}, true
+		}
+
+		if !generic.IsMappable(command) {
+			return models.AppHook{}, false
+		}
+
+		commandMap := generic.NewMap(command)
+		hook := models.AppHook{Dir: basePath}
+		hook.Command, _ = commandMap.Get("command").(string)
+		dir, dirIsString := commandMap.Get("dir").(string)
+		if hook.Command == "" || (commandMap.Has("dir") && !dirIsString) {
+			return models.AppHook{}, false
+		}
+
+		if dirIsString {
+			if filepath.IsAbs(dir) {
+				hook.Dir = filepath.Clean(dir)
+			} else {
+				hook.Dir = filepath.Join(basePath, dir)
+			}
+		}
+		return hook, true
+	}
+
+	hooks := models.AppHooks{}
+	generic.Each(generic.NewMap(yamlMap.Get(key)), func(stage, value interface{}) {
+		var commands *[]models.AppHook
+		switch stage {
+		case models.PrePushHook:
+			commands = &hooks.PrePush
+		case models.PostPushHook:
+			commands = &hooks.PostPush
+		case models.PostStartHook:
+			commands = &hooks.PostStart
+		default:
+			*errs = append(*errs, newPropertyError(fmt.Errorf(T("Unknown hook '{{.Stage}}', the hooks are {{.Stages}}",
+				map[string]interface{}{
+					"Stage":  stage,
+					"Stages": strings.Join([]string{models.PrePushHook, models.PostPushHook, models.PostStartHook}, ", "),
+				})), key, stage))
+			return
+		}
+
+		if list, ok := value.([]interface{}); ok {
+			for _, command := range list {
+				hook, ok := hookVal(command)
+				if !ok {
+					*errs = append(*errs, hookCommandsError(stage))
+					return
+				}
+				*commands = append(*commands, hook)
+			}
+			return
+		}
+
+		hook, ok := hookVal(value)
+		if !ok {
+			*errs = append(*errs, hookCommandsError(stage))
+			return
+		}
+		*commands = []models.AppHook{hook}
+	})
+
+	return &hooks
+}
+
+func hookCommandsError(stage interface{}) error {
+	return newPropertyError(fmt.Errorf(T("Expected hook {{.Stage}} to be a command or a list of commands.",
+		map[string]interface{}{"Stage": stage})), "hooks", stage)
+}
+
+// mergeEnvFile adds the variables of the env file to env, keeping the values
+// already set by the env property.
+func mergeEnvFile(basePath string, envFile string, env map[string]interface{}, errs *[]error) {
+	if !filepath.IsAbs(envFile) {
+		envFile = filepath.Join(basePath, envFile)
+	}
+
+	vars, err := ReadEnvFile(envFile)
+	if err != nil {
+		*errs = append(*errs, newPropertyError(err, "env_file"))
+		return
+	}
+
+	for key, value := range vars {
+		if _, found := env[key]; !found {
+			env[key] = value
+		}
+	}
+}
+
 func validateEnvVars(input generic.Map) (errs []error) {
 	generic.Each(input, func(key, value interface{}) {
 		if value == nil {
-			errs = append(errs, fmt.Errorf(T("env var '{{.PropertyName}}' should not be null",
-				map[string]interface{}{"PropertyName": key})))
+			errs = append(errs, newPropertyError(fmt.Errorf(T("env var '{{.PropertyName}}' should not be null",
+				map[string]interface{}{"PropertyName": key})), "env", key))
 		}
 	})
 	return
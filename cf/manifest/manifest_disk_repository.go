@@ -1,11 +1,13 @@
 package manifest
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/cloudfoundry/cli/cf/errors"
 	. "github.com/cloudfoundry/cli/cf/i18n"
@@ -15,6 +17,7 @@ import (
 
 type ManifestRepository interface {
 	ReadManifest(string) (*Manifest, error)
+	ReadManifests([]string) (*Manifest, error)
 }
 
 type ManifestDiskRepository struct{}
@@ -33,30 +36,77 @@ func (repo ManifestDiskRepository) ReadManifest(inputPath string) (*Manifest, er
 
 	m.Path = manifestPath
 
-	mapp, err := repo.readAllYAMLFiles(manifestPath)
+	mapp, sources, err := repo.readAllYAMLFiles(manifestPath)
 	if err != nil {
 		return m, err
 	}
 
 	m.Data = mapp
+	m.sources = sources
 
 	return m, nil
 }
 
-func (repo ManifestDiskRepository) readAllYAMLFiles(path string) (mergedMap generic.Map, err error) {
-	file, err := os.Open(filepath.Clean(path))
+// ReadManifests reads the manifest at the first path and merges the others
+// over it in order, see mergeOverlay for how they are merged.
+func (repo ManifestDiskRepository) ReadManifests(inputPaths []string) (*Manifest, error) {
+	m, err := repo.ReadManifest(inputPaths[0])
+	if err != nil {
+		return m, err
+	}
+
+	for _, inputPath := range inputPaths[1:] {
+		overlay, err := repo.ReadManifest(inputPath)
+		if err != nil {
+			return m, err
+		}
+
+		err = resolveAppPaths(overlay.Data, filepath.Dir(overlay.Path))
+		if err != nil {
+			return m, err
+		}
+
+		m.Data = mergeOverlay(m.Data, overlay.Data)
+		m.OverlayPaths = append(m.OverlayPaths, overlay.Path)
+	}
+
+	if len(m.OverlayPaths) > 0 {
+		// positions in the files no longer match the merged applications
+		m.sources = nil
+	}
+
+	return m, nil
+}
+
+func (repo ManifestDiskRepository) readAllYAMLFiles(path string) (mergedMap generic.Map, sources []manifestSource, err error) {
+	return repo.readYAMLFileWithParents(path, []string{})
+}
+
+func (repo ManifestDiskRepository) readYAMLFileWithParents(path string, chain []string) (mergedMap generic.Map, sources []manifestSource, err error) {
+	path = filepath.Clean(path)
+	chain = append(chain, path)
+
+	err = checkForInheritanceLoop(chain)
+	if err != nil {
+		return
+	}
+
+	content, err := ioutil.ReadFile(path)
 	if err != nil {
 		return
 	}
-	defer file.Close()
 
-	mapp, err := parseManifest(file)
+	mapp, err := parseManifest(bytes.NewReader(content))
 	if err != nil {
 		return
 	}
 
+	source := newManifestSource(path, content)
+
 	if !mapp.Has("inherit") {
 		mergedMap = mapp
+		source.appIndexes = mergedAppIndexes(nil, manifestApps(mapp))
+		sources = []manifestSource{source}
 		return
 	}
 
@@ -65,20 +115,169 @@ func (repo ManifestDiskRepository) readAllYAMLFiles(path string) (mergedMap gene
 		err = errors.New(T("invalid inherit path in manifest"))
 		return
 	}
+	mapp.Delete("inherit")
 
 	if !filepath.IsAbs(inheritedPath) {
 		inheritedPath = filepath.Join(filepath.Dir(path), inheritedPath)
 	}
 
-	inheritedMap, err := repo.readAllYAMLFiles(inheritedPath)
+	inheritedMap, sources, err := repo.readYAMLFileWithParents(inheritedPath, chain)
+	if err != nil {
+		return
+	}
+
+	err = resolveAppPaths(inheritedMap, filepath.Dir(inheritedPath))
 	if err != nil {
 		return
 	}
 
-	mergedMap = generic.DeepMerge(inheritedMap, mapp)
+	mergedMap, source.appIndexes = mergeInherited(inheritedMap, mapp)
+	sources = append(sources, source)
 	return
 }
 
+// mergeInherited merges a manifest over the one it inherits from with
+// generic.DeepMerge, except for the applications, which are matched by name
+// like the ones of an overlay instead of being appended. It also tells the
+// index each application of child gets in the merged applications.
+func mergeInherited(parent, child generic.Map) (generic.Map, []int) {
+	parentApps := manifestApps(parent)
+	childApps := manifestApps(child)
+
+	merged := generic.DeepMerge(parent.Except([]interface{}{"applications"}), child.Except([]interface{}{"applications"}))
+	if parent.Has("applications") || child.Has("applications") {
+		merged.Set("applications", mergeAppsByName(parentApps, childApps, func(base, overlay generic.Map) generic.Map {
+			return generic.DeepMerge(base, overlay)
+		}))
+	}
+
+	return merged, mergedAppIndexes(parentApps, childApps)
+}
+
+func manifestApps(data generic.Map) []interface{} {
+	apps, _ := data.Get("applications").([]interface{})
+	return apps
+}
+
+func checkForInheritanceLoop(chain []string) error {
+	current, err := filepath.Abs(chain[len(chain)-1])
+	if err != nil {
+		return err
+	}
+
+	for _, parent := range chain[:len(chain)-1] {
+		parentPath, err := filepath.Abs(parent)
+		if err != nil {
+			return err
+		}
+
+		if parentPath == current {
+			return errors.New(T("Inheritance loop detected in manifest: {{.Chain}}",
+				map[string]interface{}{"Chain": strings.Join(chain, " -> ")}))
+		}
+	}
+
+	return nil
+}
+
+// resolveAppPaths makes the relative app and env file paths of an inherited
+// manifest absolute, and records its directory in its hooks, so they stay
+// relative to the manifest that declares them.
+func resolveAppPaths(mapp generic.Map, dir string) error {
+	err := resolveAppPath(mapp, dir)
+	if err != nil {
+		return err
+	}
+
+	apps, ok := mapp.Get("applications").([]interface{})
+	if !ok {
+		return nil
+	}
+
+	for _, app := range apps {
+		if generic.IsMappable(app) {
+			err = resolveAppPath(generic.NewMap(app), dir)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+var relativePathProperties = []string{"path", "env_file"}
+
+func resolveAppPath(mapp generic.Map, dir string) error {
+	for _, key := range relativePathProperties {
+		path, ok := mapp.Get(key).(string)
+		if !ok || filepath.IsAbs(path) {
+			continue
+		}
+
+		absPath, err := filepath.Abs(filepath.Join(dir, path))
+		if err != nil {
+			return err
+		}
+
+		mapp.Set(key, absPath)
+	}
+
+	if generic.IsMappable(mapp.Get("hooks")) {
+		absDir, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		mapp.Set("hooks", resolveHookDirs(generic.NewMap(mapp.Get("hooks")), absDir))
+	}
+	return nil
+}
+
+// resolveHookDirs gives every hook command the directory it runs in. Lists
+// stay lists, so that the hooks of a parent and a child manifest are still
+// appended when they are merged.
+func resolveHookDirs(hooks generic.Map, dir string) generic.Map {
+	resolved := generic.NewMap()
+	generic.Each(hooks, func(stage, value interface{}) {
+		commands, ok := value.([]interface{})
+		if !ok {
+			resolved.Set(stage, resolveHookDir(value, dir))
+			return
+		}
+
+		resolvedCommands := make([]interface{}, len(commands))
+		for i, command := range commands {
+			resolvedCommands[i] = resolveHookDir(command, dir)
+		}
+		resolved.Set(stage, resolvedCommands)
+	})
+	return resolved
+}
+
+func resolveHookDir(command interface{}, dir string) interface{} {
+	if commandString, ok := command.(string); ok {
+		return map[interface{}]interface{}{"command": commandString, "dir": dir}
+	}
+
+	if !generic.IsMappable(command) {
+		return command
+	}
+
+	commandMap := generic.NewMap()
+	generic.Each(generic.NewMap(command), func(key, value interface{}) {
+		commandMap.Set(key, value)
+	})
+
+	commandDir, ok := commandMap.Get("dir").(string)
+	switch {
+	case !commandMap.Has("dir"):
+		commandMap.Set("dir", dir)
+	case ok && !filepath.IsAbs(commandDir):
+		commandMap.Set("dir", filepath.Join(dir, commandDir))
+	}
+	return commandMap
+}
+
 func parseManifest(file io.Reader) (yamlMap generic.Map, err error) {
 	manifest, err := ioutil.ReadAll(file)
 	if err != nil {
@@ -2,8 +2,10 @@ package manifest_test
 
 import (
 	"path/filepath"
+	"strings"
 
 	. "github.com/cloudfoundry/cli/cf/manifest"
+	"github.com/cloudfoundry/cli/cf/models"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 )
@@ -64,6 +66,77 @@ var _ = Describe("ManifestDiskRepository", func() {
 		})
 	})
 
+	Describe("given several manifests", func() {
+		var m *Manifest
+
+		BeforeEach(func() {
+			var err error
+			m, err = repo.ReadManifests([]string{
+				"../../fixtures/manifests/overlay",
+				"../../fixtures/manifests/overlay/prod/manifest-prod.yml",
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("keeps the path of the first manifest and lists the others as overlays", func() {
+			Expect(m.Path).To(Equal(filepath.Clean("../../fixtures/manifests/overlay/manifest.yml")))
+			Expect(m.OverlayPaths).To(Equal([]string{"../../fixtures/manifests/overlay/prod/manifest-prod.yml"}))
+		})
+
+		It("merges the properties and the applications with the same name", func() {
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(apps).To(HaveLen(3))
+
+			Expect(*apps[0].Name).To(Equal("web"))
+			Expect(*apps[0].Memory).To(Equal(int64(1024)))
+			Expect(*apps[0].InstanceCount).To(Equal(4))
+			Expect(*apps[0].EnvironmentVars).To(Equal(map[string]interface{}{"LOG_LEVEL": "info", "REGION": "eu"}))
+			Expect(*apps[0].ServicesToBind).To(Equal([]string{"db", "monitoring"}))
+			Expect(*apps[0].Routes).To(Equal([]string{"web.example.com"}))
+
+			Expect(*apps[1].Name).To(Equal("worker"))
+			Expect(*apps[1].Memory).To(Equal(int64(1024)))
+			Expect(apps[1].NoRoute).To(BeTrue())
+
+			Expect(*apps[2].Name).To(Equal("reporting"))
+		})
+
+		It("resolves app paths relative to the manifest that declares them", func() {
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			overlayDir, err := filepath.Abs("../../fixtures/manifests/overlay")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*apps[0].Path).To(Equal(filepath.Clean("../../fixtures/manifests/overlay/web")))
+			Expect(*apps[2].Path).To(Equal(filepath.Join(overlayDir, "prod", "reporting")))
+		})
+
+		It("runs hooks in the directory of the manifest that declares them", func() {
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			overlayDir, err := filepath.Abs("../../fixtures/manifests/overlay")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(apps[0].Hooks.PrePush).To(Equal([]models.AppHook{
+				{Command: "./build.sh", Dir: filepath.Clean("../../fixtures/manifests/overlay")},
+			}))
+			Expect(apps[0].Hooks.PostStart).To(Equal([]models.AppHook{
+				{Command: "./smoke-test.sh", Dir: filepath.Join(overlayDir, "prod")},
+			}))
+		})
+
+		It("returns an error when one of the manifests cannot be read", func() {
+			_, err := repo.ReadManifests([]string{
+				"../../fixtures/manifests/overlay",
+				"../../fixtures/manifests/overlay/missing.yml",
+			})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
 	Describe("given a path to a file", func() {
 		var (
 			inputPath string
@@ -155,6 +228,99 @@ var _ = Describe("ManifestDiskRepository", func() {
 		Expect(services).To(Equal([]string{"base-service", "foo-service"}))
 	})
 
+	Describe("given a manifest with an env file", func() {
+		It("adds the variables of the env file, letting env entries win", func() {
+			m, err := repo.ReadManifest("../../fixtures/manifests/env_file")
+			Expect(err).NotTo(HaveOccurred())
+
+			applications, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			env := *applications[0].EnvironmentVars
+			Expect(env["DATABASE_URL"]).To(Equal("postgres://db.example.com/app?sslmode=require"))
+			Expect(env["LOG_LEVEL"]).To(Equal("info"))
+			Expect(env["EMPTY"]).To(Equal(""))
+		})
+	})
+
+	Describe("manifests inheriting from other manifests", func() {
+		It("resolves inherited paths relative to the manifest that declares them", func() {
+			m, err := repo.ReadManifest("../../fixtures/manifests/inheritance/child.yml")
+			Expect(err).NotTo(HaveOccurred())
+
+			applications, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(applications)).To(Equal(2))
+
+			baseDir, err := filepath.Abs("../../fixtures/manifests/inheritance/base")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*applications[0].Name).To(Equal("base-app"))
+			Expect(*applications[0].Path).To(Equal(filepath.Join(baseDir, "..", "base-app")))
+			Expect(*applications[0].Memory).To(Equal(int64(128)))
+			Expect(*applications[0].InstanceCount).To(Equal(2))
+
+			Expect(*applications[1].Name).To(Equal("child-app"))
+			Expect(*applications[1].Path).To(Equal(filepath.Join(baseDir, "app")))
+		})
+
+		It("runs inherited hooks in the directory of the manifest that declares them", func() {
+			m, err := repo.ReadManifest("../../fixtures/manifests/inheritance/child.yml")
+			Expect(err).NotTo(HaveOccurred())
+
+			applications, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			baseDir, err := filepath.Abs("../../fixtures/manifests/inheritance/base")
+			Expect(err).NotTo(HaveOccurred())
+			childDir := filepath.Clean("../../fixtures/manifests/inheritance")
+
+			hooks := applications[1].Hooks
+			Expect(hooks.PrePush).To(Equal([]models.AppHook{{Command: "./build.sh", Dir: baseDir}}))
+			Expect(hooks.PostStart).To(Equal([]models.AppHook{
+				{Command: "./smoke-test.sh", Dir: baseDir},
+				{Command: "./notify.sh", Dir: childDir},
+			}))
+		})
+
+		It("merges the applications of a manifest into the inherited applications with the same name", func() {
+			m, err := repo.ReadManifest("../../fixtures/manifests/inheritance/override.yml")
+			Expect(err).NotTo(HaveOccurred())
+
+			applications, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(len(applications)).To(Equal(1))
+
+			baseDir, err := filepath.Abs("../../fixtures/manifests/inheritance/base")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*applications[0].Name).To(Equal("base-app"))
+			Expect(*applications[0].Path).To(Equal(filepath.Join(baseDir, "..", "base-app")))
+			Expect(*applications[0].Memory).To(Equal(int64(128)))
+			Expect(*applications[0].InstanceCount).To(Equal(3))
+		})
+
+		It("does not pass the inherit key on to the applications", func() {
+			m, err := repo.ReadManifest("../../fixtures/manifests/inheritance/child.yml")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(m.Data.Has("inherit")).To(BeFalse())
+		})
+
+		It("reports inheritance loops with the full chain", func() {
+			_, err := repo.ReadManifest("../../fixtures/manifests/inheritance/loop-a.yml")
+			Expect(err).To(HaveOccurred())
+
+			dir := filepath.Clean("../../fixtures/manifests/inheritance")
+			Expect(err.Error()).To(ContainSubstring("Inheritance loop detected in manifest"))
+			Expect(err.Error()).To(ContainSubstring(strings.Join([]string{
+				filepath.Join(dir, "loop-a.yml"),
+				filepath.Join(dir, "loop-b.yml"),
+				filepath.Join(dir, "base", "loop-c.yml"),
+				filepath.Join(dir, "loop-a.yml"),
+			}, " -> ")))
+		})
+	})
+
 	It("supports yml merges", func() {
 		m, err := repo.ReadManifest("../../fixtures/manifests/merge-manifest.yml")
 		Expect(err).NotTo(HaveOccurred())
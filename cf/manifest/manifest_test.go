@@ -1,10 +1,12 @@
 package manifest_test
 
 import (
+	"path/filepath"
 	"runtime"
 	"strings"
 
 	"github.com/cloudfoundry/cli/cf/manifest"
+	"github.com/cloudfoundry/cli/cf/models"
 	"github.com/cloudfoundry/cli/generic"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -303,6 +305,67 @@ var _ = Describe("Manifests", func() {
 		Expect(*apps[0].Hosts).To(ConsistOf([]string{"my-hostname", "host-1", "host-2"}))
 	})
 
+	Describe("variable substitution", func() {
+		It("replaces whole-value placeholders with the typed variable value", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"applications": []interface{}{
+					generic.NewMap(map[interface{}]interface{}{
+						"name":      "((app-name))",
+						"instances": "((instances))",
+						"services":  "((services))",
+					}),
+				},
+			}))
+			m.Variables = map[string]interface{}{
+				"app-name":  "my-app",
+				"instances": 3,
+				"services":  []interface{}{"db", "cache"},
+			}
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*apps[0].Name).To(Equal("my-app"))
+			Expect(*apps[0].InstanceCount).To(Equal(3))
+			Expect(*apps[0].ServicesToBind).To(Equal([]string{"db", "cache"}))
+		})
+
+		It("replaces placeholders embedded in strings", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"applications": []interface{}{
+					generic.NewMap(map[interface{}]interface{}{
+						"name": "app-((env))",
+						"env": generic.NewMap(map[interface{}]interface{}{
+							"URL": "https://((host)).((env)).example.com:((port))",
+						}),
+					}),
+				},
+			}))
+			m.Variables = map[string]interface{}{"env": "prod", "host": "api", "port": 8443}
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*apps[0].Name).To(Equal("app-prod"))
+			Expect((*apps[0].EnvironmentVars)["URL"]).To(Equal("https://api.prod.example.com:8443"))
+		})
+
+		It("returns an error listing every unresolved placeholder", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"memory": "((memory))",
+				"applications": []interface{}{
+					generic.NewMap(map[interface{}]interface{}{
+						"name": "app-((env))",
+						"host": "((host))",
+					}),
+				},
+			}))
+			m.Variables = map[string]interface{}{"host": "my-host"}
+
+			_, err := m.Applications()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unresolved variables in manifest: ((env)), ((memory))"))
+		})
+	})
+
 	Describe("old-style property syntax", func() {
 		It("returns an error when the manifest contains non-whitelist properties", func() {
 			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
@@ -431,6 +494,19 @@ var _ = Describe("Manifests", func() {
 		})
 	})
 
+	Describe("parsing env files", func() {
+		It("returns an error when the env file cannot be read", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name":     "env-file-app",
+				"env_file": "missing.env",
+			}))
+
+			_, err := m.Applications()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("missing.env"))
+		})
+	})
+
 	Describe("parsing services", func() {
 		It("can read a list of service instance names", func() {
 			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
@@ -443,4 +519,183 @@ var _ = Describe("Manifests", func() {
 			Expect(*app[0].ServicesToBind).To(Equal([]string{"service-1", "service-2"}))
 		})
 	})
+
+	Describe("parsing routes", func() {
+		It("reads a list of route URLs", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name":   "my-app",
+				"routes": []interface{}{"api.example.com/v1", "example.com"},
+			}))
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*apps[0].Routes).To(Equal([]string{"api.example.com/v1", "example.com"}))
+		})
+
+		It("leaves the routes unset when the manifest has no 'routes' key", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name": "my-app",
+			}))
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(apps[0].Routes).To(BeNil())
+		})
+
+		It("returns an error when routes are combined with host or domain keys", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"domain": "example.com",
+				"applications": []interface{}{
+					map[interface{}]interface{}{
+						"name":   "my-app",
+						"host":   "my-host",
+						"routes": []interface{}{"api.example.com"},
+					},
+				},
+			}))
+
+			_, err := m.Applications()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("routes cannot be used together with domain, host"))
+		})
+	})
+
+	Describe("parsing hooks", func() {
+		It("reads single commands and lists of commands for each stage", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"hooks": map[interface{}]interface{}{
+					"pre-push": "npm run build",
+				},
+				"applications": []interface{}{
+					map[interface{}]interface{}{
+						"name": "my-app",
+						"hooks": map[interface{}]interface{}{
+							"post-start": []interface{}{"./smoke-test.sh", "./notify.sh"},
+						},
+					},
+				},
+			}))
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			dir := filepath.Clean("/some/path")
+			hooks := apps[0].Hooks
+			Expect(hooks).NotTo(BeNil())
+			Expect(hooks.PrePush).To(Equal([]models.AppHook{{Command: "npm run build", Dir: dir}}))
+			Expect(hooks.PostPush).To(BeEmpty())
+			Expect(hooks.PostStart).To(Equal([]models.AppHook{
+				{Command: "./smoke-test.sh", Dir: dir},
+				{Command: "./notify.sh", Dir: dir},
+			}))
+		})
+
+		It("reads commands given with the directory they run in", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name": "my-app",
+				"hooks": map[interface{}]interface{}{
+					"pre-push": map[interface{}]interface{}{"command": "make", "dir": "/other/path"},
+					"post-push": []interface{}{
+						map[interface{}]interface{}{"command": "./check.sh", "dir": "scripts"},
+						map[interface{}]interface{}{"command": "./notify.sh"},
+					},
+				},
+			}))
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			hooks := apps[0].Hooks
+			Expect(hooks.PrePush).To(Equal([]models.AppHook{{Command: "make", Dir: filepath.Clean("/other/path")}}))
+			Expect(hooks.PostPush).To(Equal([]models.AppHook{
+				{Command: "./check.sh", Dir: filepath.Join("/some/path", "scripts")},
+				{Command: "./notify.sh", Dir: filepath.Clean("/some/path")},
+			}))
+		})
+
+		It("leaves the hooks unset when the manifest has no 'hooks' key", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name": "my-app",
+			}))
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(apps[0].Hooks).To(BeNil())
+		})
+
+		It("returns an error for unknown stages and commands that are not strings", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name": "my-app",
+				"hooks": map[interface{}]interface{}{
+					"pre-deploy": "make",
+					"post-push":  []interface{}{1},
+				},
+			}))
+
+			_, err := m.Applications()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown hook 'pre-deploy', the hooks are pre-push, post-push, post-start"))
+			Expect(err.Error()).To(ContainSubstring("Expected hook post-push to be a command or a list of commands."))
+		})
+	})
+
+	Describe("parsing docker apps", func() {
+		It("reads the image and the username of the registry", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name": "docker-app",
+				"docker": map[interface{}]interface{}{
+					"image":    "registry.example.com/my-image:1.0",
+					"username": "deployer",
+				},
+			}))
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*apps[0].DockerImage).To(Equal("registry.example.com/my-image:1.0"))
+			Expect(*apps[0].DockerCredentials).To(Equal(models.DockerCredentials{Username: "deployer"}))
+			Expect(apps[0].Path).To(BeNil())
+		})
+
+		It("leaves the credentials unset for public images", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name":   "docker-app",
+				"docker": map[interface{}]interface{}{"image": "nginx"},
+			}))
+
+			apps, err := m.Applications()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(*apps[0].DockerImage).To(Equal("nginx"))
+			Expect(apps[0].DockerCredentials).To(BeNil())
+		})
+
+		It("returns an error when the image is missing", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name":   "docker-app",
+				"docker": map[interface{}]interface{}{"username": "deployer", "password": "secret"},
+			}))
+
+			_, err := m.Applications()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("docker requires an image"))
+			Expect(err.Error()).To(ContainSubstring("Unknown docker property 'password', expected image or username"))
+		})
+
+		It("returns an error when docker is combined with a buildpack, path or stack", func() {
+			m := NewManifest("/some/path/manifest.yml", generic.NewMap(map[interface{}]interface{}{
+				"name":      "docker-app",
+				"buildpack": "ruby_buildpack",
+				"path":      "app",
+				"docker":    map[interface{}]interface{}{"image": "nginx"},
+			}))
+
+			_, err := m.Applications()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("docker cannot be used together with buildpack, path"))
+		})
+	})
 })
@@ -0,0 +1,149 @@
+package manifest
+
+import (
+	"reflect"
+
+	"github.com/cloudfoundry/cli/generic"
+)
+
+// mergeOverlay merges the manifest data of overlay over base. It follows
+// generic.DeepMerge: maps are merged key by key and other values of overlay
+// replace those of base. Lists are merged as follows:
+//
+//   - applications are matched by name. An application of overlay is merged
+//     into the application of base with the same name, or added after the
+//     applications of base if there is none.
+//   - services keep the entries of base and add those of overlay that base
+//     does not have yet, as an overlay usually binds extra services.
+//   - all other lists, such as routes, hosts and domains, are replaced by the
+//     list of overlay, so that an overlay can move an app to other routes.
+func mergeOverlay(base, overlay generic.Map) generic.Map {
+	merged := mergeOverlayMaps(base.Except([]interface{}{"applications"}), overlay.Except([]interface{}{"applications"}))
+
+	baseApps, baseHasApps := base.Get("applications").([]interface{})
+	overlayApps, overlayHasApps := overlay.Get("applications").([]interface{})
+
+	switch {
+	case baseHasApps && overlayHasApps:
+		merged.Set("applications", mergeOverlayApps(baseApps, overlayApps))
+	case overlay.Has("applications"):
+		merged.Set("applications", overlay.Get("applications"))
+	case base.Has("applications"):
+		merged.Set("applications", base.Get("applications"))
+	}
+
+	return merged
+}
+
+func mergeOverlayApps(baseApps, overlayApps []interface{}) []interface{} {
+	return mergeAppsByName(baseApps, overlayApps, mergeOverlayMaps)
+}
+
+// mergeAppsByName merges each application of overlayApps into the application
+// of baseApps with the same name with mergeApp, or adds it after the
+// applications of baseApps if there is none.
+func mergeAppsByName(baseApps, overlayApps []interface{}, mergeApp func(base, overlay generic.Map) generic.Map) []interface{} {
+	merged := append([]interface{}{}, baseApps...)
+
+	for i, index := range mergedAppIndexes(baseApps, overlayApps) {
+		if index == len(merged) {
+			merged = append(merged, overlayApps[i])
+			continue
+		}
+		merged[index] = mergeApp(generic.NewMap(merged[index]), generic.NewMap(overlayApps[i]))
+	}
+
+	return merged
+}
+
+// mergedAppIndexes tells the index each application of overlayApps gets in
+// the applications merged by mergeAppsByName.
+func mergedAppIndexes(baseApps, overlayApps []interface{}) []int {
+	merged := append([]interface{}{}, baseApps...)
+	indexes := make([]int, len(overlayApps))
+
+	for i, overlayApp := range overlayApps {
+		index := -1
+		if generic.IsMappable(overlayApp) {
+			index = findAppByName(merged, generic.NewMap(overlayApp).Get("name"))
+		}
+
+		if index < 0 {
+			index = len(merged)
+			merged = append(merged, overlayApp)
+		}
+		indexes[i] = index
+	}
+
+	return indexes
+}
+
+func findAppByName(apps []interface{}, name interface{}) int {
+	if name == nil {
+		return -1
+	}
+
+	for index, app := range apps {
+		if generic.IsMappable(app) && reflect.DeepEqual(generic.NewMap(app).Get("name"), name) {
+			return index
+		}
+	}
+	return -1
+}
+
+func mergeOverlayMaps(base, overlay generic.Map) generic.Map {
+	merged := generic.NewMap()
+	generic.Each(base, func(key, value interface{}) {
+		merged.Set(key, value)
+	})
+
+	generic.Each(overlay, func(key, value interface{}) {
+		current := merged.Get(key)
+
+		switch {
+		case !merged.Has(key):
+			merged.Set(key, value)
+		case generic.IsMappable(current) && generic.IsMappable(value):
+			merged.Set(key, mergeOverlayMaps(generic.NewMap(current), generic.NewMap(value)))
+		case key == "services" && generic.IsSliceable(current) && generic.IsSliceable(value):
+			merged.Set(key, mergeOverlayLists(current, value))
+		default:
+			merged.Set(key, value)
+		}
+	})
+
+	return merged
+}
+
+func mergeOverlayLists(base, overlay interface{}) []interface{} {
+	merged := toInterfaceSlice(base)
+
+	for _, entry := range toInterfaceSlice(overlay) {
+		found := false
+		for _, existing := range merged {
+			if reflect.DeepEqual(existing, entry) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			merged = append(merged, entry)
+		}
+	}
+
+	return merged
+}
+
+func toInterfaceSlice(list interface{}) []interface{} {
+	switch list := list.(type) {
+	case []string:
+		slice := make([]interface{}, len(list))
+		for i, entry := range list {
+			slice[i] = entry
+		}
+		return slice
+	default:
+		return append([]interface{}{}, list.([]interface{})...)
+	}
+}
@@ -0,0 +1,268 @@
+package manifest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	. "github.com/cloudfoundry/cli/cf/i18n"
+
+	"github.com/cloudfoundry/cli/generic"
+	"github.com/cloudfoundry/cli/words/generator"
+)
+
+var knownAppProperties = map[string]bool{
+	"buildpack":         true,
+	"command":           true,
+	"disk_quota":        true,
+	"docker":            true,
+	"domain":            true,
+	"domains":           true,
+	"env":               true,
+	"env_file":          true,
+	"health-check-type": true,
+	"hooks":             true,
+	"host":              true,
+	"hosts":             true,
+	"instances":         true,
+	"memory":            true,
+	"name":              true,
+	"no-hostname":       true,
+	"no-route":          true,
+	"path":              true,
+	"random-route":      true,
+	"routes":            true,
+	"services":          true,
+	"stack":             true,
+	"timeout":           true,
+}
+
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (err ValidationError) Error() string {
+	if err.Line == 0 {
+		return fmt.Sprintf("%s: %s", err.File, err.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", err.File, err.Line, err.Column, err.Message)
+}
+
+type propertyError struct {
+	path []interface{}
+	err  error
+}
+
+func newPropertyError(err error, path ...interface{}) error {
+	return propertyError{path: path, err: err}
+}
+
+func (err propertyError) Error() string {
+	return err.err.Error()
+}
+
+// manifestSource is a single file of a manifest and its inheritance chain.
+// appIndexes holds the index each application of the file has in the merged
+// applications.
+type manifestSource struct {
+	path       string
+	positions  yamlPositions
+	appIndexes []int
+}
+
+func newManifestSource(path string, content []byte) manifestSource {
+	return manifestSource{
+		path:      path,
+		positions: indexYAMLPositions(content),
+	}
+}
+
+// Validate runs all the checks done when reading the applications of the
+// manifest, and reports every problem found along with its location.
+func (m Manifest) Validate() []ValidationError {
+	interpolatedData, err := interpolateVariables(m.Data, m.Variables)
+	if err != nil {
+		return []ValidationError{m.errorAt(nil, err)}
+	}
+
+	rawData, err := expandProperties(interpolatedData, generator.NewWordGenerator())
+	if err != nil {
+		return []ValidationError{m.errorAt(nil, err)}
+	}
+
+	data := generic.NewMap(rawData)
+	globalProperties := data.Except([]interface{}{"applications"})
+
+	var errs []ValidationError
+	generic.Each(globalProperties, func(key, _ interface{}) {
+		if !knownAppProperties[fmt.Sprintf("%v", key)] {
+			errs = append(errs, m.errorAt([]interface{}{key}, unknownPropertyError(key)))
+		}
+	})
+
+	if !data.Has("applications") {
+		errs = append(errs, m.validateApp(nil, generic.NewMap(), globalProperties)...)
+		return sortValidationErrors(errs)
+	}
+
+	appMaps, ok := data.Get("applications").([]interface{})
+	if !ok {
+		errs = append(errs, m.errorAt([]interface{}{"applications"}, fmt.Errorf(T("Expected applications to be a list"))))
+		return sortValidationErrors(errs)
+	}
+
+	appIndexes := map[string]int{}
+	for index, appData := range appMaps {
+		appPath := []interface{}{"applications", index}
+		if !generic.IsMappable(appData) {
+			errs = append(errs, m.errorAt(appPath, fmt.Errorf(T("Expected application to be a list of key/value pairs"))))
+			continue
+		}
+
+		appMap := generic.NewMap(appData)
+		generic.Each(appMap, func(key, _ interface{}) {
+			if !knownAppProperties[fmt.Sprintf("%v", key)] {
+				errs = append(errs, m.errorAt(appendPath(appPath, key), unknownPropertyError(key)))
+			}
+		})
+
+		mergedMap := generic.DeepMerge(globalProperties, appMap)
+		errs = append(errs, m.validateApp(appPath, appMap, mergedMap)...)
+
+		name, ok := mergedMap.Get("name").(string)
+		if !ok {
+			continue
+		}
+
+		if firstIndex, found := appIndexes[name]; found {
+			errs = append(errs, m.errorAt(appendPath(appPath, "name"),
+				fmt.Errorf(T("Duplicate application name '{{.Name}}', already used by application {{.Index}}",
+					map[string]interface{}{"Name": name, "Index": firstIndex + 1}))))
+			continue
+		}
+		appIndexes[name] = index
+	}
+
+	return sortValidationErrors(errs)
+}
+
+func (m Manifest) validateApp(appPath []interface{}, appMap generic.Map, mergedMap generic.Map) []ValidationError {
+	_, errs := parseAppParams(filepath.Dir(m.Path), mergedMap)
+
+	var validationErrs []ValidationError
+	for _, err := range errs {
+		propErr, ok := err.(propertyError)
+		if !ok {
+			validationErrs = append(validationErrs, m.errorAt(appPath, err))
+			continue
+		}
+
+		path := propErr.path
+		if appPath != nil && appMap.Has(path[0]) {
+			path = append(append([]interface{}{}, appPath...), path...)
+		}
+		validationErrs = append(validationErrs, m.errorAt(path, err))
+	}
+	return validationErrs
+}
+
+func (m Manifest) errorAt(path []interface{}, err error) ValidationError {
+	validationErr := ValidationError{File: m.Path, Message: err.Error()}
+
+	source, localPath, ok := m.sourceFor(path)
+	if !ok {
+		return validationErr
+	}
+
+	validationErr.File = source.path
+	if pos, found := source.positions.lookup(localPath); found {
+		validationErr.Line = pos.Line
+		validationErr.Column = pos.Column
+	}
+	return validationErr
+}
+
+// sourceFor finds the file of the inheritance chain that declares path. An
+// application may be declared by several files of the chain, which are
+// merged by name: the path is looked for in the files that declare the
+// application, the closest to the manifest first.
+func (m Manifest) sourceFor(path []interface{}) (manifestSource, []interface{}, bool) {
+	if len(m.sources) == 0 {
+		return manifestSource{}, nil, false
+	}
+
+	if len(path) >= 2 && path[0] == "applications" {
+		if index, ok := path[1].(int); ok {
+			found := false
+			var appSource manifestSource
+			var appPath []interface{}
+
+			for i := len(m.sources) - 1; i >= 0; i-- {
+				for localIndex, mergedIndex := range m.sources[i].appIndexes {
+					if mergedIndex != index {
+						continue
+					}
+
+					localPath := append([]interface{}{"applications", localIndex}, path[2:]...)
+					if _, declared := m.sources[i].positions[pathKey(localPath)]; declared {
+						return m.sources[i], localPath, true
+					}
+					if !found {
+						found = true
+						appSource, appPath = m.sources[i], localPath
+					}
+				}
+			}
+
+			if found {
+				return appSource, appPath, true
+			}
+		}
+	}
+
+	for i := len(m.sources) - 1; i >= 0; i-- {
+		if _, found := m.sources[i].positions[pathKey(path)]; found {
+			return m.sources[i], path, true
+		}
+	}
+
+	return m.sources[len(m.sources)-1], path, true
+}
+
+func unknownPropertyError(key interface{}) error {
+	return fmt.Errorf(T("Unknown property '{{.PropertyName}}'", map[string]interface{}{"PropertyName": key}))
+}
+
+func sortValidationErrors(errs []ValidationError) []ValidationError {
+	seen := map[ValidationError]bool{}
+	uniqueErrs := []ValidationError{}
+	for _, err := range errs {
+		if !seen[err] {
+			seen[err] = true
+			uniqueErrs = append(uniqueErrs, err)
+		}
+	}
+
+	sort.Sort(validationErrorsByLocation(uniqueErrs))
+	return uniqueErrs
+}
+
+type validationErrorsByLocation []ValidationError
+
+func (errs validationErrorsByLocation) Len() int      { return len(errs) }
+func (errs validationErrorsByLocation) Swap(i, j int) { errs[i], errs[j] = errs[j], errs[i] }
+func (errs validationErrorsByLocation) Less(i, j int) bool {
+	if errs[i].File != errs[j].File {
+		return errs[i].File < errs[j].File
+	}
+	if errs[i].Line != errs[j].Line {
+		return errs[i].Line < errs[j].Line
+	}
+	if errs[i].Column != errs[j].Column {
+		return errs[i].Column < errs[j].Column
+	}
+	return errs[i].Message < errs[j].Message
+}
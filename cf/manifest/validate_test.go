@@ -0,0 +1,125 @@
+package manifest_test
+
+import (
+	"path/filepath"
+
+	. "github.com/cloudfoundry/cli/cf/manifest"
+	"github.com/cloudfoundry/cli/generic"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Validate", func() {
+	var repo ManifestRepository
+
+	BeforeEach(func() {
+		repo = NewManifestDiskRepository()
+	})
+
+	locations := func(errs []ValidationError) []string {
+		result := []string{}
+		for _, err := range errs {
+			result = append(result, err.Error())
+		}
+		return result
+	}
+
+	It("returns no errors for a valid manifest", func() {
+		m, err := repo.ReadManifest("../../fixtures/manifests/manifest.yml")
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(m.Validate()).To(BeEmpty())
+	})
+
+	It("reports every problem with its line and column", func() {
+		m, err := repo.ReadManifest("../../fixtures/manifests/invalid.yml")
+		Expect(err).NotTo(HaveOccurred())
+
+		path := filepath.Clean("../../fixtures/manifests/invalid.yml")
+		errs := m.Validate()
+
+		Expect(errs).To(HaveLen(7))
+		Expect(errs[0]).To(Equal(ValidationError{File: path, Line: 3, Column: 1, Message: "Unknown property 'colour'"}))
+		Expect(errs[1].Line).To(Equal(6))
+		Expect(errs[1].Column).To(Equal(3))
+		Expect(errs[1].Message).To(ContainSubstring("three"))
+		Expect(errs[2].Line).To(Equal(7))
+		Expect(errs[2].Message).To(ContainSubstring("Invalid value for 'disk_quota': 1Z"))
+
+		Expect(locations(errs[3:])).To(Equal([]string{
+			path + ":10:5: env var 'EMPTY' should not be null",
+			path + ":12:3: Expected no-route to be a boolean.",
+			path + ":13:3: Unknown property 'servces'",
+			path + ":15:3: Duplicate application name 'app1', already used by application 1",
+		}))
+	})
+
+	It("reports problems in the file of the inheritance chain that declares them", func() {
+		m, err := repo.ReadManifest("../../fixtures/manifests/validate/child.yml")
+		Expect(err).NotTo(HaveOccurred())
+
+		child := filepath.Clean("../../fixtures/manifests/validate/child.yml")
+		parent := filepath.Join(filepath.Dir(child), "parent.yml")
+		errs := m.Validate()
+
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0].File).To(Equal(child))
+		Expect(errs[0].Line).To(Equal(5))
+		Expect(errs[0].Message).To(Equal("stack must be a string value"))
+		Expect(errs[1].File).To(Equal(parent))
+		Expect(errs[1].Line).To(Equal(2))
+		Expect(errs[1].Message).To(ContainSubstring("Invalid value for 'memory': lots"))
+		Expect(errs[2].File).To(Equal(parent))
+		Expect(errs[2].Line).To(Equal(5))
+		Expect(errs[2].Message).To(ContainSubstring("never"))
+	})
+
+	It("reports problems of an application merged by name in the file that declares them", func() {
+		m, err := repo.ReadManifest("../../fixtures/manifests/validate/override.yml")
+		Expect(err).NotTo(HaveOccurred())
+
+		override := filepath.Clean("../../fixtures/manifests/validate/override.yml")
+		parent := filepath.Join(filepath.Dir(override), "parent.yml")
+		errs := m.Validate()
+
+		Expect(errs).To(HaveLen(3))
+		Expect(errs[0].File).To(Equal(override))
+		Expect(errs[0].Line).To(Equal(5))
+		Expect(errs[0].Message).To(Equal("stack must be a string value"))
+		Expect(errs[1].File).To(Equal(parent))
+		Expect(errs[1].Line).To(Equal(2))
+		Expect(errs[1].Message).To(ContainSubstring("Invalid value for 'memory': lots"))
+		Expect(errs[2].File).To(Equal(parent))
+		Expect(errs[2].Line).To(Equal(5))
+		Expect(errs[2].Message).To(ContainSubstring("never"))
+	})
+
+	It("reports unresolved variables", func() {
+		m := &Manifest{
+			Path: "manifest.yml",
+			Data: generic.NewMap(map[interface{}]interface{}{
+				"name":      "app",
+				"instances": "((instances))",
+			}),
+		}
+
+		errs := m.Validate()
+		Expect(errs).To(HaveLen(1))
+		Expect(errs[0].Message).To(ContainSubstring("Unresolved variables in manifest: ((instances))"))
+	})
+
+	It("reports the manifest path when no positions are known", func() {
+		m := &Manifest{
+			Path: "manifest.yml",
+			Data: generic.NewMap(map[interface{}]interface{}{
+				"applications": []interface{}{
+					map[interface{}]interface{}{"name": "app", "instances": true},
+				},
+			}),
+		}
+
+		Expect(locations(m.Validate())).To(Equal([]string{
+			"manifest.yml: Expected instances to be a number, but it was a true.",
+		}))
+	})
+})
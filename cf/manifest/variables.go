@@ -0,0 +1,142 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry/cli/cf/errors"
+	. "github.com/cloudfoundry/cli/cf/i18n"
+	"github.com/cloudfoundry/cli/generic"
+	"gopkg.in/yaml.v2"
+)
+
+var variableRegex = regexp.MustCompile(`\(\(([-\w.]+)\)\)`)
+
+func ReadVariablesFile(path string) (map[string]interface{}, error) {
+	contents, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, err
+	}
+
+	rawVars := make(map[interface{}]interface{})
+	err = yaml.Unmarshal(contents, &rawVars)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", T("Invalid vars file {{.Path}}", map[string]interface{}{"Path": path}), err.Error())
+	}
+
+	vars := make(map[string]interface{}, len(rawVars))
+	for key, value := range rawVars {
+		name, ok := key.(string)
+		if !ok {
+			return nil, errors.New(T("Invalid vars file {{.Path}}: variable names must be strings, found {{.Name}}",
+				map[string]interface{}{"Path": path, "Name": key}))
+		}
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+// CollectVariables gathers the variables given to a command with
+// --vars-file and --var. The files are read in order and the KEY=VALUE
+// variables come last, so that later values override earlier ones.
+func CollectVariables(varsFiles []string, keyValues []string) (map[string]interface{}, error) {
+	vars := map[string]interface{}{}
+
+	for _, path := range varsFiles {
+		fileVars, err := ReadVariablesFile(path)
+		if err != nil {
+			return nil, errors.New(T("Error reading vars file:\n{{.Err}}", map[string]interface{}{"Err": err.Error()}))
+		}
+
+		for name, value := range fileVars {
+			vars[name] = value
+		}
+	}
+
+	for _, keyValue := range keyValues {
+		name, value, err := ParseVariable(keyValue)
+		if err != nil {
+			return nil, err
+		}
+
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+func ParseVariable(keyValue string) (string, string, error) {
+	parts := strings.SplitN(keyValue, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", errors.New(T("Invalid variable '{{.Variable}}', expected KEY=VALUE", map[string]interface{}{"Variable": keyValue}))
+	}
+
+	return parts[0], parts[1], nil
+}
+
+func interpolateVariables(input interface{}, vars map[string]interface{}) (interface{}, error) {
+	missing := map[string]bool{}
+	output := interpolate(input, vars, missing)
+
+	if len(missing) > 0 {
+		names := []string{}
+		for name := range missing {
+			names = append(names, "(("+name+"))")
+		}
+		sort.Strings(names)
+
+		return nil, errors.New(T("Unresolved variables in manifest: {{.Variables}}\nProvide values with --var KEY=VALUE or --vars-file VARS_FILE_PATH",
+			map[string]interface{}{"Variables": strings.Join(names, ", ")}))
+	}
+
+	return output, nil
+}
+
+func interpolate(input interface{}, vars map[string]interface{}, missing map[string]bool) interface{} {
+	switch input := input.(type) {
+	case string:
+		if match := variableRegex.FindStringSubmatch(input); match != nil && match[0] == input {
+			value, ok := vars[match[1]]
+			if !ok {
+				missing[match[1]] = true
+				return input
+			}
+			return value
+		}
+
+		return variableRegex.ReplaceAllStringFunc(input, func(placeholder string) string {
+			name := variableRegex.FindStringSubmatch(placeholder)[1]
+			value, ok := vars[name]
+			if !ok {
+				missing[name] = true
+				return placeholder
+			}
+			return fmt.Sprintf("%v", value)
+		})
+	case []interface{}:
+		outputSlice := make([]interface{}, len(input))
+		for index, item := range input {
+			outputSlice[index] = interpolate(item, vars, missing)
+		}
+		return outputSlice
+	case map[interface{}]interface{}:
+		outputMap := make(map[interface{}]interface{}, len(input))
+		for key, value := range input {
+			outputMap[key] = interpolate(value, vars, missing)
+		}
+		return outputMap
+	case generic.Map:
+		outputMap := generic.NewMap()
+		generic.Each(input, func(key, value interface{}) {
+			outputMap.Set(key, interpolate(value, vars, missing))
+		})
+		return outputMap
+	default:
+		return input
+	}
+}
@@ -0,0 +1,76 @@
+package manifest_test
+
+import (
+	"path/filepath"
+
+	. "github.com/cloudfoundry/cli/cf/manifest"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Variables", func() {
+	Describe("ReadVariablesFile", func() {
+		It("reads the variables with their YAML types", func() {
+			vars, err := ReadVariablesFile(filepath.Clean("../../fixtures/manifests/vars.yml"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(vars["app-name"]).To(Equal("app-from-vars"))
+			Expect(vars["instances"]).To(Equal(3))
+			Expect(vars["services"]).To(Equal([]interface{}{"database", "cache"}))
+		})
+
+		It("returns an error when the file does not exist", func() {
+			_, err := ReadVariablesFile("some/path/that/doesnt/exist/vars.yml")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("ParseVariable", func() {
+		It("splits KEY=VALUE on the first equals sign", func() {
+			key, value, err := ParseVariable("db-url=postgres://host/db?sslmode=require")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal("db-url"))
+			Expect(value).To(Equal("postgres://host/db?sslmode=require"))
+		})
+
+		It("allows empty values", func() {
+			key, value, err := ParseVariable("empty=")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(key).To(Equal("empty"))
+			Expect(value).To(Equal(""))
+		})
+
+		It("returns an error when there is no equals sign", func() {
+			_, _, err := ParseVariable("no-value")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid variable 'no-value'"))
+		})
+
+		It("returns an error when the key is empty", func() {
+			_, _, err := ParseVariable("=value")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("CollectVariables", func() {
+		It("lets variables override the ones read from files", func() {
+			vars, err := CollectVariables([]string{filepath.Clean("../../fixtures/manifests/vars.yml")}, []string{"app-name=app-from-var"})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(vars["app-name"]).To(Equal("app-from-var"))
+			Expect(vars["instances"]).To(Equal(3))
+		})
+
+		It("returns an error when a vars file cannot be read", func() {
+			_, err := CollectVariables([]string{"some/path/that/doesnt/exist/vars.yml"}, nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Error reading vars file"))
+		})
+
+		It("returns an error when a variable is invalid", func() {
+			_, err := CollectVariables(nil, []string{"no-value"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid variable 'no-value'"))
+		})
+	})
+})
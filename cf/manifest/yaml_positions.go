@@ -0,0 +1,169 @@
+package manifest
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Position struct {
+	Line   int
+	Column int
+}
+
+type yamlPositions map[string]Position
+
+type positionFrame struct {
+	indent   int
+	path     []interface{}
+	sequence bool
+	index    int
+}
+
+// indexYAMLPositions records the line and column of every key and list item
+// of a block style YAML document. Flow style collections are not descended
+// into, lookups for their contents fall back to the enclosing key.
+func indexYAMLPositions(source []byte) yamlPositions {
+	positions := yamlPositions{}
+	stack := []*positionFrame{{indent: -1}}
+
+	var pendingPath []interface{}
+	pendingIndent := -1
+	blockScalarIndent := -1
+
+	for lineIndex, line := range strings.Split(string(source), "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		content := strings.TrimLeft(line, " ")
+		indent := len(line) - len(content)
+
+		if content == "" {
+			continue
+		}
+
+		if blockScalarIndent >= 0 {
+			if indent > blockScalarIndent {
+				continue
+			}
+			blockScalarIndent = -1
+		}
+
+		if strings.HasPrefix(content, "#") || content == "---" || content == "..." {
+			continue
+		}
+
+		isItem := content == "-" || strings.HasPrefix(content, "- ")
+
+		if pendingPath != nil {
+			if indent > pendingIndent || (indent == pendingIndent && isItem) {
+				stack = append(stack, &positionFrame{indent: indent, path: pendingPath, sequence: isItem, index: -1})
+			}
+			pendingPath = nil
+		}
+
+		for len(stack) > 1 {
+			top := stack[len(stack)-1]
+			if indent < top.indent || (indent == top.indent && top.sequence != isItem) {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+			break
+		}
+
+		frame := stack[len(stack)-1]
+		if frame.indent < 0 {
+			frame.indent = indent
+		}
+
+		column := indent
+		for isItem && frame.sequence {
+			frame.index++
+			itemPath := appendPath(frame.path, frame.index)
+			positions[pathKey(itemPath)] = Position{Line: lineIndex + 1, Column: column + 1}
+
+			rest := strings.TrimLeft(strings.TrimPrefix(content, "-"), " ")
+			if rest == "" {
+				pendingPath = itemPath
+				pendingIndent = column
+				break
+			}
+
+			column += len(content) - len(rest)
+			content = rest
+			isItem = content == "-" || strings.HasPrefix(content, "- ")
+
+			frame = &positionFrame{indent: column, path: itemPath, sequence: isItem, index: -1}
+			stack = append(stack, frame)
+		}
+
+		if frame.sequence || pendingPath != nil {
+			continue
+		}
+
+		key, value, ok := splitYAMLKey(content)
+		if !ok {
+			continue
+		}
+
+		keyPath := appendPath(frame.path, key)
+		positions[pathKey(keyPath)] = Position{Line: lineIndex + 1, Column: column + 1}
+
+		switch {
+		case value == "" || strings.HasPrefix(value, "#"):
+			pendingPath = keyPath
+			pendingIndent = column
+		case strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">"):
+			blockScalarIndent = column
+		}
+	}
+
+	return positions
+}
+
+// lookup returns the position of path, or of the closest ancestor of path
+// that was found in the document.
+func (positions yamlPositions) lookup(path []interface{}) (Position, bool) {
+	for i := len(path); i >= 0; i-- {
+		if pos, ok := positions[pathKey(path[:i])]; ok {
+			return pos, true
+		}
+	}
+	return Position{}, false
+}
+
+func splitYAMLKey(content string) (key string, value string, ok bool) {
+	var quote rune
+	for i, char := range content {
+		switch {
+		case quote != 0:
+			if char == quote {
+				quote = 0
+			}
+		case char == '"' || char == '\'':
+			if i == 0 {
+				quote = char
+			}
+		case char == '#' && i > 0 && content[i-1] == ' ':
+			return "", "", false
+		case char == ':':
+			if i+1 == len(content) || content[i+1] == ' ' || content[i+1] == '\t' {
+				key = strings.TrimSpace(content[:i])
+				key = strings.Trim(key, `"'`)
+				return key, strings.TrimSpace(content[i+1:]), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func appendPath(path []interface{}, element interface{}) []interface{} {
+	newPath := make([]interface{}, len(path), len(path)+1)
+	copy(newPath, path)
+	return append(newPath, element)
+}
+
+func pathKey(path []interface{}) string {
+	parts := make([]string, len(path))
+	for i, element := range path {
+		parts[i] = fmt.Sprintf("%v", element)
+	}
+	return strings.Join(parts, "\x00")
+}
@@ -80,11 +80,14 @@ type AppParams struct {
 	Guid               *string
 	HealthCheckType    *string
 	HealthCheckTimeout *int
+	Hooks              *AppHooks
 	DockerImage        *string
+	DockerCredentials  *DockerCredentials
 	Diego              *bool
 	EnableSsh          *bool
 	Hosts              *[]string
 	RoutePath          *string
+	Routes             *[]string
 	InstanceCount      *int
 	Memory             *int64
 	Name               *string
@@ -125,12 +128,18 @@ func (app *AppParams) Merge(other *AppParams) {
 	if other.HealthCheckTimeout != nil {
 		app.HealthCheckTimeout = other.HealthCheckTimeout
 	}
+	if other.Hooks != nil {
+		app.Hooks = other.Hooks
+	}
 	if other.Hosts != nil {
 		app.Hosts = other.Hosts
 	}
 	if other.RoutePath != nil {
 		app.RoutePath = other.RoutePath
 	}
+	if other.Routes != nil {
+		app.Routes = other.Routes
+	}
 	if other.InstanceCount != nil {
 		app.InstanceCount = other.InstanceCount
 	}
@@ -143,6 +152,9 @@ func (app *AppParams) Merge(other *AppParams) {
 	if other.DockerImage != nil {
 		app.DockerImage = other.DockerImage
 	}
+	if other.DockerCredentials != nil {
+		app.DockerCredentials = other.DockerCredentials
+	}
 	if other.Name != nil {
 		app.Name = other.Name
 	}
@@ -173,6 +185,49 @@ func (app *AppParams) Merge(other *AppParams) {
 	app.UseRandomHostname = app.UseRandomHostname || other.UseRandomHostname
 }
 
+// DockerCredentials log in to the private registry of a docker image.
+type DockerCredentials struct {
+	Username string
+	Password string
+}
+
+const (
+	PrePushHook   = "pre-push"
+	PostPushHook  = "post-push"
+	PostStartHook = "post-start"
+)
+
+// AppHooks are the local commands a manifest runs at the stages of a push.
+type AppHooks struct {
+	PrePush   []AppHook
+	PostPush  []AppHook
+	PostStart []AppHook
+}
+
+// AppHook is a command run in Dir, the directory of the manifest that
+// declares it.
+type AppHook struct {
+	Command string
+	Dir     string
+}
+
+// Commands returns the commands of the given stage.
+func (hooks *AppHooks) Commands(stage string) []AppHook {
+	if hooks == nil {
+		return nil
+	}
+
+	switch stage {
+	case PrePushHook:
+		return hooks.PrePush
+	case PostPushHook:
+		return hooks.PostPush
+	case PostStartHook:
+		return hooks.PostStart
+	}
+	return nil
+}
+
 func (app *AppParams) IsEmpty() bool {
 	return reflect.DeepEqual(*app, AppParams{})
 }
@@ -180,3 +235,7 @@ func (app *AppParams) IsEmpty() bool {
 func (app *AppParams) IsHostEmpty() bool {
 	return app.Hosts == nil || len(*app.Hosts) == 0
 }
+
+func (app *AppParams) HasRouteFlags() bool {
+	return app.Domains != nil || !app.IsHostEmpty() || app.NoHostname || app.UseRandomHostname || app.RoutePath != nil
+}
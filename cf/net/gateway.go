@@ -13,6 +13,7 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cloudfoundry/cli/cf"
@@ -52,6 +53,26 @@ type tokenRefresher interface {
 type Request struct {
 	HttpReq      *http.Request
 	SeekableBody io.ReadSeeker
+
+	// StreamBody generates the body of requests that are streamed instead of
+	// read from a seekable source. It is called again each time the request
+	// has to be resent.
+	StreamBody func() io.ReadCloser
+
+	// hideResponseBody keeps the body of the response out of the trace, for
+	// responses that are streamed to a file.
+	hideResponseBody bool
+
+	// progressTotal is the size the progress of a streamed body is reported
+	// against.
+	progressTotal int64
+}
+
+// SetProgressTotal sets an estimate of the size of a streamed body whose
+// exact size is not known, so that the progress of the upload still shows a
+// total and the time left. It does not change the content length sent.
+func (request *Request) SetProgressTotal(total int64) {
+	request.progressTotal = total
 }
 
 type Gateway struct {
@@ -62,9 +83,14 @@ type Gateway struct {
 	trustedCerts    []tls.Certificate
 	config          core_config.Reader
 	warnings        *[]string
+	warningsMutex   *sync.Mutex
 	Clock           func() time.Time
 	transport       *http.Transport
 	ui              terminal.UI
+
+	// progressSummaryOnly reports uploads with a single line once they are
+	// done, for a UI whose output is buffered.
+	progressSummaryOnly bool
 }
 
 func newGateway(errHandler apiErrorHandler, config core_config.Reader, ui terminal.UI) (gateway Gateway) {
@@ -72,12 +98,23 @@ func newGateway(errHandler apiErrorHandler, config core_config.Reader, ui termin
 	gateway.config = config
 	gateway.PollingThrottle = DEFAULT_POLLING_THROTTLE
 	gateway.warnings = &[]string{}
+	gateway.warningsMutex = &sync.Mutex{}
 	gateway.Clock = time.Now
 	gateway.ui = ui
 
 	return
 }
 
+// WithBufferedUI returns a copy of the gateway that reports the progress of
+// uploads to ui, whose output is buffered and shown later, as for the apps of
+// a parallel push. Uploads then print a single line once they are done
+// instead of a live progress line.
+func (gateway Gateway) WithBufferedUI(ui terminal.UI) Gateway {
+	gateway.ui = ui
+	gateway.progressSummaryOnly = true
+	return gateway
+}
+
 func (gateway *Gateway) AsyncTimeout() time.Duration {
 	if gateway.config.AsyncTimeout() > 0 {
 		return time.Duration(gateway.config.AsyncTimeout()) * time.Minute
@@ -205,7 +242,7 @@ func (gateway Gateway) newRequest(request *http.Request, accessToken string, bod
 }
 
 func (gateway Gateway) NewRequestForFile(method, fullUrl, accessToken string, body *os.File) (*Request, error) {
-	progressReader := NewProgressReader(body, gateway.ui, 5*time.Second)
+	progressReader := NewProgressReader(body, gateway.ui, progressInterval())
 	progressReader.Seek(0, 0)
 	fileStats, err := body.Stat()
 
@@ -229,6 +266,40 @@ func (gateway Gateway) NewRequestForFile(method, fullUrl, accessToken string, bo
 	return gateway.newRequest(request, accessToken, progressReader), nil
 }
 
+// NewRequestForStream builds a request whose body is generated by body for
+// each attempt. size is the exact size of the body, sent as its content
+// length and used to report the progress of the upload, or 0 when it is not
+// known, in which case the body is sent chunked.
+func (gateway Gateway) NewRequestForStream(method, fullUrl, accessToken string, body func() io.ReadCloser, size int64) (*Request, error) {
+	request, err := http.NewRequest(method, fullUrl, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", T("Error building request"), err.Error())
+	}
+	if size > 0 {
+		request.ContentLength = size
+	}
+
+	streamRequest := gateway.newRequest(request, accessToken, nil)
+	streamRequest.progressTotal = size
+	streamRequest.StreamBody = func() io.ReadCloser {
+		progressReader := NewStreamProgressReader(body(), gateway.ui, progressInterval())
+		progressReader.SetTotalSize(streamRequest.progressTotal)
+		progressReader.SetSummaryOnly(gateway.progressSummaryOnly)
+		return progressReader
+	}
+	return streamRequest, nil
+}
+
+// progressInterval is how often the progress of uploads is reported: a
+// terminal line is updated every second, while logs get a line every ten
+// seconds.
+func progressInterval() time.Duration {
+	if terminal.IsTerminal() {
+		return time.Second
+	}
+	return 10 * time.Second
+}
+
 func (gateway Gateway) NewRequest(method, path, accessToken string, body io.ReadSeeker) (*Request, error) {
 	request, err := http.NewRequest(method, path, body)
 	if err != nil {
@@ -241,6 +312,14 @@ func (gateway Gateway) PerformRequest(request *Request) (rawResponse *http.Respo
 	return gateway.doRequestHandlingAuth(request)
 }
 
+// PerformRequestForDownload performs the request like PerformRequest, but
+// leaves the body of the response out of the trace, so that it can be
+// streamed by the caller without being read into memory first.
+func (gateway Gateway) PerformRequestForDownload(request *Request) (rawResponse *http.Response, apiErr error) {
+	request.hideResponseBody = true
+	return gateway.doRequestHandlingAuth(request)
+}
+
 func (gateway Gateway) performRequestForResponseBytes(request *Request) (bytes []byte, headers http.Header, rawResponse *http.Response, apiErr error) {
 	rawResponse, apiErr = gateway.doRequestHandlingAuth(request)
 	if apiErr != nil {
@@ -327,6 +406,8 @@ func (gateway Gateway) PerformPollingRequestForJSONResponse(endpoint string, req
 }
 
 func (gateway Gateway) Warnings() []string {
+	gateway.warningsMutex.Lock()
+	defer gateway.warningsMutex.Unlock()
 	return *gateway.warnings
 }
 
@@ -360,12 +441,6 @@ func (gateway Gateway) waitForJob(jobUrl, accessToken string, timeout time.Durat
 }
 
 func (gateway Gateway) doRequestHandlingAuth(request *Request) (rawResponse *http.Response, err error) {
-	httpReq := request.HttpReq
-
-	if request.SeekableBody != nil {
-		httpReq.Body = ioutil.NopCloser(request.SeekableBody)
-	}
-
 	// perform request
 	rawResponse, err = gateway.doRequestAndHandlerError(request)
 	if err == nil || gateway.authenticator == nil {
@@ -381,12 +456,8 @@ func (gateway Gateway) doRequestHandlingAuth(request *Request) (rawResponse *htt
 			return
 		}
 
-		// reset the auth token and request body
-		httpReq.Header.Set("Authorization", newToken)
-		if request.SeekableBody != nil {
-			request.SeekableBody.Seek(0, 0)
-			httpReq.Body = ioutil.NopCloser(request.SeekableBody)
-		}
+		// reset the auth token, the request body is reset before each attempt
+		request.HttpReq.Header.Set("Authorization", newToken)
 
 		// make the request again
 		rawResponse, err = gateway.doRequestAndHandlerError(request)
@@ -395,8 +466,20 @@ func (gateway Gateway) doRequestHandlingAuth(request *Request) (rawResponse *htt
 	return
 }
 
+// resetRequestBody rewinds seekable bodies and regenerates streamed ones so
+// the whole body is sent again every time the request is performed.
+func resetRequestBody(request *Request) {
+	switch {
+	case request.StreamBody != nil:
+		request.HttpReq.Body = request.StreamBody()
+	case request.SeekableBody != nil:
+		request.SeekableBody.Seek(0, 0)
+		request.HttpReq.Body = ioutil.NopCloser(request.SeekableBody)
+	}
+}
+
 func (gateway Gateway) doRequestAndHandlerError(request *Request) (rawResponse *http.Response, err error) {
-	rawResponse, err = gateway.doRequest(request.HttpReq)
+	rawResponse, err = gateway.doRequest(request)
 	if err != nil {
 		err = WrapNetworkErrors(request.HttpReq.URL.Host, err)
 		return
@@ -412,17 +495,29 @@ func (gateway Gateway) doRequestAndHandlerError(request *Request) (rawResponse *
 	return
 }
 
-func (gateway Gateway) doRequest(request *http.Request) (response *http.Response, err error) {
+func (gateway Gateway) doRequest(request *Request) (response *http.Response, err error) {
 	if gateway.transport == nil {
 		makeHttpTransport(&gateway)
 	}
 
 	httpClient := NewHttpClient(gateway.transport)
 
-	dumpRequest(request)
+	resetRequestBody(request)
+	dumpRequest(request.HttpReq)
+
+	// streamed bodies are uploads that are costly to generate again, and
+	// that their callers retry themselves within their own budget
+	attempts := 3
+	if request.StreamBody != nil {
+		attempts = 1
+	}
+
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			resetRequestBody(request)
+		}
 
-	for i := 0; i < 3; i++ {
-		response, err = httpClient.Do(request)
+		response, err = httpClient.Do(request.HttpReq)
 		if response == nil && err != nil {
 			continue
 		} else {
@@ -434,14 +529,16 @@ func (gateway Gateway) doRequest(request *http.Request) (response *http.Response
 		return
 	}
 
-	dumpResponse(response)
+	dumpResponse(response, !request.hideResponseBody)
 
 	header := http.CanonicalHeaderKey("X-Cf-Warnings")
 	raw_warnings := response.Header[header]
+	gateway.warningsMutex.Lock()
 	for _, raw_warning := range raw_warnings {
 		warning, _ := url.QueryUnescape(raw_warning)
 		*gateway.warnings = append(*gateway.warnings, warning)
 	}
+	gateway.warningsMutex.Unlock()
 
 	return
 }
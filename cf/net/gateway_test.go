@@ -3,6 +3,7 @@ package net_test
 import (
 	"crypto/tls"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -95,6 +96,21 @@ var _ = Describe("Gateway", func() {
 			Expect(apiErr).To(HaveOccurred())
 			Expect(client.DoCallCount()).To(Equal(3))
 		})
+
+		It("does not retry streamed bodies, leaving it to the caller", func() {
+			client.DoReturns(nil, errors.New("Connection refused"))
+			bodiesGenerated := 0
+			request, apiErr := ccGateway.NewRequestForStream("PUT", "https://example.com/v2/apps", "BEARER my-access-token", func() io.ReadCloser {
+				bodiesGenerated++
+				return ioutil.NopCloser(strings.NewReader("expected body"))
+			}, int64(len("expected body")))
+			Expect(apiErr).ToNot(HaveOccurred())
+
+			_, apiErr = ccGateway.PerformRequest(request)
+			Expect(apiErr).To(HaveOccurred())
+			Expect(client.DoCallCount()).To(Equal(1))
+			Expect(bodiesGenerated).To(Equal(1))
+		})
 	})
 
 	Describe("NewRequest", func() {
@@ -424,6 +440,80 @@ var _ = Describe("Gateway", func() {
 		})
 	})
 
+	Describe("when streaming a request body", func() {
+		var (
+			apiServer  *httptest.Server
+			authServer *httptest.Server
+		)
+
+		BeforeEach(func() {
+			apiServer = httptest.NewTLSServer(refreshTokenApiEndPoint(
+				`{ "code": 1000, "description": "Auth token is invalid" }`,
+				testnet.TestResponse{Status: http.StatusOK},
+			))
+
+			authServer = httptest.NewTLSServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				fmt.Fprintln(
+					writer,
+					`{ "access_token": "new-access-token", "token_type": "bearer", "refresh_token": "new-refresh-token"}`)
+			}))
+		})
+
+		AfterEach(func() {
+			apiServer.Close()
+			authServer.Close()
+		})
+
+		It("sets the content length to the size of the body", func() {
+			request, apiErr := ccGateway.NewRequestForStream("POST", "https://example.com/v2/foo", "BEARER my-access-token", func() io.ReadCloser {
+				return ioutil.NopCloser(strings.NewReader("expected body"))
+			}, int64(len("expected body")))
+			Expect(apiErr).NotTo(HaveOccurred())
+			Expect(request.HttpReq.ContentLength).To(Equal(int64(13)))
+		})
+
+		It("leaves the content length unset when the size is not known", func() {
+			request, apiErr := ccGateway.NewRequestForStream("POST", "https://example.com/v2/foo", "BEARER my-access-token", func() io.ReadCloser {
+				return ioutil.NopCloser(strings.NewReader("expected body"))
+			}, 0)
+			Expect(apiErr).NotTo(HaveOccurred())
+			Expect(request.HttpReq.ContentLength).To(BeZero())
+		})
+
+		It("reports the progress against an estimated total without sending it as the content length", func() {
+			request, apiErr := ccGateway.NewRequestForStream("POST", "https://example.com/v2/foo", "BEARER my-access-token", func() io.ReadCloser {
+				return ioutil.NopCloser(strings.NewReader("expected body"))
+			}, 0)
+			Expect(apiErr).NotTo(HaveOccurred())
+
+			request.SetProgressTotal(100)
+
+			Expect(request.HttpReq.ContentLength).To(BeZero())
+		})
+
+		It("generates the body again when the access token expires during the upload", func() {
+			config, auth := createAuthenticationRepository(apiServer, authServer)
+			ccGateway.SetTokenRefresher(auth)
+			ccGateway.SetTrustedCerts(apiServer.TLS.Certificates)
+
+			bodiesGenerated := 0
+			request, apiErr := ccGateway.NewRequestForStream("POST", config.ApiEndpoint()+"/v2/foo", config.AccessToken(), func() io.ReadCloser {
+				bodiesGenerated++
+				bodyReader, bodyWriter := io.Pipe()
+				go func() {
+					_, err := bodyWriter.Write([]byte("expected body"))
+					bodyWriter.CloseWithError(err)
+				}()
+				return bodyReader
+			}, 0)
+			Expect(apiErr).NotTo(HaveOccurred())
+
+			_, apiErr = ccGateway.PerformRequest(request)
+			Expect(apiErr).NotTo(HaveOccurred())
+			Expect(bodiesGenerated).To(Equal(2))
+		})
+	})
+
 	Describe("refreshing the auth token", func() {
 		var authServer *httptest.Server
 
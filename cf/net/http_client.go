@@ -63,12 +63,15 @@ func dumpRequest(req *http.Request) {
 	}
 }
 
-func dumpResponse(res *http.Response) {
-	dumpedResponse, err := httputil.DumpResponse(res, true)
+func dumpResponse(res *http.Response, shouldDisplayBody bool) {
+	dumpedResponse, err := httputil.DumpResponse(res, shouldDisplayBody)
 	if err != nil {
 		trace.Logger.Printf(T("Error dumping response\n{{.Err}}\n", map[string]interface{}{"Err": err}))
 	} else {
 		trace.Logger.Printf("\n%s [%s]\n%s\n", terminal.HeaderColor(T("RESPONSE:")), time.Now().Format(time.RFC3339), trace.Sanitize(string(dumpedResponse)))
+		if !shouldDisplayBody {
+			trace.Logger.Println(T("[BINARY CONTENT HIDDEN]"))
+		}
 	}
 }
 
@@ -91,12 +94,12 @@ func WrapNetworkErrors(host string, err error) error {
 			return errors.NewInvalidSSLCert(host, "")
 		case *net.OpError:
 			if typedInnerErr.Op == "dial" {
-				return fmt.Errorf("%s: %s\n%s", T("Error performing request"), err.Error(), T("TIP: If you are behind a firewall and require an HTTP proxy, verify the https_proxy environment variable is correctly set. Else, check your network connection."))
+				return errors.NewNetworkError(fmt.Sprintf("%s: %s\n%s", T("Error performing request"), err.Error(), T("TIP: If you are behind a firewall and require an HTTP proxy, verify the https_proxy environment variable is correctly set. Else, check your network connection.")))
 			}
 		}
 	}
 
-	return fmt.Errorf("%s: %s", T("Error performing request"), err.Error())
+	return errors.NewNetworkError(fmt.Sprintf("%s: %s", T("Error performing request"), err.Error()))
 }
 
 func getBaseDomain(host string) string {
@@ -139,6 +139,9 @@ var _ = Describe("HTTP Client", func() {
 
 			_, ok := err.(*errors.InvalidSSLCert)
 			Expect(ok).To(BeFalse())
+
+			_, ok = err.(*errors.NetworkError)
+			Expect(ok).To(BeTrue())
 		})
 
 		It("returns an error with a tip when it is a tcp dial error", func() {
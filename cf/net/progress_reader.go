@@ -3,30 +3,71 @@ package net
 import (
 	"io"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cloudfoundry/cli/cf/formatters"
+	. "github.com/cloudfoundry/cli/cf/i18n"
 	"github.com/cloudfoundry/cli/cf/terminal"
 )
 
 type ProgressReader struct {
 	ioReadSeeker   io.ReadSeeker
+	ioReadCloser   io.ReadCloser
 	bytesRead      int64
 	total          int64
 	quit           chan bool
+	quitMutex      *sync.Mutex
+	done           bool
 	ui             terminal.UI
 	outputInterval time.Duration
+	tty            bool
+	startTime      time.Time
+	lineLength     int
+	summaryOnly    bool
 }
 
 func NewProgressReader(readSeeker io.ReadSeeker, ui terminal.UI, outputInterval time.Duration) *ProgressReader {
 	return &ProgressReader{
 		ioReadSeeker:   readSeeker,
+		quitMutex:      &sync.Mutex{},
 		ui:             ui,
 		outputInterval: outputInterval,
+		tty:            terminal.IsTerminal(),
 	}
 }
 
+// NewStreamProgressReader reports the progress of a body that is generated
+// while it is read. It is done once the stream reaches EOF or is closed.
+func NewStreamProgressReader(readCloser io.ReadCloser, ui terminal.UI, outputInterval time.Duration) *ProgressReader {
+	return &ProgressReader{
+		ioReadCloser:   readCloser,
+		quitMutex:      &sync.Mutex{},
+		ui:             ui,
+		outputInterval: outputInterval,
+		tty:            terminal.IsTerminal(),
+	}
+}
+
+// SetTTY chooses between updating a single progress line, when the output
+// is a terminal, and printing a new line at each interval, for logs.
+func (progressReader *ProgressReader) SetTTY(tty bool) {
+	progressReader.tty = tty
+}
+
+// SetSummaryOnly replaces the progress lines by a single line once the
+// upload is done, for output that is buffered and shown later.
+func (progressReader *ProgressReader) SetSummaryOnly(summaryOnly bool) {
+	progressReader.summaryOnly = summaryOnly
+}
+
 func (progressReader *ProgressReader) Read(p []byte) (int, error) {
+	if progressReader.ioReadCloser != nil {
+		return progressReader.readStream(p)
+	}
+
 	if progressReader.ioReadSeeker == nil {
 		return 0, os.ErrInvalid
 	}
@@ -35,15 +76,12 @@ func (progressReader *ProgressReader) Read(p []byte) (int, error) {
 
 	if progressReader.total > int64(0) {
 		if n > 0 {
-			if progressReader.quit == nil {
-				progressReader.quit = make(chan bool)
-				go progressReader.printProgress(progressReader.quit)
-			}
+			progressReader.startPrinting()
 
-			progressReader.bytesRead += int64(n)
+			bytesRead := atomic.AddInt64(&progressReader.bytesRead, int64(n))
 
-			if progressReader.total == progressReader.bytesRead {
-				progressReader.quit <- true
+			if progressReader.total == bytesRead {
+				progressReader.stopPrinting()
 				return n, err
 			}
 		}
@@ -52,27 +90,137 @@ func (progressReader *ProgressReader) Read(p []byte) (int, error) {
 	return n, err
 }
 
+func (progressReader *ProgressReader) readStream(p []byte) (int, error) {
+	n, err := progressReader.ioReadCloser.Read(p)
+
+	if n > 0 {
+		progressReader.startPrinting()
+		atomic.AddInt64(&progressReader.bytesRead, int64(n))
+	}
+
+	if err == io.EOF {
+		progressReader.stopPrinting()
+	}
+
+	return n, err
+}
+
 func (progressReader *ProgressReader) Seek(offset int64, whence int) (int64, error) {
+	if progressReader.ioReadSeeker == nil {
+		return 0, os.ErrInvalid
+	}
 	return progressReader.ioReadSeeker.Seek(offset, whence)
 }
 
+func (progressReader *ProgressReader) Close() error {
+	progressReader.stopPrinting()
+
+	if progressReader.ioReadCloser != nil {
+		return progressReader.ioReadCloser.Close()
+	}
+	return nil
+}
+
+func (progressReader *ProgressReader) startPrinting() {
+	progressReader.quitMutex.Lock()
+	defer progressReader.quitMutex.Unlock()
+
+	if progressReader.startTime.IsZero() {
+		progressReader.startTime = time.Now()
+	}
+
+	if progressReader.quit == nil && !progressReader.done && !progressReader.summaryOnly {
+		progressReader.quit = make(chan bool)
+		go progressReader.printProgress(progressReader.quit)
+	}
+}
+
+func (progressReader *ProgressReader) stopPrinting() {
+	progressReader.quitMutex.Lock()
+	defer progressReader.quitMutex.Unlock()
+
+	if progressReader.quit != nil && !progressReader.done {
+		progressReader.quit <- true
+		<-progressReader.quit
+	}
+
+	if progressReader.summaryOnly && !progressReader.done && !progressReader.startTime.IsZero() {
+		elapsed := time.Since(progressReader.startTime)
+		progressReader.ui.Say(T("Done uploading {{.Sent}} in {{.Duration}}", map[string]interface{}{
+			"Sent":     formatters.ByteSize(atomic.LoadInt64(&progressReader.bytesRead)),
+			"Duration": time.Duration(elapsed.Seconds()) * time.Second,
+		}))
+	}
+	progressReader.done = true
+}
+
 func (progressReader *ProgressReader) printProgress(quit chan bool) {
 	timer := time.NewTicker(progressReader.outputInterval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-quit:
-			//The spaces are there to ensure we overwrite the entire line
-			//before using the terminal printer to output Done Uploading
-			progressReader.ui.PrintCapturingNoOutput("\r                             ")
-			progressReader.ui.Say("\rDone uploading")
+			if progressReader.tty {
+				//The spaces are there to ensure we overwrite the entire line
+				//before using the terminal printer to output Done Uploading
+				progressReader.ui.PrintCapturingNoOutput("\r%s", strings.Repeat(" ", progressReader.lineLength))
+				progressReader.ui.Say("\r" + T("Done uploading"))
+			} else {
+				progressReader.ui.Say(T("Done uploading"))
+			}
+			quit <- true
 			return
 		case <-timer.C:
-			progressReader.ui.PrintCapturingNoOutput("\r%s uploaded...", formatters.ByteSize(progressReader.bytesRead))
+			line := progressReader.progressLine(time.Since(progressReader.startTime))
+			if progressReader.tty {
+				progressReader.ui.PrintCapturingNoOutput("\r%s", line)
+				if len(line) > progressReader.lineLength {
+					progressReader.lineLength = len(line)
+				}
+			} else {
+				progressReader.ui.Say(line)
+			}
 		}
 	}
 }
 
+// progressLine describes the bytes sent so far and the transfer rate, and
+// when the total size is known, the percentage done and the time left.
+func (progressReader *ProgressReader) progressLine(elapsed time.Duration) string {
+	bytesRead := atomic.LoadInt64(&progressReader.bytesRead)
+
+	var rate int64
+	if elapsed > 0 {
+		rate = int64(float64(bytesRead) / elapsed.Seconds())
+	}
+
+	total := atomic.LoadInt64(&progressReader.total)
+	if total <= 0 {
+		return T("{{.Sent}} uploaded, {{.Rate}}/s...", map[string]interface{}{
+			"Sent": formatters.ByteSize(bytesRead),
+			"Rate": formatters.ByteSize(rate),
+		})
+	}
+
+	if bytesRead > total {
+		bytesRead = total
+	}
+
+	remaining := T("unknown")
+	if rate > 0 {
+		remaining = (time.Duration((total-bytesRead)/rate) * time.Second).String()
+	}
+
+	return T("{{.Sent}} of {{.Total}} uploaded ({{.Percent}}%), {{.Rate}}/s, {{.Remaining}} left...", map[string]interface{}{
+		"Sent":      formatters.ByteSize(bytesRead),
+		"Total":     formatters.ByteSize(total),
+		"Percent":   bytesRead * 100 / total,
+		"Rate":      formatters.ByteSize(rate),
+		"Remaining": remaining,
+	})
+}
+
 func (progressReader *ProgressReader) SetTotalSize(size int64) {
-	progressReader.total = size
+	atomic.StoreInt64(&progressReader.total, size)
 }
@@ -37,6 +37,8 @@ var _ = Describe("ProgressReader", func() {
 	})
 
 	It("prints progress while content is being read", func() {
+		progressReader.SetTTY(true)
+
 		for {
 			time.Sleep(50 * time.Microsecond)
 			_, err := progressReader.Read(b)
@@ -45,11 +47,32 @@ var _ = Describe("ProgressReader", func() {
 			}
 		}
 
-		Expect(ui.UncapturedOutput).To(ContainSubstrings([]string{"\r", "uploaded..."}))
+		Expect(ui.UncapturedOutput).To(ContainSubstrings([]string{"\r", "of", "uploaded (", "%), ", "/s, ", "left..."}))
 		Expect(ui.UncapturedOutput).To(ContainSubstrings([]string{"\r    "}))
 		Expect(ui.Outputs).To(ContainSubstrings([]string{"\rDone "}))
 	})
 
+	It("prints separate log lines when the output is not a terminal", func() {
+		progressReader.SetTTY(false)
+
+		for {
+			time.Sleep(50 * time.Microsecond)
+			_, err := progressReader.Read(b)
+			if err != nil {
+				break
+			}
+		}
+
+		Expect(ui.UncapturedOutput).To(BeEmpty())
+		Expect(ui.Outputs).To(ContainSubstrings(
+			[]string{"of", "uploaded ("},
+			[]string{"Done uploading"},
+		))
+		for _, line := range ui.Outputs {
+			Expect(line).NotTo(HavePrefix("\r"))
+		}
+	})
+
 	It("reads the correct number of bytes", func() {
 		bytesRead := 0
 
@@ -64,4 +87,66 @@ var _ = Describe("ProgressReader", func() {
 
 		Expect(int64(bytesRead)).To(Equal(fileStat.Size()))
 	})
+
+	Describe("streams", func() {
+		BeforeEach(func() {
+			progressReader = NewStreamProgressReader(testFile, ui, 1*time.Millisecond)
+		})
+
+		It("reports being done uploading once the stream ends", func() {
+			progressReader.SetTTY(true)
+
+			for {
+				time.Sleep(50 * time.Microsecond)
+				_, err := progressReader.Read(b)
+				if err != nil {
+					break
+				}
+			}
+
+			Expect(ui.UncapturedOutput).To(ContainSubstrings([]string{"\r", "uploaded, ", "/s..."}))
+			Expect(ui.Outputs).To(ContainSubstrings([]string{"\rDone "}))
+		})
+
+		It("reports the progress against the expected total when it is given", func() {
+			progressReader.SetTTY(true)
+			progressReader.SetTotalSize(fileStat.Size())
+
+			for {
+				time.Sleep(50 * time.Microsecond)
+				_, err := progressReader.Read(b)
+				if err != nil {
+					break
+				}
+			}
+
+			Expect(ui.UncapturedOutput).To(ContainSubstrings([]string{"of", "uploaded (", "left..."}))
+		})
+
+		It("only reports a summary once done when asked to", func() {
+			progressReader.SetTTY(true)
+			progressReader.SetSummaryOnly(true)
+			progressReader.SetTotalSize(fileStat.Size())
+
+			for {
+				time.Sleep(50 * time.Microsecond)
+				_, err := progressReader.Read(b)
+				if err != nil {
+					break
+				}
+			}
+			Expect(progressReader.Close()).To(Succeed())
+
+			Expect(ui.UncapturedOutput).To(BeEmpty())
+			Expect(ui.Outputs).To(HaveLen(1))
+			Expect(ui.Outputs[0]).To(MatchRegexp(`^Done uploading \S+ in \d+s$`))
+		})
+
+		It("closes the underlying stream", func() {
+			Expect(progressReader.Close()).To(Succeed())
+
+			_, err := testFile.Read(b)
+			Expect(err).To(HaveOccurred())
+		})
+	})
 })
@@ -0,0 +1,76 @@
+package terminal
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// BufferedPrinter keeps output in memory until it is taken with TakeLines,
+// so that the output of concurrent work can be printed as one group.
+type BufferedPrinter struct {
+	prefix string
+	buffer bytes.Buffer
+	mutex  sync.Mutex
+}
+
+func NewBufferedPrinter(prefix string) *BufferedPrinter {
+	return &BufferedPrinter{prefix: prefix}
+}
+
+func (p *BufferedPrinter) Print(values ...interface{}) (n int, err error) {
+	return p.write(fmt.Sprint(values...))
+}
+
+func (p *BufferedPrinter) Printf(format string, a ...interface{}) (n int, err error) {
+	return p.write(fmt.Sprintf(format, a...))
+}
+
+func (p *BufferedPrinter) Println(values ...interface{}) (n int, err error) {
+	return p.write(fmt.Sprintln(values...))
+}
+
+func (p *BufferedPrinter) ForcePrint(values ...interface{}) (n int, err error) {
+	return p.Print(values...)
+}
+
+func (p *BufferedPrinter) ForcePrintf(format string, a ...interface{}) (n int, err error) {
+	return p.Printf(format, a...)
+}
+
+func (p *BufferedPrinter) ForcePrintln(values ...interface{}) (n int, err error) {
+	return p.Println(values...)
+}
+
+// TakeLines returns the complete lines printed so far, each one starting with
+// the prefix, and removes them from the buffer. Incomplete lines are kept
+// until they are finished, unless flushAll is set.
+func (p *BufferedPrinter) TakeLines(flushAll bool) []string {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	output := p.buffer.String()
+	end := strings.LastIndex(output, "\n") + 1
+	if flushAll {
+		end = len(output)
+	}
+	p.buffer.Reset()
+	p.buffer.WriteString(output[end:])
+
+	if end == 0 {
+		return []string{}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(output[:end], "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = p.prefix + line
+	}
+	return lines
+}
+
+func (p *BufferedPrinter) write(output string) (int, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return p.buffer.WriteString(output)
+}
@@ -0,0 +1,56 @@
+package terminal_test
+
+import (
+	. "github.com/cloudfoundry/cli/cf/terminal"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BufferedPrinter", func() {
+	var printer *BufferedPrinter
+
+	BeforeEach(func() {
+		printer = NewBufferedPrinter("[my-app] ")
+	})
+
+	It("returns the complete lines with the prefix", func() {
+		printer.Printf("Creating %s...\n", "my-app")
+		printer.Println("OK")
+		printer.Print("Uploading")
+
+		Expect(printer.TakeLines(false)).To(Equal([]string{
+			"[my-app] Creating my-app...",
+			"[my-app] OK",
+		}))
+	})
+
+	It("keeps incomplete lines until they are finished", func() {
+		printer.Print("Uploading")
+		Expect(printer.TakeLines(false)).To(BeEmpty())
+
+		printer.Print(" my-app\n")
+		Expect(printer.TakeLines(false)).To(Equal([]string{"[my-app] Uploading my-app"}))
+		Expect(printer.TakeLines(false)).To(BeEmpty())
+	})
+
+	It("returns incomplete lines when flushing everything", func() {
+		printer.Print("...")
+		Expect(printer.TakeLines(true)).To(Equal([]string{"[my-app] ..."}))
+	})
+
+	It("can be used from several goroutines", func() {
+		done := make(chan bool)
+		for i := 0; i < 10; i++ {
+			go func() {
+				printer.Println("line")
+				done <- true
+			}()
+		}
+		for i := 0; i < 10; i++ {
+			<-done
+		}
+
+		Expect(printer.TakeLines(false)).To(HaveLen(10))
+	})
+})
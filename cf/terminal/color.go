@@ -136,6 +136,20 @@ func LogSysHeaderColor(message string) string {
 	return ColorizeBold(message, cyan)
 }
 
+var logAppNameColors = []Color{cyan, green, yellow, magenta, grey}
+
+// LogAppNameColor colors the name of the index-th app when the logs of several
+// apps are shown together.
+func LogAppNameColor(message string, index int) string {
+	return ColorizeBold(message, logAppNameColors[index%len(logAppNameColors)])
+}
+
 func isTerminal() bool {
 	return terminal.IsTerminal(1)
 }
+
+// IsTerminal tells whether the standard output is a terminal, as opposed to
+// a file or a pipe.
+func IsTerminal() bool {
+	return isTerminal()
+}
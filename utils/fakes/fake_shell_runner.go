@@ -0,0 +1,60 @@
+// This file was generated by counterfeiter
+package fakes
+
+import (
+	"io"
+	"sync"
+
+	"github.com/cloudfoundry/cli/utils"
+)
+
+type FakeShellRunner struct {
+	RunStub        func(command string, dir string, env []string, output io.Writer) error
+	runMutex       sync.RWMutex
+	runArgsForCall []struct {
+		command string
+		dir     string
+		env     []string
+		output  io.Writer
+	}
+	runReturns struct {
+		result1 error
+	}
+}
+
+func (fake *FakeShellRunner) Run(command string, dir string, env []string, output io.Writer) error {
+	fake.runMutex.Lock()
+	fake.runArgsForCall = append(fake.runArgsForCall, struct {
+		command string
+		dir     string
+		env     []string
+		output  io.Writer
+	}{command, dir, env, output})
+	fake.runMutex.Unlock()
+	if fake.RunStub != nil {
+		return fake.RunStub(command, dir, env, output)
+	} else {
+		return fake.runReturns.result1
+	}
+}
+
+func (fake *FakeShellRunner) RunCallCount() int {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return len(fake.runArgsForCall)
+}
+
+func (fake *FakeShellRunner) RunArgsForCall(i int) (string, string, []string, io.Writer) {
+	fake.runMutex.RLock()
+	defer fake.runMutex.RUnlock()
+	return fake.runArgsForCall[i].command, fake.runArgsForCall[i].dir, fake.runArgsForCall[i].env, fake.runArgsForCall[i].output
+}
+
+func (fake *FakeShellRunner) RunReturns(result1 error) {
+	fake.RunStub = nil
+	fake.runReturns = struct {
+		result1 error
+	}{result1}
+}
+
+var _ utils.ShellRunner = new(FakeShellRunner)
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+type ShellRunner interface {
+	Run(command string, dir string, env []string, output io.Writer) error
+}
+
+type shellRunner struct{}
+
+func NewShellRunner() ShellRunner {
+	return shellRunner{}
+}
+
+// Run runs command with the shell of the platform, in dir, with env added to
+// the environment of the CLI. The standard and error outputs both go to
+// output.
+func (shellRunner) Run(command string, dir string, env []string, output io.Writer) error {
+	var shellCommand *exec.Cmd
+	if runtime.GOOS == "windows" {
+		shellCommand = exec.Command("cmd", "/C", command)
+	} else {
+		shellCommand = exec.Command("sh", "-c", command)
+	}
+
+	shellCommand.Dir = dir
+	shellCommand.Env = append(os.Environ(), env...)
+	shellCommand.Stdout = output
+	shellCommand.Stderr = output
+
+	return shellCommand.Run()
+}
@@ -0,0 +1,60 @@
+package utils_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	. "github.com/cloudfoundry/cli/utils"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ShellRunner", func() {
+	var (
+		runner ShellRunner
+		dir    string
+		output *bytes.Buffer
+	)
+
+	BeforeEach(func() {
+		if runtime.GOOS == "windows" {
+			Skip("The commands of these tests are written for sh")
+		}
+
+		runner = NewShellRunner()
+		output = &bytes.Buffer{}
+
+		var err error
+		dir, err = ioutil.TempDir("", "shell-runner")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("runs the command in the given directory", func() {
+		err := runner.Run("echo hello > greeting.txt", dir, nil, output)
+		Expect(err).NotTo(HaveOccurred())
+
+		contents, err := ioutil.ReadFile(filepath.Join(dir, "greeting.txt"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(string(contents)).To(Equal("hello\n"))
+	})
+
+	It("adds the given variables to the environment", func() {
+		err := runner.Run("echo $CF_APP_NAME", dir, []string{"CF_APP_NAME=my-app"}, output)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(output.String()).To(Equal("my-app\n"))
+	})
+
+	It("writes both outputs and returns an error when the command fails", func() {
+		err := runner.Run("echo out; echo err >&2; exit 3", dir, nil, output)
+		Expect(err).To(HaveOccurred())
+		Expect(output.String()).To(Equal("out\nerr\n"))
+	})
+})
@@ -3,6 +3,7 @@ package generator
 import (
 	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	. "github.com/cloudfoundry/cli/words"
@@ -14,11 +15,15 @@ type WordGenerator interface {
 
 type wordGenerator struct {
 	numberGenerator *rand.Rand
+	mutex           *sync.Mutex
 	adjectives      []string
 	nouns           []string
 }
 
 func (wg wordGenerator) Babble() (word string) {
+	wg.mutex.Lock()
+	defer wg.mutex.Unlock()
+
 	idx := int(wg.numberGenerator.Int()) % len(wg.adjectives)
 	word = wg.adjectives[idx] + "-"
 	idx = int(wg.numberGenerator.Int()) % len(wg.nouns)
@@ -35,5 +40,6 @@ func NewWordGenerator() WordGenerator {
 		adjectives:      strings.Split(string(adjectiveBytes), "\n"),
 		nouns:           strings.Split(string(nounBytes), "\n"),
 		numberGenerator: rand.New(source),
+		mutex:           &sync.Mutex{},
 	}
 }